@@ -0,0 +1,53 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/cecepsprd/ticketing-api/cmd/server"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/spf13/cobra"
+)
+
+// productCmd represents the product command
+var productCmd = &cobra.Command{
+	Use:   "product",
+	Short: "inspect products",
+}
+
+var productListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the products as JSON",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var filter model.ProductFilter
+		filter.CategoryID, _ = cmd.Flags().GetInt64("category")
+		filter.Tag, _ = cmd.Flags().GetString("tag")
+		filter.WithDeleted, _ = cmd.Flags().GetBool("with-deleted")
+		filter.Currency, _ = cmd.Flags().GetString("currency")
+
+		server.RunProductList(filter)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(productCmd)
+	productCmd.AddCommand(productListCmd)
+
+	productListCmd.Flags().Int64("category", 0, "only list the products of a category and its subcategories")
+	productListCmd.Flags().String("tag", "", "only list the products with a tag")
+	productListCmd.Flags().Bool("with-deleted", false, "also list the soft deleted products")
+	productListCmd.Flags().String("currency", "", "also show the prices in a currency")
+}
@@ -0,0 +1,40 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/cecepsprd/ticketing-api/cmd/server"
+	"github.com/spf13/cobra"
+)
+
+// reconcileCmd represents the reconcile command
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "reconcile pending payments",
+	Long:  `reconcile checks the pending transactions against the payment gateway and applies the notifications it missed`,
+	Run: func(cmd *cobra.Command, args []string) {
+		report, _ := cmd.Flags().GetBool("report")
+		date, _ := cmd.Flags().GetString("date")
+
+		server.RunReconcile(report, date)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+	reconcileCmd.Flags().Bool("report", false, "print the reconciliation report instead of reconciling")
+	reconcileCmd.Flags().String("date", "", "day of the report as YYYY-MM-DD (default yesterday)")
+}
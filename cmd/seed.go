@@ -0,0 +1,42 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/cecepsprd/ticketing-api/cmd/server"
+	"github.com/spf13/cobra"
+)
+
+// seedCmd represents the seed command
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "seed demo data",
+	Long:  `seed creates an admin, sample users and products and optionally paid transactions from a YAML or JSON fixture. Records that already exist are skipped, so it can be run again safely.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		transactions, _ := cmd.Flags().GetInt("transactions")
+		tickets, _ := cmd.Flags().GetBool("tickets")
+
+		server.RunSeed(file, transactions, tickets)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(seedCmd)
+	seedCmd.Flags().String("file", "", "YAML or JSON fixture file (default the built-in demo fixture)")
+	seedCmd.Flags().Int("transactions", -1, "paid transactions per product, overrides the fixture")
+	seedCmd.Flags().Bool("tickets", false, "render the PDF ticket of every seeded transaction")
+}
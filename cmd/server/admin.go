@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+// UserOptions are the flags of the user create command
+type UserOptions struct {
+	Username string
+	Password string
+	Email    string
+	Phone    string
+	Address  string
+	Role     string
+}
+
+// RunUserCreate creates a user with any role, it is the way to create the
+// first admin. Without a password a random one is generated and printed once.
+func RunUserCreate(options UserOptions) {
+	if options.Role != constans.RoleAdmin && options.Role != constans.RoleUser {
+		log.Fatalf("invalid role %s, expected %s or %s", options.Role, constans.RoleAdmin, constans.RoleUser)
+	}
+
+	a := newApp(constans.StorageSQL)
+
+	password := options.Password
+	if password == "" {
+		password = generatePassword()
+	}
+
+	err := a.userService.Create(context.Background(), model.User{
+		Username: options.Username,
+		Password: password,
+		Email:    options.Email,
+		Phone:    options.Phone,
+		Address:  options.Address,
+		Roles:    options.Role,
+	})
+	if err != nil {
+		log.Fatal("error creating user: ", err)
+	}
+
+	fmt.Printf("created %s %s\n", options.Role, options.Username)
+	if options.Password == "" {
+		fmt.Println("password:", password)
+	}
+}
+
+func RunUserSetRole(username string, role string) {
+	a := newApp(constans.StorageSQL)
+
+	if err := a.userService.SetRole(context.Background(), username, role); err != nil {
+		log.Fatal("error setting role: ", err)
+	}
+
+	fmt.Printf("%s is now %s\n", username, role)
+}
+
+// RunUserResetPassword sets the password of a user, without a password a
+// random one is generated and printed once
+func RunUserResetPassword(username string, password string) {
+	a := newApp(constans.StorageSQL)
+
+	generated := password == ""
+	if generated {
+		password = generatePassword()
+	}
+
+	if err := a.userService.ResetPassword(context.Background(), username, password); err != nil {
+		log.Fatal("error resetting password: ", err)
+	}
+
+	fmt.Printf("password of %s was reset\n", username)
+	if generated {
+		fmt.Println("password:", password)
+	}
+}
+
+func RunProductList(filter model.ProductFilter) {
+	a := newApp(constans.StorageSQL)
+
+	products, err := a.productService.Read(context.Background(), filter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printJSON(products)
+}
+
+func RunTransactionShow(transactionID int64) {
+	a := newApp(constans.StorageSQL)
+
+	transaction, err := a.transactionService.ReadByID(context.Background(), transactionID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printJSON(transaction)
+}
+
+func RunTransactionCancel(transactionID int64) {
+	a := newApp(constans.StorageSQL)
+
+	if err := a.transactionService.Cancel(context.Background(), transactionID); err != nil {
+		log.Fatal("error cancelling transaction: ", err)
+	}
+
+	fmt.Printf("cancelled transaction %d\n", transactionID)
+}
+
+// RunTokenIssue prints a token of a user valid for ttl, for scripts and support
+func RunTokenIssue(username string, ttl time.Duration) {
+	a := newApp(constans.StorageSQL)
+
+	token, err := a.authService.IssueToken(context.Background(), username, ttl)
+	if err != nil {
+		log.Fatal("error issuing token: ", err)
+	}
+
+	fmt.Println(token.Token)
+}
+
+func generatePassword() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
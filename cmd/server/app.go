@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/config"
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils/eventbus"
+	"github.com/cecepsprd/ticketing-api/utils/exchange"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/cecepsprd/ticketing-api/utils/notification"
+	"github.com/cecepsprd/ticketing-api/utils/pricing"
+)
+
+// app holds the services shared by the server and the worker
+type app struct {
+	cfg          config.Config
+	db           *sql.DB
+	replicas     *repository.Replicas
+	rateProvider exchange.Provider
+
+	idempotencyService  service.IdempotencyService
+	eventService        service.EventService
+	webhookService      service.WebhookService
+	jobService          service.JobService
+	userService         service.UserService
+	exchangeService     service.ExchangeService
+	voucherService      service.VoucherService
+	waitlistService     service.WaitlistService
+	waitingRoomService  service.WaitingRoomService
+	authService         service.AuthService
+	productService      service.ProductService
+	transactionService  service.TransactionService
+	venueService        service.VenueService
+	categoryService     service.CategoryService
+	sessionService      service.SessionService
+	availabilityService service.AvailabilityService
+	ticketService       service.TicketService
+	reconcileService    service.ReconciliationService
+	seedService         service.SeedService
+}
+
+// newApp builds the app on the given storage, the database of the config backs
+// the sql storage and the memory storage needs none
+func newApp(storage string) *app {
+	var (
+		cfg      = config.NewConfig()
+		db       *sql.DB
+		replicas *repository.Replicas
+		repos    repositories
+		err      error
+	)
+
+	if storage == constans.StorageMemory {
+		repos = memoryRepositories()
+	} else {
+		db, err = cfg.Connect()
+		if err != nil {
+			log.Fatal("error connecting to database: ", err.Error())
+		}
+
+		var replicaDBs []*sql.DB
+		replicaDBs, err = cfg.ConnectReplicas()
+		if err != nil {
+			log.Fatal("error connecting to read replicas: ", err.Error())
+		}
+
+		replicas = repository.NewReplicas(replicaDBs)
+		repos = sqlRepositories(db, repository.NewDialect(cfg.MysqlDB.DriverName()), replicas)
+
+		repos, err = cacheRepositories(repos, cfg.Cache)
+		if err != nil {
+			log.Fatal("error setting up the cache: ", err.Error())
+		}
+	}
+
+	if err = logger.Init(cfg.App.LogLevel, cfg.App.LogTimeFormat); err != nil {
+		log.Fatal(err)
+	}
+
+	timeoutContext := time.Duration(cfg.App.ContextTimeout) * time.Second
+
+	waitlistOfferWindow := time.Duration(cfg.App.WaitlistOfferWindow) * time.Minute
+	idempotencyRetention := time.Duration(cfg.App.IdempotencyRetention) * time.Hour
+	reconcileAfter := time.Duration(cfg.App.ReconcileAfter) * time.Minute
+	reconcileExpireAfter := time.Duration(cfg.App.ReconcileExpireAfter) * time.Hour
+
+	pricingRules := pricing.Rules{
+		PlatformFeeBPS:   cfg.Pricing.PlatformFeeBPS,
+		PlatformFeeFixed: cfg.Pricing.PlatformFeeFixed,
+		FeeCurrency:      constans.DefaultCurrency,
+		TaxBPS:           cfg.Pricing.TaxBPS,
+		Surcharges:       pricing.ParseValues(cfg.Pricing.PaymentSurcharges),
+		RoundTo:          pricing.ParseValues(cfg.Pricing.RoundTo),
+	}
+
+	var rateProvider exchange.Provider
+	if cfg.Pricing.ExchangeRateFile != "" {
+		rateProvider = exchange.NewFileProvider(cfg.Pricing.ExchangeRateFile)
+	}
+
+	jobService := service.NewJobService(repos.job, timeoutContext)
+	notifier := service.NewQueuedNotifier(jobService)
+	bus := eventbus.New()
+	service.SubscribeNotifications(bus, repos.user, repos.product, notifier)
+	service.SubscribeJobs(bus, jobService)
+
+	webhookService := service.NewWebhookService(repos.webhook, timeoutContext)
+	service.SubscribeWebhooks(bus, webhookService)
+
+	a := &app{
+		cfg:          cfg,
+		db:           db,
+		replicas:     replicas,
+		rateProvider: rateProvider,
+		jobService:   jobService,
+	}
+
+	a.webhookService = webhookService
+	a.idempotencyService = service.NewIdempotencyService(repos.idempotency, idempotencyRetention, timeoutContext)
+	a.eventService = service.NewEventService(repos.outbox, bus, timeoutContext)
+	a.userService = service.NewUserService(repos.unitOfWork, repos.user, a.eventService, timeoutContext)
+	a.exchangeService = service.NewExchangeService(repos.exchangeRate, rateProvider, constans.DefaultCurrency, timeoutContext)
+	a.voucherService = service.NewVoucherService(repos.voucher, timeoutContext)
+	a.waitlistService = service.NewWaitlistService(repos.waitlist, repos.product, repos.session, repos.user, notifier, waitlistOfferWindow, timeoutContext)
+	a.waitingRoomService = service.NewWaitingRoomService(repos.unitOfWork, repos.waitingRoom, repos.product, cfg.App.JWTSecret, timeoutContext)
+	a.authService = service.NewAuthService(a.userService, cfg.App.JWTSecret)
+	a.productService = service.NewProductService(repos.product, repos.category, repos.session, repos.transaction, a.waitlistService, a.exchangeService, a.eventService, timeoutContext)
+	a.transactionService = service.NewTransactionService(repos.unitOfWork, repos.transaction, repos.product, repos.seat, repos.session, a.waitlistService, a.waitingRoomService, a.voucherService, a.exchangeService, a.eventService, pricingRules, timeoutContext)
+	a.venueService = service.NewVenueService(repos.unitOfWork, repos.venue, repos.seat, repos.product, repos.session, a.eventService, timeoutContext)
+	a.categoryService = service.NewCategoryService(repos.category, timeoutContext)
+	a.sessionService = service.NewSessionService(repos.unitOfWork, repos.session, repos.product, repos.seat, a.waitlistService, a.eventService, timeoutContext)
+	a.availabilityService = service.NewAvailabilityService(repos.product, repos.session, repos.seat, repos.outbox, cfg.App.JWTSecret, timeoutContext)
+	a.ticketService = service.NewTicketService(repos.transaction, repos.product, repos.session, repos.seat, repos.user, cfg.App.TicketDir, timeoutContext)
+
+	a.reconcileService = service.NewReconciliationService(repos.reconciliation, repos.transaction, repos.user, a.transactionService, notifier, reconcileAfter, reconcileExpireAfter, timeoutContext)
+	a.seedService = service.NewSeedService(repos.unitOfWork, repos.user, repos.product, repos.transaction, a.ticketService, timeoutContext)
+
+	service.RegisterJobs(jobService, repos.user, notification.NewLogNotifier(), a.ticketService, a.waitlistService, a.idempotencyService, a.reconcileService)
+
+	return a
+}
+
+// startWorkers runs the background jobs and schedules the recurring sweeps until
+// ctx is cancelled. The returned channel is closed once the running jobs are done.
+func (a *app) startWorkers(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			service.ScheduleSweeps(ctx, a.jobService)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	go func() {
+		a.jobService.Work(ctx, a.cfg.App.WorkerConcurrency)
+		close(done)
+	}()
+
+	return done
+}
+
+// databaseStats are the pool statistics of the primary and of every read replica
+func (a *app) databaseStats() model.DatabaseStats {
+	stats := model.NewDatabaseStats(a.db.Stats())
+
+	for _, replica := range a.replicas.Status() {
+		stats.Replicas = append(stats.Replicas, model.ReplicaStats{
+			Healthy:       replica.Healthy,
+			DatabaseStats: model.NewDatabaseStats(replica.Stats),
+		})
+	}
+
+	return stats
+}
+
+// drainWorkers waits for the running jobs to finish, jobs still running after
+// WorkerDrainTimeout are run again by another worker once their lease expires
+func drainWorkers(done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(constans.WorkerDrainTimeout):
+		log.Println("worker drain timed out, unfinished jobs will be retried.")
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cecepsprd/ticketing-api/config"
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/migrations"
+	"github.com/cecepsprd/ticketing-api/utils/migrate"
+)
+
+// RunMigrate runs a migrate subcommand: up, down [steps], status, baseline <version>
+// or create <name>
+func RunMigrate(action string, args []string) {
+	var cfg = config.NewConfig()
+
+	driver := cfg.MysqlDB.DriverName()
+
+	if action == "create" {
+		up, down, err := migrate.Create(filepath.Join(constans.MigrationDir, driver), args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Println("created", up)
+		log.Println("created", down)
+		return
+	}
+
+	db, err := cfg.Connect()
+	if err != nil {
+		log.Fatal("error connecting to database: ", err.Error())
+	}
+	defer db.Close()
+
+	migrator := newMigrator(db, driver)
+
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		done, err := migrator.Up(ctx)
+		logMigrations("applied", done)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			steps, err = strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				log.Fatal("invalid steps, expected a positive number: ", args[0])
+			}
+		}
+
+		done, err := migrator.Down(ctx, steps)
+		logMigrations("reverted", done)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "baseline":
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatal("invalid version: ", args[0])
+		}
+
+		done, err := migrator.Baseline(ctx, version)
+		logMigrations("marked as applied", done)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printJSON(statuses)
+	}
+}
+
+// migrateUp applies the pending migrations before the server starts
+func migrateUp(db *sql.DB, driver string) {
+	migrator := newMigrator(db, driver)
+
+	done, err := migrator.Up(context.Background())
+	logMigrations("applied", done)
+	if err != nil {
+		log.Fatal("error migrating database: ", err)
+	}
+}
+
+func newMigrator(db *sql.DB, driver string) *migrate.Migrator {
+	files, err := migrations.For(driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	migrator, err := migrate.New(db, driver, files)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return migrator
+}
+
+func logMigrations(action string, done []migrate.Migration) {
+	for _, migration := range done {
+		log.Printf("%s migration %06d_%s", action, migration.Version, migration.Name)
+	}
+}
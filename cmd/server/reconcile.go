@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+)
+
+// RunReconcile checks the pending transactions against the payment gateway and
+// prints the outcome. With report it prints the report of date instead, which
+// defaults to the day before.
+func RunReconcile(report bool, date string) {
+	a := newApp(constans.StorageSQL)
+	ctx := context.Background()
+
+	var (
+		result interface{}
+		err    error
+	)
+
+	if report {
+		day := time.Now().AddDate(0, 0, -1)
+		if date != "" {
+			day, err = time.ParseInLocation(constans.DateFormat, date, time.Local)
+			if err != nil {
+				log.Fatal("invalid date, expected YYYY-MM-DD: ", err)
+			}
+		}
+
+		result, err = a.reconcileService.Report(ctx, day)
+	} else {
+		result, err = a.reconcileService.Reconcile(ctx)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printJSON(result)
+}
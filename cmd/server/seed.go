@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/fixtures"
+	"github.com/cecepsprd/ticketing-api/model"
+	"gopkg.in/yaml.v2"
+)
+
+// RunSeed seeds the fixture of file, or the default fixture when file is empty,
+// and prints what was created. A transactions volume of zero or more overrides
+// the one of the fixture, and tickets also renders their PDF tickets.
+func RunSeed(file string, transactions int, tickets bool) {
+	fixture, err := loadFixture(file)
+	if err != nil {
+		log.Fatal("error loading fixture: ", err)
+	}
+
+	if transactions >= 0 {
+		fixture.Transactions.PerProduct = transactions
+	}
+	if tickets {
+		fixture.Transactions.Tickets = true
+	}
+
+	a := newApp(constans.StorageSQL)
+
+	result, err := a.seedService.Seed(context.Background(), fixture)
+	if result != nil {
+		printJSON(result)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// seedMemory fills the memory storage with the default fixture, without the
+// demo transactions whose payments the gateway never saw
+func seedMemory(a *app) {
+	fixture, err := loadFixture("")
+	if err != nil {
+		log.Fatal("error loading fixture: ", err)
+	}
+
+	fixture.Transactions.PerProduct = 0
+
+	result, err := a.seedService.Seed(context.Background(), fixture)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("memory storage seeded with %d users and %d products, nothing is kept after shutdown.", result.Users, result.Products)
+}
+
+// loadFixture decodes a YAML or JSON fixture, by the extension of its file
+func loadFixture(file string) (fixture model.Fixture, err error) {
+	var content []byte
+	if file == "" {
+		file = fixtures.DefaultFile
+		content, err = fs.ReadFile(fixtures.FS, file)
+	} else {
+		content, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return fixture, err
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.Unmarshal(content, &fixture)
+	default:
+		err = yaml.UnmarshalStrict(content, &fixture)
+	}
+
+	return fixture, err
+}
@@ -3,15 +3,14 @@ package server
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/cecepsprd/ticketing-api/config"
+	"github.com/cecepsprd/ticketing-api/constans"
 	"github.com/cecepsprd/ticketing-api/handler"
-	"github.com/cecepsprd/ticketing-api/repository"
-	"github.com/cecepsprd/ticketing-api/service"
 	"github.com/cecepsprd/ticketing-api/utils/logger"
 	"github.com/cecepsprd/ticketing-api/utils/validate"
 	"github.com/labstack/echo"
@@ -19,16 +18,19 @@ import (
 	en_translations "gopkg.in/go-playground/validator.v9/translations/en"
 )
 
-func RunServer() {
-	var cfg = config.NewConfig()
-
-	db, err := cfg.MysqlConnect()
-	if err != nil {
-		log.Fatal("error connecting to database: ", err.Error())
-	}
-
-	if err = logger.Init(cfg.App.LogLevel, cfg.App.LogTimeFormat); err != nil {
-		log.Fatal(err)
+// RunServer serves the API on the given storage. On the sql storage, with migrate
+// or AUTO_MIGRATE the pending migrations are applied first. The memory storage
+// starts with the default fixture, so a demo has an admin and products to show.
+func RunServer(migrate bool, storage string) {
+	a := newApp(storage)
+
+	switch storage {
+	case constans.StorageMemory:
+		seedMemory(a)
+	default:
+		if migrate || a.cfg.App.AutoMigrate {
+			migrateUp(a.db, a.cfg.MysqlDB.DriverName())
+		}
 	}
 
 	e := echo.New()
@@ -37,25 +39,88 @@ func RunServer() {
 	en_translations.RegisterDefaultTranslations(customValidator.Validator, customValidator.Translator)
 	e.Validator = customValidator
 
-	timeoutContext := time.Duration(cfg.App.ContextTimeout) * time.Second
+	handler.NewIdempotencyHandler(e, a.idempotencyService)
+	handler.NewAuthHandler(e, a.authService)
+	handler.NewUserHandler(e, a.userService)
+	handler.NewProductHandler(e, a.productService, a.transactionService)
+	handler.NewVenueHandler(e, a.venueService)
+	handler.NewCategoryHandler(e, a.categoryService)
+	handler.NewSessionHandler(e, a.sessionService)
+	handler.NewAvailabilityHandler(e, a.availabilityService)
+	handler.NewWaitlistHandler(e, a.waitlistService)
+	handler.NewWaitingRoomHandler(e, a.waitingRoomService)
+	handler.NewVoucherHandler(e, a.voucherService)
+	handler.NewExchangeHandler(e, a.exchangeService)
+	handler.NewWebhookHandler(e, a.webhookService)
+	handler.NewJobHandler(e, a.jobService)
+	handler.NewTicketHandler(e, a.ticketService)
+	handler.NewReconciliationHandler(e, a.reconcileService)
+
+	// the memory storage has no connection pool
+	if a.db != nil {
+		handler.NewDatabaseHandler(e, a.databaseStats)
+	}
+
+	if a.rateProvider != nil {
+		if err := a.exchangeService.Refresh(context.Background()); err != nil {
+			logger.Log.Error(err.Error())
+		}
+	}
+
+	// Checking the health of the read replicas
+	replicaCtx, stopReplicas := context.WithCancel(context.Background())
+	defer stopReplicas()
+
+	if a.replicas != nil {
+		interval := time.Duration(a.cfg.MysqlDB.ReplicaCheckInterval) * time.Second
+		if interval <= 0 {
+			interval = constans.DefaultReplicaCheckInterval
+		}
+
+		go a.replicas.Watch(replicaCtx, interval)
+	}
+
+	// Dispatching domain events
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.eventService.Dispatch(context.Background())
+		}
+	}()
+
+	// Streaming the availability changed on any instance
+	go func() {
+		ticker := time.NewTicker(constans.AvailabilityFeedInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.availabilityService.Feed(context.Background())
+		}
+	}()
 
-	userRepository := repository.NewUserRepository(db)
-	productRepository := repository.NewProductRepository(db)
-	transactionRepository := repository.NewTransactionRepository(db)
+	// Delivering webhooks
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.webhookService.Deliver(context.Background())
+		}
+	}()
 
-	userService := service.NewUserService(userRepository, timeoutContext)
-	authService := service.NewAuthService(userService, cfg.App.JWTSecret)
-	productService := service.NewProductService(productRepository, timeoutContext)
-	transactionService := service.NewTransactionService(transactionRepository, productRepository, timeoutContext)
+	// Running background jobs, unless a separate worker runs them
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
 
-	handler.NewAuthHandler(e, authService)
-	handler.NewUserHandler(e, userService)
-	handler.NewProductHandler(e, productService, transactionService)
+	var workersDone <-chan struct{}
+	if !a.cfg.App.WorkerDisabled {
+		workersDone = a.startWorkers(workerCtx)
+	}
 
 	// Starting server
 	go func() {
-		err := e.Start(cfg.App.HTTPPort)
-		if err != nil {
+		err := e.Start(a.cfg.App.HTTPPort)
+		// a closed server is the shutdown below, the workers are still draining
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal("error starting server: ", err)
 		}
 	}()
@@ -69,9 +134,16 @@ func RunServer() {
 
 	log.Println("server shutdown of 5 second.")
 
+	// stop taking new jobs, the running ones are drained after the server is down
+	stopWorkers()
+
 	// gracefully shutdown the server, waiting max 5 seconds for current operations to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	e.Shutdown(ctx)
+
+	if workersDone != nil {
+		drainWorkers(workersDone)
+	}
 }
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/config"
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/repository/memory"
+	"github.com/cecepsprd/ticketing-api/utils/cache"
+	"github.com/go-redis/redis/v8"
+)
+
+// repositories are the storage every service of the app is built on
+type repositories struct {
+	unitOfWork     repository.UnitOfWork
+	user           repository.UserRepository
+	product        repository.ProductRepository
+	transaction    repository.TransactionRepository
+	venue          repository.VenueRepository
+	seat           repository.SeatRepository
+	category       repository.CategoryRepository
+	session        repository.SessionRepository
+	waitlist       repository.WaitlistRepository
+	voucher        repository.VoucherRepository
+	exchangeRate   repository.ExchangeRateRepository
+	idempotency    repository.IdempotencyRepository
+	outbox         repository.OutboxRepository
+	webhook        repository.WebhookRepository
+	job            repository.JobRepository
+	reconciliation repository.ReconciliationRepository
+	waitingRoom    repository.WaitingRoomRepository
+}
+
+// sqlRepositories stores in db, the catalogue reads that allow it are served by replicas
+func sqlRepositories(db *sql.DB, dialect repository.Dialect, replicas *repository.Replicas) repositories {
+	return repositories{
+		unitOfWork:     repository.NewUnitOfWork(db),
+		user:           repository.NewUserRepository(db, dialect),
+		product:        repository.NewProductRepository(db, dialect, replicas),
+		transaction:    repository.NewTransactionRepository(db, dialect),
+		venue:          repository.NewVenueRepository(db, dialect, replicas),
+		seat:           repository.NewSeatRepository(db, dialect, replicas),
+		category:       repository.NewCategoryRepository(db, dialect, replicas),
+		session:        repository.NewSessionRepository(db, dialect, replicas),
+		waitlist:       repository.NewWaitlistRepository(db, dialect),
+		voucher:        repository.NewVoucherRepository(db, dialect, replicas),
+		exchangeRate:   repository.NewExchangeRateRepository(db, dialect),
+		idempotency:    repository.NewIdempotencyRepository(db, dialect),
+		outbox:         repository.NewOutboxRepository(db, dialect),
+		webhook:        repository.NewWebhookRepository(db, dialect),
+		job:            repository.NewJobRepository(db, dialect),
+		reconciliation: repository.NewReconciliationRepository(db, dialect, replicas),
+		waitingRoom:    repository.NewWaitingRoomRepository(db, dialect),
+	}
+}
+
+// memoryRepositories keeps everything in process memory, nothing survives a restart
+func memoryRepositories() repositories {
+	store := memory.NewStore()
+
+	return repositories{
+		unitOfWork:     memory.NewUnitOfWork(store),
+		user:           memory.NewUserRepository(store),
+		product:        memory.NewProductRepository(store),
+		transaction:    memory.NewTransactionRepository(store),
+		venue:          memory.NewVenueRepository(store),
+		seat:           memory.NewSeatRepository(store),
+		category:       memory.NewCategoryRepository(store),
+		session:        memory.NewSessionRepository(store),
+		waitlist:       memory.NewWaitlistRepository(store),
+		voucher:        memory.NewVoucherRepository(store),
+		exchangeRate:   memory.NewExchangeRateRepository(store),
+		idempotency:    memory.NewIdempotencyRepository(store),
+		outbox:         memory.NewOutboxRepository(store),
+		webhook:        memory.NewWebhookRepository(store),
+		job:            memory.NewJobRepository(store),
+		reconciliation: memory.NewReconciliationRepository(store),
+		waitingRoom:    memory.NewWaitingRoomRepository(store),
+	}
+}
+
+// cacheRepositories serves the catalogue reads of repos from the cache of the
+// config, repos stays as it is when no cache is configured
+func cacheRepositories(repos repositories, cfg config.Cache) (repositories, error) {
+	var c cache.Cache
+
+	switch cfg.Driver {
+	case "":
+		return repos, nil
+	case constans.CacheLRU:
+		size := cfg.Size
+		if size <= 0 {
+			size = constans.DefaultCacheSize
+		}
+		c = cache.NewLRU(size)
+	case constans.CacheRedis:
+		// the client connects on first use, a Redis that is down only costs cache misses
+		c = cache.NewRedis(redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		}), constans.CachePrefix)
+	default:
+		return repos, fmt.Errorf("unsupported cache driver %s", cfg.Driver)
+	}
+
+	ttl := time.Duration(cfg.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = constans.DefaultCacheTTL
+	}
+
+	repos.product = repository.NewCachedProductRepository(repos.product, c, ttl)
+
+	return repos, nil
+}
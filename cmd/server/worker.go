@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+)
+
+// RunWorker runs the background jobs without serving the API, so they can be
+// scaled apart from the server started with WORKER_DISABLED
+func RunWorker() {
+	a := newApp(constans.StorageSQL)
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	done := a.startWorkers(ctx)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, syscall.SIGTERM)
+
+	// Block until a signal is received.
+	<-quit
+
+	log.Println("worker shutdown, draining running jobs.")
+
+	stop()
+	drainWorkers(done)
+}
@@ -16,7 +16,10 @@ limitations under the License.
 package cmd
 
 import (
+	"log"
+
 	"github.com/cecepsprd/ticketing-api/cmd/server"
+	"github.com/cecepsprd/ticketing-api/constans"
 	"github.com/spf13/cobra"
 )
 
@@ -26,10 +29,19 @@ var startCmd = &cobra.Command{
 	Short: "start",
 	Long:  `start`,
 	Run: func(cmd *cobra.Command, args []string) {
-		server.RunServer()
+		migrate, _ := cmd.Flags().GetBool("migrate")
+		storage, _ := cmd.Flags().GetString("storage")
+
+		if storage != constans.StorageSQL && storage != constans.StorageMemory {
+			log.Fatalf("invalid storage %s, expected %s or %s", storage, constans.StorageSQL, constans.StorageMemory)
+		}
+
+		server.RunServer(migrate, storage)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(startCmd)
+	startCmd.Flags().Bool("migrate", false, "apply the pending migrations before serving")
+	startCmd.Flags().String("storage", constans.StorageSQL, "sql serves the configured database, memory keeps everything in memory for demos")
 }
@@ -0,0 +1,46 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/cecepsprd/ticketing-api/cmd/server"
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/spf13/cobra"
+)
+
+// tokenCmd represents the token command
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "manage API tokens",
+}
+
+var tokenIssueCmd = &cobra.Command{
+	Use:   "issue <username>",
+	Short: "print a token of a user without its password",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+
+		server.RunTokenIssue(args[0], ttl)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenIssueCmd)
+
+	tokenIssueCmd.Flags().Duration("ttl", constans.DefaultTokenTTL, "how long the token is valid")
+}
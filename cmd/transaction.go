@@ -0,0 +1,62 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/cecepsprd/ticketing-api/cmd/server"
+	"github.com/spf13/cobra"
+)
+
+// transactionCmd represents the transaction command
+var transactionCmd = &cobra.Command{
+	Use:   "transaction",
+	Short: "inspect and cancel transactions",
+}
+
+var transactionShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "print a transaction as JSON",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		server.RunTransactionShow(transactionID(args[0]))
+	},
+}
+
+var transactionCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "cancel a pending transaction and give its tickets back",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		server.RunTransactionCancel(transactionID(args[0]))
+	},
+}
+
+func transactionID(arg string) int64 {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		log.Fatal("invalid transaction id: ", arg)
+	}
+
+	return id
+}
+
+func init() {
+	rootCmd.AddCommand(transactionCmd)
+	transactionCmd.AddCommand(transactionShowCmd, transactionCancelCmd)
+}
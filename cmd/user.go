@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/cecepsprd/ticketing-api/cmd/server"
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/spf13/cobra"
+)
+
+// userCmd represents the user command
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "manage users",
+	Long:  `user creates users with any role and manages their role and password against the configured database`,
+}
+
+var userCreateCmd = &cobra.Command{
+	Use:   "create <username>",
+	Short: "create a user, use --role admin to create an admin",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		options := server.UserOptions{Username: args[0]}
+		options.Password, _ = cmd.Flags().GetString("password")
+		options.Email, _ = cmd.Flags().GetString("email")
+		options.Phone, _ = cmd.Flags().GetString("phone")
+		options.Address, _ = cmd.Flags().GetString("address")
+		options.Role, _ = cmd.Flags().GetString("role")
+
+		server.RunUserCreate(options)
+	},
+}
+
+var userSetRoleCmd = &cobra.Command{
+	Use:   "set-role <username> <role>",
+	Short: "change the role of a user to admin or user",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		server.RunUserSetRole(args[0], args[1])
+	},
+}
+
+var userResetPasswordCmd = &cobra.Command{
+	Use:   "reset-password <username>",
+	Short: "set a new password, a random one is printed when --password is empty",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		password, _ := cmd.Flags().GetString("password")
+
+		server.RunUserResetPassword(args[0], password)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(userCmd)
+	userCmd.AddCommand(userCreateCmd, userSetRoleCmd, userResetPasswordCmd)
+
+	userCreateCmd.Flags().String("password", "", "password of the user (default a random one that is printed)")
+	userCreateCmd.Flags().String("email", "", "email of the user")
+	userCreateCmd.Flags().String("phone", "", "phone number of the user")
+	userCreateCmd.Flags().String("address", "", "address of the user")
+	userCreateCmd.Flags().String("role", constans.RoleUser, "role of the user, admin or user")
+	userCreateCmd.MarkFlagRequired("email")
+	userCreateCmd.MarkFlagRequired("phone")
+
+	userResetPasswordCmd.Flags().String("password", "", "new password (default a random one that is printed)")
+}
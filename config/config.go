@@ -19,6 +19,37 @@ type App struct {
 	ContextTimeout int `json:"context_timeout "`
 	// JWTSecret is a private jwt secret key
 	JWTSecret string `json:"jwt_secret"`
+	// WaitlistOfferWindow is how many minutes a waitlisted user has to buy an offered ticket
+	WaitlistOfferWindow int `json:"waitlist_offer_window"`
+	// IdempotencyRetention is how many hours the responses of idempotent requests are kept for retries
+	IdempotencyRetention int `json:"idempotency_retention"`
+	// WorkerConcurrency is how many background jobs run at the same time
+	WorkerConcurrency int `json:"worker_concurrency"`
+	// WorkerDisabled stops the server from running background jobs, they are run by the worker command instead
+	WorkerDisabled bool `json:"worker_disabled"`
+	// TicketDir is the directory the PDF tickets are written to
+	TicketDir string `json:"ticket_dir"`
+	// ReconcileAfter is how many minutes a transaction stays pending before it is checked against the payment gateway
+	ReconcileAfter int `json:"reconcile_after"`
+	// ReconcileExpireAfter is how many hours a transaction unknown to the payment gateway stays pending before it is cancelled
+	ReconcileExpireAfter int `json:"reconcile_expire_after"`
+	// AutoMigrate applies the pending migrations when the server starts
+	AutoMigrate bool `json:"auto_migrate"`
+}
+
+type Pricing struct {
+	// PlatformFeeBPS is the platform fee in basis points of the discounted subtotal e.g. 250 is 2.5%
+	PlatformFeeBPS int64 `json:"platform_fee_bps"`
+	// PlatformFeeFixed is a flat platform fee per transaction in minor units of the default currency
+	PlatformFeeFixed int64 `json:"platform_fee_fixed"`
+	// TaxBPS is the VAT in basis points e.g. 1100 is 11%
+	TaxBPS int64 `json:"tax_bps"`
+	// PaymentSurcharges are the surcharges per payment method in basis points e.g. credit_card=290,gopay=200
+	PaymentSurcharges string `json:"payment_surcharges"`
+	// RoundTo is the smallest payable amount in minor units per currency, fees and taxes are rounded to it e.g. IDR=100
+	RoundTo string `json:"round_to"`
+	// ExchangeRateFile is a JSON file with exchange rates loaded on start and on refresh, rates are managed by admins when empty
+	ExchangeRateFile string `json:"exchange_rate_file"`
 }
 
 type MysqlDB struct {
@@ -28,23 +59,84 @@ type MysqlDB struct {
 	Port     string `json:"port"`
 	Password string `json:"password"`
 	Driver   string `json:"driver"`
+	// MaxOpenConns limits the connections open at the same time, 0 is unlimited
+	MaxOpenConns int `json:"max_open_conns"`
+	// MaxIdleConns is how many idle connections are kept for reuse, the database/sql default of 2 when 0
+	MaxIdleConns int `json:"max_idle_conns"`
+	// ConnMaxLifetime is how many seconds a connection is reused before it is closed, 0 reuses it forever
+	ConnMaxLifetime int `json:"conn_max_lifetime"`
+	// ConnectTimeout is how many seconds dialing the database may take, 0 waits for the operating system
+	ConnectTimeout int `json:"connect_timeout"`
+	// ReadTimeout and WriteTimeout are how many seconds a single read or write on a MySQL connection may take
+	ReadTimeout  int `json:"read_timeout"`
+	WriteTimeout int `json:"write_timeout"`
+	// TLS is disable, require to encrypt without verifying the server, or verify to also check its certificate and host
+	TLS string `json:"tls"`
+	// TLSCA is a PEM file of the CA that signed the server certificate, the system CAs are used when empty.
+	// It is only used with verify, require rejects it as it does not verify the server
+	TLSCA string `json:"tls_ca"`
+	// TLSCert and TLSKey are the PEM files of a client certificate, for servers requiring one
+	TLSCert string `json:"tls_cert"`
+	TLSKey  string `json:"tls_key"`
+	// ConnectRetries is how many times connecting is tried again when the database is not up yet
+	ConnectRetries int `json:"connect_retries"`
+	// ConnectBackoff is how many seconds to wait before the first retry, it doubles with every retry
+	ConnectBackoff int `json:"connect_backoff"`
+	// Replicas are the host:port of the read replicas, comma separated. They share the name and credentials of the primary.
+	Replicas string `json:"replicas"`
+	// ReplicaCheckInterval is how many seconds pass between the health checks of the replicas
+	ReplicaCheckInterval int `json:"replica_check_interval"`
+
+	// replica opens the database without waiting for it, the health check tells when it is up
+	replica bool
+}
+
+type Cache struct {
+	// Driver is lru to cache the catalogue in process memory, redis to share the cache between instances, or empty for no cache
+	Driver string `json:"driver"`
+	// TTL is how many seconds a cached product is served before it is read again
+	TTL int `json:"ttl"`
+	// Size is how many values the lru cache keeps
+	Size int `json:"size"`
+	// RedisAddr is the host:port of the Redis of the redis cache
+	RedisAddr     string `json:"redis_addr"`
+	RedisPassword string `json:"redis_password"`
+	RedisDB       int    `json:"redis_db"`
 }
 
 type Config struct {
 	App     App
+	Pricing Pricing
 	MysqlDB MysqlDB
+	Cache   Cache
 }
 
 // LoadConfiguration will initialize fixed value for config
 func NewConfig() Config {
 	return Config{
 		App: App{
-			Name:           viper.GetString("APP_NAME"),
-			HTTPPort:       viper.GetString("HTTP_PORT"),
-			LogLevel:       viper.GetInt("LOG_LEVEL"),
-			LogTimeFormat:  viper.GetString("LOG_TIME_FORMAT"),
-			ContextTimeout: viper.GetInt("CONTEXT_TIMEOUT"),
-			JWTSecret:      viper.GetString("APP_JWT_SECRET"),
+			Name:                 viper.GetString("APP_NAME"),
+			HTTPPort:             viper.GetString("HTTP_PORT"),
+			LogLevel:             viper.GetInt("LOG_LEVEL"),
+			LogTimeFormat:        viper.GetString("LOG_TIME_FORMAT"),
+			ContextTimeout:       viper.GetInt("CONTEXT_TIMEOUT"),
+			JWTSecret:            viper.GetString("APP_JWT_SECRET"),
+			WaitlistOfferWindow:  viper.GetInt("WAITLIST_OFFER_WINDOW"),
+			IdempotencyRetention: viper.GetInt("IDEMPOTENCY_RETENTION"),
+			WorkerConcurrency:    viper.GetInt("WORKER_CONCURRENCY"),
+			WorkerDisabled:       viper.GetBool("WORKER_DISABLED"),
+			TicketDir:            viper.GetString("TICKET_DIR"),
+			ReconcileAfter:       viper.GetInt("RECONCILE_AFTER"),
+			ReconcileExpireAfter: viper.GetInt("RECONCILE_EXPIRE_AFTER"),
+			AutoMigrate:          viper.GetBool("AUTO_MIGRATE"),
+		},
+		Pricing: Pricing{
+			PlatformFeeBPS:    viper.GetInt64("PLATFORM_FEE_BPS"),
+			PlatformFeeFixed:  viper.GetInt64("PLATFORM_FEE_FIXED"),
+			TaxBPS:            viper.GetInt64("TAX_BPS"),
+			PaymentSurcharges: viper.GetString("PAYMENT_SURCHARGES"),
+			RoundTo:           viper.GetString("PRICE_ROUND_TO"),
+			ExchangeRateFile:  viper.GetString("EXCHANGE_RATE_FILE"),
 		},
 		MysqlDB: MysqlDB{
 			Name:     viper.GetString("DB_NAME"),
@@ -53,6 +145,30 @@ func NewConfig() Config {
 			Port:     viper.GetString("DB_PORT"),
 			Password: viper.GetString("DB_PASSWORD"),
 			Driver:   viper.GetString("DRIVER"),
+
+			MaxOpenConns:    viper.GetInt("DB_MAX_OPEN_CONNS"),
+			MaxIdleConns:    viper.GetInt("DB_MAX_IDLE_CONNS"),
+			ConnMaxLifetime: viper.GetInt("DB_CONN_MAX_LIFETIME"),
+			ConnectTimeout:  viper.GetInt("DB_CONNECT_TIMEOUT"),
+			ReadTimeout:     viper.GetInt("DB_READ_TIMEOUT"),
+			WriteTimeout:    viper.GetInt("DB_WRITE_TIMEOUT"),
+			TLS:             viper.GetString("DB_TLS"),
+			TLSCA:           viper.GetString("DB_TLS_CA"),
+			TLSCert:         viper.GetString("DB_TLS_CERT"),
+			TLSKey:          viper.GetString("DB_TLS_KEY"),
+			ConnectRetries:  viper.GetInt("DB_CONNECT_RETRIES"),
+			ConnectBackoff:  viper.GetInt("DB_CONNECT_BACKOFF"),
+
+			Replicas:             viper.GetString("DB_REPLICAS"),
+			ReplicaCheckInterval: viper.GetInt("DB_REPLICA_CHECK_INTERVAL"),
+		},
+		Cache: Cache{
+			Driver:        viper.GetString("CACHE_DRIVER"),
+			TTL:           viper.GetInt("CACHE_TTL"),
+			Size:          viper.GetInt("CACHE_SIZE"),
+			RedisAddr:     viper.GetString("REDIS_ADDR"),
+			RedisPassword: viper.GetString("REDIS_PASSWORD"),
+			RedisDB:       viper.GetInt("REDIS_DB"),
 		},
 	}
 }
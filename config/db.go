@@ -1,30 +1,297 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	//mysql driver
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+
+	//postgres driver
+	_ "github.com/lib/pq"
+)
+
+const (
+	// sqliteDriver is SQLite with the functions of MySQL the repositories rely on
+	sqliteDriver = "sqlite3_ticketing"
+	// mysqlTLSConfig prefixes the names the TLS of each host is registered with at
+	// the MySQL driver, the primary and the replicas verify different server names
+	mysqlTLSConfig = "ticketing-"
 )
 
+// errTLSRequireCA rejects a CA that would not be checked, verify is the TLS that checks it
+var errTLSRequireCA = fmt.Errorf("database tls %s does not verify the server, use %s with a CA", constans.TLSRequire, constans.TLSVerify)
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("distance_sphere", distanceSphere, true)
+		},
+	})
+}
+
+// distanceSphere is the great-circle distance in meters between two points
+// like ST_Distance_Sphere of MySQL
+func distanceSphere(lng1, lat1, lng2, lat2 float64) float64 {
+	const earthRadius = 6371008.8
+
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Pow(math.Sin(dLng/2), 2)
+
+	return earthRadius * 2 * math.Asin(math.Sqrt(a))
+}
+
+// DriverName returns the configured database driver, MySQL when none is set
+func (db MysqlDB) DriverName() string {
+	if db.Driver == "" {
+		return constans.DriverMySQL
+	}
+
+	return db.Driver
+}
+
+// Connect opens the database of the configured driver
+func (cfg Config) Connect() (*sql.DB, error) {
+	switch cfg.MysqlDB.DriverName() {
+	case constans.DriverMySQL:
+		return cfg.MysqlConnect()
+	case constans.DriverPostgres:
+		return cfg.PostgresConnect()
+	case constans.DriverSQLite:
+		return cfg.SqliteConnect()
+	}
+
+	return nil, fmt.Errorf("unsupported database driver %s", cfg.MysqlDB.Driver)
+}
+
+// ConnectReplicas opens the read replicas, a replica that is down is opened all
+// the same and only serves queries once it passes a health check
+func (cfg Config) ConnectReplicas() ([]*sql.DB, error) {
+	var replicas []*sql.DB
+
+	for _, addr := range strings.Split(cfg.MysqlDB.Replicas, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+
+		if cfg.MysqlDB.DriverName() == constans.DriverSQLite {
+			return nil, fmt.Errorf("read replicas are not supported by %s", constans.DriverSQLite)
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replica %s: %w", addr, err)
+		}
+
+		replica := cfg
+		replica.MysqlDB.Host = host
+		replica.MysqlDB.Port = port
+		replica.MysqlDB.replica = true
+
+		db, err := replica.Connect()
+		if err != nil {
+			return nil, err
+		}
+
+		replicas = append(replicas, db)
+	}
+
+	return replicas, nil
+}
+
+// MysqlConnect opens MySQL with the timeouts and TLS of the config
 func (cfg Config) MysqlConnect() (*sql.DB, error) {
-	dbConnString := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.MysqlDB.User,
-		cfg.MysqlDB.Password,
-		cfg.MysqlDB.Host,
-		cfg.MysqlDB.Port,
+	dsn := mysql.NewConfig()
+	dsn.User = cfg.MysqlDB.User
+	dsn.Passwd = cfg.MysqlDB.Password
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(cfg.MysqlDB.Host, cfg.MysqlDB.Port)
+	dsn.DBName = cfg.MysqlDB.Name
+	dsn.Params = map[string]string{"charset": "utf8mb4"}
+	dsn.ParseTime = true
+	dsn.Loc = time.Local
+	dsn.Timeout = seconds(cfg.MysqlDB.ConnectTimeout)
+	dsn.ReadTimeout = seconds(cfg.MysqlDB.ReadTimeout)
+	dsn.WriteTimeout = seconds(cfg.MysqlDB.WriteTimeout)
+
+	tlsConfig, err := cfg.MysqlDB.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		name := mysqlTLSConfig + dsn.Addr
+		if err = mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+			return nil, err
+		}
+		dsn.TLSConfig = name
+	}
+
+	return cfg.open(constans.DriverMySQL, dsn.FormatDSN())
+}
+
+// PostgresConnect opens PostgreSQL, lib/pq has no read or write timeout so only
+// the connect timeout of the config applies
+func (cfg Config) PostgresConnect() (*sql.DB, error) {
+	params := []string{
+		"host=" + quote(cfg.MysqlDB.Host),
+		"port=" + quote(cfg.MysqlDB.Port),
+		"user=" + quote(cfg.MysqlDB.User),
+		"password=" + quote(cfg.MysqlDB.Password),
+		"dbname=" + quote(cfg.MysqlDB.Name),
+	}
+
+	if cfg.MysqlDB.ConnectTimeout > 0 {
+		params = append(params, "connect_timeout="+strconv.Itoa(cfg.MysqlDB.ConnectTimeout))
+	}
+
+	switch cfg.MysqlDB.TLS {
+	case "", constans.TLSDisable:
+		params = append(params, "sslmode=disable")
+	case constans.TLSRequire:
+		if cfg.MysqlDB.TLSCA != "" {
+			return nil, errTLSRequireCA
+		}
+		params = append(params, "sslmode=require")
+	case constans.TLSVerify:
+		params = append(params, "sslmode=verify-full")
+		if cfg.MysqlDB.TLSCA != "" {
+			params = append(params, "sslrootcert="+quote(cfg.MysqlDB.TLSCA))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported database tls %s", cfg.MysqlDB.TLS)
+	}
+
+	if cfg.MysqlDB.TLSCert != "" {
+		params = append(params, "sslcert="+quote(cfg.MysqlDB.TLSCert), "sslkey="+quote(cfg.MysqlDB.TLSKey))
+	}
+
+	return cfg.open(constans.DriverPostgres, strings.Join(params, " "))
+}
+
+// SqliteConnect opens the database file named DB_NAME. Transactions take the
+// write lock when they begin, so a transaction never fails upgrading its lock.
+func (cfg Config) SqliteConnect() (*sql.DB, error) {
+	dbConnString := fmt.Sprintf("file:%s?_busy_timeout=5000&_txlock=immediate",
 		cfg.MysqlDB.Name,
 	)
 
-	db, err := sql.Open("mysql", dbConnString)
+	return cfg.open(sqliteDriver, dbConnString)
+}
+
+// open opens a database with the pool limits of the config and waits for it to
+// answer, a database that is still starting up is retried with a growing backoff
+func (cfg Config) open(driver string, dbConnString string) (*sql.DB, error) {
+	db, err := sql.Open(driver, dbConnString)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, err
+	if cfg.MysqlDB.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MysqlDB.MaxOpenConns)
+	}
+	if cfg.MysqlDB.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MysqlDB.MaxIdleConns)
+	}
+	if cfg.MysqlDB.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(seconds(cfg.MysqlDB.ConnMaxLifetime))
+	}
+
+	if cfg.MysqlDB.replica {
+		return db, nil
+	}
+
+	backoff := seconds(cfg.MysqlDB.ConnectBackoff)
+	if backoff <= 0 {
+		backoff = time.Second
 	}
 
-	return db, nil
+	for attempt := 0; ; attempt++ {
+		err = db.Ping()
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt >= cfg.MysqlDB.ConnectRetries {
+			db.Close()
+			return nil, err
+		}
+
+		log.Printf("database is not available, retrying in %s: %s", backoff, err)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > constans.DBMaxConnectBackoff {
+			backoff = constans.DBMaxConnectBackoff
+		}
+	}
+}
+
+// tlsConfig returns the TLS of the connection, nil when it is disabled
+func (db MysqlDB) tlsConfig() (*tls.Config, error) {
+	var tlsConfig *tls.Config
+
+	switch db.TLS {
+	case "", constans.TLSDisable:
+		return nil, nil
+	case constans.TLSRequire:
+		// the server is not verified, a CA would silently go unused
+		if db.TLSCA != "" {
+			return nil, errTLSRequireCA
+		}
+		tlsConfig = &tls.Config{InsecureSkipVerify: true}
+	case constans.TLSVerify:
+		tlsConfig = &tls.Config{ServerName: db.Host}
+	default:
+		return nil, fmt.Errorf("unsupported database tls %s", db.TLS)
+	}
+
+	if db.TLSCA != "" {
+		ca, err := os.ReadFile(db.TLSCA)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %s", db.TLSCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if db.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(db.TLSCert, db.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// quote quotes a value of a PostgreSQL connection string
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return "'" + value + "'"
 }
@@ -1,30 +1,232 @@
 package constans
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 const (
 	UserEntity        = `User`
 	ProductEntity     = `Product`
 	TransactionEntity = `Transaction`
+	VenueEntity       = `Venue`
+	SeatEntity        = `Seat`
+	CategoryEntity    = `Category`
+	SessionEntity     = `Session`
+	WaitlistEntity    = `Waitlist`
+	VoucherEntity     = `Voucher`
+	ExchangeEntity    = `Exchange rate`
+	WebhookEntity     = `Webhook`
+	JobEntity         = `Job`
+	ReconcileEntity   = `Reconciliation`
+	DatabaseEntity    = `Database pool`
+	WaitingRoomEntity = `Waiting room`
+	StreamTokenEntity = `Stream token`
 
 	MessageSuccessReadAll      = "Success retrieve all data from %s"
 	MessageSuccessReadByID     = "Success get %s with id %s"
 	MessageSuccessCreate       = "Success create new %s"
 	MessageSuccessUpdate       = "Success update %s with id %s"
 	MessageSuccessDelete       = "Success delete %s with id %s"
+	MessageSuccessRestore      = "Success restore %s with id %s"
 	MessageSuccessUploadImage  = "Success upload %s image"
 	MessageSuccessCheckoutItem = "Success checkout item"
+	MessageSuccessJoinWaitlist = "Success join waitlist"
+	MessageSuccessJoinQueue    = "Success join waiting room"
+	MessageSuccessNotification = "Success handle payment notification"
+	MessageSuccessQuote        = "Success quote item"
+	MessageSuccessRefreshRates = "Success refresh exchange rates"
+	MessageSuccessRedeliver    = "Success redeliver %s delivery with id %s"
+	MessageSuccessRetry        = "Success retry %s with id %s"
+	MessageSuccessReconcile    = "Success reconcile pending transactions"
 
 	DefaultImage  = "image/default.jpg"
 	BaseImagePath = "images/%d.%s"
 
-	PENDING = "pending"
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+
+	TLSDisable = "disable"
+	TLSRequire = "require"
+	TLSVerify  = "verify"
+
+	DBMaxConnectBackoff         = 30 * time.Second
+	DefaultReplicaCheckInterval = 5 * time.Second
+
+	StorageSQL    = "sql"
+	StorageMemory = "memory"
+
+	CacheLRU         = "lru"
+	CacheRedis       = "redis"
+	DefaultCacheTTL  = time.Minute
+	DefaultCacheSize = 10000
+	CachePrefix      = "ticketing:"
+	// CacheWriteWindow is how long after a write the cache refills from the
+	// primary, it covers the replication lag of the replicas
+	CacheWriteWindow = 5 * time.Second
+	// CacheLoadTimeout bounds a refill, which no longer depends on the request that started it
+	CacheLoadTimeout = 10 * time.Second
+
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+
+	DefaultTokenTTL = 72 * time.Hour
+
+	DefaultTicketDir = "tickets"
+	TicketFileName   = "%d.pdf"
+
+	PENDING   = "pending"
+	PAID      = "paid"
+	CANCELLED = "cancelled"
+	REFUNDED  = "refunded"
+
+	AVAILABLE = "available"
+	HELD      = "held"
+	SOLD      = "sold"
+
+	WAITING   = "waiting"
+	OFFERED   = "offered"
+	EXPIRED   = "expired"
+	PURCHASED = "purchased"
+	LEFT      = "left"
+
+	PERCENTAGE = "percentage"
+	FIXED      = "fixed"
+	RESERVED   = "reserved"
+	REDEEMED   = "redeemed"
+	RELEASED   = "released"
+
+	DISPATCHING = "dispatching"
+	DISPATCHED  = "dispatched"
+	SENDING     = "sending"
+	DELIVERED   = "delivered"
+	FAILED      = "failed"
+
+	RUNNING   = "running"
+	COMPLETED = "completed"
+	DEAD      = "dead"
+
+	APPLIED  = "applied"
+	MISMATCH = "mismatch"
+
+	DefaultWaitlistOfferWindow  = 15 * time.Minute
+	DefaultCheckoutWindow       = 10 * time.Minute
+	QueueTokenTTL               = 24 * time.Hour
+	DefaultCurrency             = "IDR"
+	DefaultIdempotencyRetention = 24 * time.Hour
+	IdempotencyLockTimeout      = time.Minute
+	DefaultReconcileAfter       = 30 * time.Minute
+	DefaultReconcileExpireAfter = 24 * time.Hour
+	ReconcileBatchSize          = 100
+	DateFormat                  = "2006-01-02"
+	DateTimeFormat              = "2006-01-02 15:04"
+	SeedPaymentMethod           = "seed"
+	MigrationDir                = "migrations"
+
+	// domain events
+	TransactionCreated   = "TransactionCreated"
+	TransactionPaid      = "TransactionPaid"
+	TransactionCancelled = "TransactionCancelled"
+	TransactionRefunded  = "TransactionRefunded"
+	ProductSoldOut       = "ProductSoldOut"
+	StockUpdated         = "StockUpdated"
+	UserRegistered       = "UserRegistered"
+
+	// TransactionPaidAfterCancel flags a payment captured for a transaction
+	// that was already cancelled, its tickets are gone and it has to be refunded
+	TransactionPaidAfterCancel = "TransactionPaidAfterCancel"
+
+	// availability streams
+	AvailabilityHeartbeat = 15 * time.Second
+	AvailabilityRetry     = 3 * time.Second
+	// AvailabilityTokenTTL is how long a stream token opens a stream, a client
+	// reconnecting later asks for a new one
+	AvailabilityTokenTTL = time.Minute
+	// AvailabilityFeedInterval is how often every instance reads the outbox for
+	// the changes to stream, AvailabilityFeedLag how long it keeps reading an
+	// event again, so an event committed after a newer one is still streamed
+	AvailabilityFeedInterval  = time.Second
+	AvailabilityFeedLag       = 10 * time.Second
+	AvailabilityFeedBatchSize = 1000
+
+	// outbox dispatching
+	OutboxBatchSize   = 100
+	OutboxMaxAttempts = 10
+	OutboxBaseBackoff = 5 * time.Second
+	OutboxMaxBackoff  = time.Hour
+	// OutboxLeaseTimeout is how long a claimed batch is hidden from the other
+	// instances, events of an instance that died are dispatched again after it
+	OutboxLeaseTimeout = 5 * time.Minute
+
+	// webhook deliveries
+	WebhookBatchSize    = 100
+	WebhookMaxAttempts  = 8
+	WebhookBaseBackoff  = 30 * time.Second
+	WebhookMaxBackoff   = 6 * time.Hour
+	WebhookTimeout      = 10 * time.Second
+	WebhookDisableAfter = 20
+	WebhookLogLimit     = 100
+	// WebhookLeaseTimeout is how long a claimed batch is hidden from the other
+	// instances, long enough for every delivery of a batch to time out
+	WebhookLeaseTimeout = 30 * time.Minute
+
+	// background jobs
+	JobSendNotification      = "notification.send"
+	JobGenerateTicket        = "ticket.generate"
+	JobExpireWaitlistOffers  = "waitlist.expire_offers"
+	JobDeleteIdempotencyKeys = "idempotency.delete_expired"
+	JobDeleteFinishedJobs    = "job.delete_finished"
+	JobReconcilePayments     = "payment.reconcile"
+	JobReconciliationReport  = "payment.reconciliation_report"
+
+	// job queue
+	JobMaxAttempts           = 5
+	JobBaseBackoff           = 10 * time.Second
+	JobMaxBackoff            = time.Hour
+	JobVisibilityTimeout     = 5 * time.Minute
+	JobTimeout               = 2 * time.Minute
+	JobPollInterval          = time.Second
+	JobRetention             = 7 * 24 * time.Hour
+	JobLogLimit              = 100
+	DefaultWorkerConcurrency = 4
+	WorkerDrainTimeout       = 30 * time.Second
+
+	// exchange rate sources
+	ManualRateSource   = "manual"
+	ProviderRateSource = "provider"
 )
 
 var (
-	ErrInternalServerError  = errors.New("internal server error")
-	ErrNotFound             = errors.New("your requested item is not found")
-	ErrConflict             = errors.New("data already exist")
-	ErrBadParamInput        = errors.New("given param is not valid")
-	ErrWrongEmailOrPassword = errors.New("wrong email/password")
+	ErrInternalServerError     = errors.New("internal server error")
+	ErrNotFound                = errors.New("your requested item is not found")
+	ErrConflict                = errors.New("data already exist")
+	ErrBadParamInput           = errors.New("given param is not valid")
+	ErrWrongEmailOrPassword    = errors.New("wrong email/password")
+	ErrSeatNotAvailable        = errors.New("selected seat is not available")
+	ErrSeatRequired            = errors.New("seat selection is required for this event")
+	ErrVenueHasNoSeats         = errors.New("venue has no seats")
+	ErrCategoryNotFound        = errors.New("category does not exist")
+	ErrTagInvalid              = errors.New("tag can not contain a comma")
+	ErrSessionRequired         = errors.New("session selection is required for this event")
+	ErrSessionClosed           = errors.New("selected session has already started")
+	ErrNotSoldOut              = errors.New("ticket is still available, no need to join the waitlist")
+	ErrTicketRunOut            = errors.New("ticket has run out")
+	ErrInvalidSignature        = errors.New("invalid signature")
+	ErrProductHasTickets       = errors.New("product has paid tickets, use force to delete it anyway")
+	ErrVoucherInvalid          = errors.New("promo code is not valid")
+	ErrVoucherNotApplicable    = errors.New("promo code can not be used for this item")
+	ErrVoucherExhausted        = errors.New("promo code has reached its redemption limit")
+	ErrPaymentMethodRequired   = errors.New("payment method is required")
+	ErrCurrencyNotSupported    = errors.New("currency is not supported")
+	ErrRateProviderUnavailable = errors.New("exchange rate provider is not available")
+	ErrIdempotencyKeyReused    = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyInProgress   = errors.New("a request with this idempotency key is still in progress")
+	ErrTicketNotIssued         = errors.New("ticket is only issued for paid transactions")
+	ErrQueueTokenInvalid       = errors.New("queue token is not valid")
+	ErrAdmissionRequired       = errors.New("checkout of this product requires an admission token of its waiting room")
+	ErrAdmissionExpired        = errors.New("checkout window has expired, join the waiting room again")
+	// seats are held for the whole event, so an event has either a seat map or sessions
+	ErrSeatMapHasSessions = errors.New("event has sessions, a seat map can only be attached to an event without sessions")
+	ErrSessionsHaveSeats  = errors.New("event has a seat map, sessions can only be added to an event without seats")
 )
@@ -0,0 +1,10 @@
+// Package fixtures embeds the default data of the seed command
+package fixtures
+
+import "embed"
+
+// DefaultFile is the fixture seeded when no file is given
+const DefaultFile = "seed.yaml"
+
+//go:embed *.yaml
+var FS embed.FS
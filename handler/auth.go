@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/cecepsprd/ticketing-api/model"
 	"github.com/cecepsprd/ticketing-api/service"
@@ -49,6 +51,33 @@ func auth() echo.MiddlewareFunc {
 	})
 }
 
+// authenticatedUserID returns the id of the user a request carries a valid
+// bearer token for, checked like auth() does, for middlewares running before it
+func authenticatedUserID(req *http.Request) (int64, bool) {
+	header := req.Header.Get(echo.HeaderAuthorization)
+	if !strings.HasPrefix(header, middleware.DefaultJWTConfig.AuthScheme+" ") {
+		return 0, false
+	}
+
+	token, err := jwt.Parse(header[len(middleware.DefaultJWTConfig.AuthScheme)+1:], func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != middleware.AlgorithmHS256 {
+			return nil, fmt.Errorf("unexpected jwt signing method=%v", t.Header["alg"])
+		}
+		return []byte(viper.GetString("APP_JWT_SECRET")), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+
+	id, ok := claims["id"].(float64)
+	return int64(id), ok
+}
+
 func isAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := c.Get("user").(*jwt.Token)
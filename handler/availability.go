@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+const headerLastEventID = "Last-Event-ID"
+
+type availability struct {
+	availabilityService service.AvailabilityService
+	// done is closed when the server shuts down, ending the open streams
+	done chan struct{}
+}
+
+func NewAvailabilityHandler(e *echo.Echo, as service.AvailabilityService) {
+	handler := &availability{
+		availabilityService: as,
+		done:                make(chan struct{}),
+	}
+
+	e.Server.RegisterOnShutdown(func() {
+		close(handler.done)
+	})
+
+	e.POST("/api/products/:id/availability/token", handler.Token, auth())
+	e.GET("/api/products/:id/availability/stream", handler.Stream, handler.streamAuth)
+}
+
+// Token issues the short lived token a client that cannot set headers, like
+// the EventSource of browsers, opens the stream with as the token query parameter
+func (h *availability) Token(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.availabilityService.StreamToken(ctx, convert.Atoi(id), utils.GetUserByContext(c).ID)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.StreamTokenEntity),
+		Data:    data,
+	})
+}
+
+// streamAuth lets a stream in with a stream token of its product in the token
+// query parameter, or with the login token in the Authorization header
+func (h *availability) streamAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	bearer := auth()(next)
+
+	return func(c echo.Context) error {
+		token := c.QueryParam("token")
+		if token == "" {
+			return bearer(c)
+		}
+
+		if !h.availabilityService.VerifyStreamToken(token, convert.Atoi(c.Param("id"))) {
+			return echo.ErrUnauthorized
+		}
+
+		return next(c)
+	}
+}
+
+// Stream sends the availability of a product as Server-Sent Events, first as
+// it is now and then whenever it changes. A client reconnecting with the
+// Last-Event-ID it received only gets the current availability again when it
+// changed since.
+func (h *availability) Stream(c echo.Context) error {
+	var (
+		ctx       = c.Request().Context()
+		productID = convert.Atoi(c.Param("id"))
+		res       = c.Response()
+	)
+
+	lastEventID, _ := strconv.ParseInt(c.Request().Header.Get(headerLastEventID), 10, 64)
+
+	// subscribing first, a change made while the current availability is read is still sent
+	sub := h.availabilityService.Subscribe(productID)
+	defer sub.Close()
+
+	latest, known := h.availabilityService.Latest(productID)
+
+	var current *model.Availability
+	if !known || lastEventID < latest {
+		var err error
+		current, err = h.availabilityService.Read(ctx, productID)
+		if err != nil {
+			return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+		}
+	}
+
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(headerCacheControl, "no-cache")
+	// proxies like nginx would hold the events back otherwise
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", constans.AvailabilityRetry/time.Millisecond); err != nil {
+		return nil
+	}
+
+	sent := lastEventID
+	if current != nil {
+		data, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+
+		// without a change since the server started there is no ID to resume from
+		if !known {
+			latest = 0
+		}
+		if err := writeEvent(res, latest, data); err != nil {
+			return nil
+		}
+		if latest > sent {
+			sent = latest
+		}
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(constans.AvailabilityHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+
+		select {
+		case msg := <-sub.C:
+			// an equal ID is a fresher read of a state already sent
+			if msg.ID < sent {
+				continue
+			}
+			sent = msg.ID
+			err = writeEvent(res, msg.ID, msg.Data)
+		case <-heartbeat.C:
+			// a comment keeps idle connections from being closed by proxies
+			_, err = fmt.Fprint(res, ": heartbeat\n\n")
+		case <-ctx.Done():
+			return nil
+		case <-h.done:
+			return nil
+		}
+
+		// the client is gone
+		if err != nil {
+			return nil
+		}
+		res.Flush()
+	}
+}
+
+// writeEvent writes an availability event, an id of 0 is left out
+func writeEvent(res *echo.Response, id int64, data []byte) error {
+	if id > 0 {
+		if _, err := fmt.Fprintf(res, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(res, "event: availability\ndata: %s\n\n", data)
+	return err
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/spf13/viper"
+)
+
+const (
+	headerETag         = "ETag"
+	headerIfNoneMatch  = "If-None-Match"
+	headerCacheControl = "Cache-Control"
+)
+
+// conditional tags a catalogue response with an ETag of its body and answers
+// 304 Not Modified when the client already holds it, as told by If-None-Match.
+// The responses depend on the user, so only the client may cache them, for
+// HTTP_CACHE_MAX_AGE seconds before it has to revalidate.
+func conditional(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			res    = c.Response()
+			writer = res.Writer
+			buffer = &bufferedWriter{ResponseWriter: writer, status: http.StatusOK}
+		)
+
+		res.Writer = buffer
+		err := next(c)
+		res.Writer = writer
+
+		if err == nil && buffer.status == http.StatusOK {
+			etag := `"` + digest(buffer.body.String()) + `"`
+
+			header := res.Header()
+			header.Set(headerETag, etag)
+			header.Set(headerCacheControl, fmt.Sprintf("private, max-age=%d", viper.GetInt("HTTP_CACHE_MAX_AGE")))
+			header.Add(echo.HeaderVary, echo.HeaderAuthorization+", Accept-Currency")
+
+			if etagMatches(c.Request().Header.Get(headerIfNoneMatch), etag) {
+				header.Del(echo.HeaderContentType)
+				res.Status = http.StatusNotModified
+				writer.WriteHeader(http.StatusNotModified)
+				return nil
+			}
+		}
+
+		if !buffer.written {
+			return err
+		}
+
+		writer.WriteHeader(buffer.status)
+		if _, writeErr := writer.Write(buffer.body.Bytes()); writeErr != nil && err == nil {
+			err = writeErr
+		}
+
+		return err
+	}
+}
+
+// etagMatches reports whether an If-None-Match header names etag, weak tags
+// match their strong counterpart as the header compares them weakly
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bufferedWriter holds back the response until its ETag is known
+type bufferedWriter struct {
+	http.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.status = status
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
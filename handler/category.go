@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+type category struct {
+	categoryService service.CategoryService
+}
+
+func NewCategoryHandler(e *echo.Echo, cs service.CategoryService) {
+	handler := &category{
+		categoryService: cs,
+	}
+
+	e.POST("/api/categories", handler.Create, auth(), isAdmin)
+	e.GET("/api/categories", handler.Read, auth(), conditional)
+	e.GET("/api/categories/:id", handler.ReadByID, auth(), conditional)
+	e.PUT("/api/categories/:id", handler.Update, auth(), isAdmin)
+	e.DELETE("/api/categories/:id", handler.Delete, auth(), isAdmin)
+}
+
+func (h *category) Create(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req model.CategoryRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	data, err := h.categoryService.Create(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.CategoryEntity),
+		Data:    data,
+	})
+}
+
+func (h *category) Read(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+	)
+
+	data, err := h.categoryService.Read(ctx)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.CategoryEntity),
+		Data:    data,
+	})
+}
+
+func (h *category) ReadByID(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.categoryService.ReadByID(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.CategoryEntity, id),
+		Data:    data,
+	})
+}
+
+func (h *category) Update(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.CategoryRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ID = convert.Atoi(id)
+
+	err = h.categoryService.Update(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessUpdate, constans.CategoryEntity, id),
+		Data:    nil,
+	})
+}
+
+func (h *category) Delete(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	err := h.categoryService.Delete(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessDelete, constans.CategoryEntity, id),
+		Data:    nil,
+	})
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+
+	"github.com/labstack/echo"
+)
+
+type database struct {
+	stats func() model.DatabaseStats
+}
+
+// NewDatabaseHandler exposes the connection pool statistics for monitoring
+func NewDatabaseHandler(e *echo.Echo, stats func() model.DatabaseStats) {
+	handler := &database{
+		stats: stats,
+	}
+
+	e.GET("/api/database/stats", handler.Stats, auth(), isAdmin)
+}
+
+func (h *database) Stats(c echo.Context) error {
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.DatabaseEntity),
+		Data:    h.stats(),
+	})
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+
+	"github.com/labstack/echo"
+)
+
+type exchange struct {
+	exchangeService service.ExchangeService
+}
+
+func NewExchangeHandler(e *echo.Echo, es service.ExchangeService) {
+	handler := &exchange{
+		exchangeService: es,
+	}
+
+	e.GET("/api/exchange-rates", handler.Read, auth())
+	e.PUT("/api/exchange-rates/:currency", handler.Update, auth(), isAdmin)
+	e.DELETE("/api/exchange-rates/:currency", handler.Delete, auth(), isAdmin)
+	e.POST("/api/exchange-rates/refresh", handler.Refresh, auth(), isAdmin)
+}
+
+func (h *exchange) Read(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+	)
+
+	data, err := h.exchangeService.Read(ctx)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.ExchangeEntity),
+		Data:    data,
+	})
+}
+
+func (h *exchange) Update(c echo.Context) error {
+	var (
+		ctx      = c.Request().Context()
+		currency = c.Param("currency")
+		req      model.ExchangeRateRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.Currency = currency
+
+	err = h.exchangeService.Update(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessUpdate, constans.ExchangeEntity, currency),
+		Data:    nil,
+	})
+}
+
+func (h *exchange) Delete(c echo.Context) error {
+	var (
+		ctx      = c.Request().Context()
+		currency = c.Param("currency")
+	)
+
+	err := h.exchangeService.Delete(ctx, currency)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessDelete, constans.ExchangeEntity, currency),
+		Data:    nil,
+	})
+}
+
+func (h *exchange) Refresh(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+	)
+
+	err := h.exchangeService.Refresh(ctx)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: constans.MessageSuccessRefreshRates,
+		Data:    nil,
+	})
+}
+
+// requestedCurrency returns the currency asked for with the currency query
+// parameter or the Accept-Currency header
+func requestedCurrency(c echo.Context) string {
+	currency := c.QueryParam("currency")
+	if currency == "" {
+		currency = c.Request().Header.Get("Accept-Currency")
+	}
+
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
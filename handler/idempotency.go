@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	headerIdempotencyKey = "Idempotency-Key"
+	headerReplayed       = "Idempotent-Replayed"
+	maxIdempotencyKey    = 255
+)
+
+type idempotency struct {
+	idempotencyService service.IdempotencyService
+}
+
+// NewIdempotencyHandler makes every mutating request carrying an Idempotency-Key
+// header safe to retry: the first response is stored and returned again for
+// retries with the same key, reusing a key for another request fails with 409.
+// Keys belong to the authenticated user, anonymous requests are not deduplicated.
+func NewIdempotencyHandler(e *echo.Echo, is service.IdempotencyService) {
+	handler := &idempotency{
+		idempotencyService: is,
+	}
+
+	e.Use(handler.Handle)
+}
+
+func (h *idempotency) Handle(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			req = c.Request()
+			ctx = req.Context()
+			key = req.Header.Get(headerIdempotencyKey)
+		)
+
+		if key == "" || !mutating(req.Method) {
+			return next(c)
+		}
+
+		userID, ok := authenticatedUserID(req)
+		if !ok {
+			return next(c)
+		}
+
+		if len(key) > maxIdempotencyKey {
+			return c.JSON(http.StatusBadRequest, model.ResponseError{Message: "idempotency key is too long"})
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		idempotencyKey := model.IdempotencyKey{
+			Key:         key,
+			Scope:       strconv.FormatInt(userID, 10),
+			RequestHash: digest(req.Method, req.URL.RequestURI(), string(body)),
+		}
+
+		previous, err := h.idempotencyService.Begin(ctx, idempotencyKey)
+		if err != nil {
+			return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+		}
+
+		if previous != nil {
+			c.Response().Header().Set(headerReplayed, "true")
+			return c.JSONBlob(previous.StatusCode, previous.Response)
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+
+		err = next(c)
+
+		// failed requests did not change anything and may be retried with the same key
+		if err != nil || c.Response().Status >= http.StatusInternalServerError {
+			if err := h.idempotencyService.Abandon(ctx, idempotencyKey.Scope, idempotencyKey.Key); err != nil {
+				logger.Log.Error(err.Error())
+			}
+			return err
+		}
+
+		idempotencyKey.StatusCode = c.Response().Status
+		idempotencyKey.Response = recorder.body.Bytes()
+		if err := h.idempotencyService.Complete(ctx, idempotencyKey); err != nil {
+			logger.Log.Error(err.Error())
+		}
+
+		return nil
+	}
+}
+
+// responseRecorder keeps a copy of the response body written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func digest(values ...string) string {
+	hash := sha256.New()
+	for _, v := range values {
+		hash.Write([]byte(v))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
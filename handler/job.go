@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+type job struct {
+	jobService service.JobService
+}
+
+func NewJobHandler(e *echo.Echo, js service.JobService) {
+	handler := &job{
+		jobService: js,
+	}
+
+	e.GET("/api/jobs", handler.Read, auth(), isAdmin)
+	e.POST("/api/jobs/:id/retry", handler.Retry, auth(), isAdmin)
+}
+
+// Read lists the jobs with the given status, the dead letters by default
+func (h *job) Read(c echo.Context) error {
+	var (
+		ctx    = c.Request().Context()
+		status = c.QueryParam("status")
+	)
+
+	if status == "" {
+		status = constans.DEAD
+	}
+
+	data, err := h.jobService.Read(ctx, status)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.JobEntity),
+		Data:    data,
+	})
+}
+
+func (h *job) Retry(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	err := h.jobService.Retry(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessRetry, constans.JobEntity, id),
+		Data:    nil,
+	})
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/cecepsprd/ticketing-api/constans"
 	"github.com/cecepsprd/ticketing-api/model"
@@ -25,11 +26,15 @@ func NewProductHandler(e *echo.Echo, ps service.ProductService, ts service.Trans
 	}
 
 	e.POST("/api/products", handler.Create, auth(), isAdmin)
-	e.GET("/api/products", handler.Read, auth())
+	e.GET("/api/products", handler.Read, auth(), conditional)
 	e.PUT("/api/products/:id", handler.Update, auth(), isAdmin)
 	e.DELETE("/api/products/:id", handler.Delete, auth(), isAdmin)
-	e.GET("/api/products/:id", handler.ReadByID, auth())
+	e.POST("/api/products/:id/restore", handler.Restore, auth(), isAdmin)
+	e.GET("/api/products/:id", handler.ReadByID, auth(), conditional)
+	e.PUT("/api/products/:id/stock", handler.UpdateStock, auth(), isAdmin)
 	e.POST("/api/products/checkout", handler.Checkout, auth())
+	e.POST("/api/products/quote", handler.Quote, auth())
+	e.POST("/api/payments/notification", handler.PaymentNotification)
 }
 
 func (p *product) Create(c echo.Context) error {
@@ -61,10 +66,24 @@ func (p *product) Create(c echo.Context) error {
 
 func (p *product) Read(c echo.Context) error {
 	var (
-		ctx = c.Request().Context()
+		ctx    = c.Request().Context()
+		filter model.ProductFilter
 	)
 
-	data, err := p.productService.Read(ctx)
+	err := c.Bind(&filter)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if utils.GetUserByContext(c).Roles != "admin" {
+		filter.WithDeleted = false
+	}
+
+	if filter.Currency == "" {
+		filter.Currency = requestedCurrency(c)
+	}
+
+	data, err := p.productService.Read(ctx, filter)
 	if err != nil {
 		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
 	}
@@ -92,6 +111,8 @@ func (p *product) Update(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
 	}
 
+	req.ID = id
+
 	err = p.productService.Update(ctx, req)
 	if err != nil {
 		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
@@ -110,7 +131,9 @@ func (p *product) Delete(c echo.Context) error {
 		id  = c.Param("id")
 	)
 
-	err := p.productService.Delete(ctx, convert.Atoi(id))
+	force, _ := strconv.ParseBool(c.QueryParam("force"))
+
+	err := p.productService.Delete(ctx, convert.Atoi(id), force)
 	if err != nil {
 		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
 	}
@@ -122,13 +145,31 @@ func (p *product) Delete(c echo.Context) error {
 	})
 }
 
+func (p *product) Restore(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	err := p.productService.Restore(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessRestore, constans.ProductEntity, id),
+		Data:    nil,
+	})
+}
+
 func (p *product) ReadByID(c echo.Context) error {
 	var (
 		ctx = c.Request().Context()
 		id  = c.Param("id")
 	)
 
-	data, err := p.productService.ReadByID(ctx, convert.Atoi(id))
+	data, err := p.productService.ReadByID(ctx, convert.Atoi(id), requestedCurrency(c))
 	if err != nil {
 		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
 	}
@@ -140,6 +181,36 @@ func (p *product) ReadByID(c echo.Context) error {
 	})
 }
 
+func (p *product) UpdateStock(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.StockRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ProductID = convert.Atoi(id)
+
+	err = p.productService.UpdateStock(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessUpdate, constans.ProductEntity, id),
+		Data:    nil,
+	})
+}
+
 func (h *product) Checkout(c echo.Context) error {
 	var (
 		ctx = c.Request().Context()
@@ -157,6 +228,14 @@ func (h *product) Checkout(c echo.Context) error {
 
 	req.User = utils.GetUserByContext(c)
 
+	if req.Currency == "" {
+		req.Currency = requestedCurrency(c)
+	}
+
+	if req.AdmissionToken == "" {
+		req.AdmissionToken = c.Request().Header.Get(headerAdmissionToken)
+	}
+
 	transaction, err := h.trxService.Checkout(ctx, req)
 	if err != nil {
 		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
@@ -168,3 +247,61 @@ func (h *product) Checkout(c echo.Context) error {
 		Data:    transaction,
 	})
 }
+
+// Quote previews the price breakdown of a checkout
+func (h *product) Quote(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req model.CreateTransactionRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.User = utils.GetUserByContext(c)
+
+	if req.Currency == "" {
+		req.Currency = requestedCurrency(c)
+	}
+
+	quote, err := h.trxService.Quote(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: constans.MessageSuccessQuote,
+		Data:    quote,
+	})
+}
+
+// PaymentNotification receives the payment status notifications of midtrans
+func (h *product) PaymentNotification(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req model.UpdateTransactionRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	err = h.trxService.Update(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: constans.MessageSuccessNotification,
+		Data:    nil,
+	})
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+
+	"github.com/labstack/echo"
+)
+
+type reconciliation struct {
+	reconcileService service.ReconciliationService
+}
+
+func NewReconciliationHandler(e *echo.Echo, rs service.ReconciliationService) {
+	handler := &reconciliation{
+		reconcileService: rs,
+	}
+
+	e.POST("/api/reconciliations", handler.Reconcile, auth(), isAdmin)
+	e.GET("/api/reconciliations/report", handler.Report, auth(), isAdmin)
+}
+
+// Reconcile checks the pending transactions against the payment gateway right away
+func (h *reconciliation) Reconcile(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+	)
+
+	data, err := h.reconcileService.Reconcile(ctx)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: constans.MessageSuccessReconcile,
+		Data:    data,
+	})
+}
+
+// Report returns the reconciliation report of the date query param, today by default
+func (h *reconciliation) Report(c echo.Context) error {
+	var (
+		ctx  = c.Request().Context()
+		date = time.Now()
+		err  error
+	)
+
+	if param := c.QueryParam("date"); param != "" {
+		date, err = time.ParseInLocation(constans.DateFormat, param, time.Local)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, model.ResponseError{Message: constans.ErrBadParamInput.Error()})
+		}
+	}
+
+	data, err := h.reconcileService.Report(ctx, date)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.ReconcileEntity),
+		Data:    data,
+	})
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+type session struct {
+	sessionService service.SessionService
+}
+
+func NewSessionHandler(e *echo.Echo, ss service.SessionService) {
+	handler := &session{
+		sessionService: ss,
+	}
+
+	e.POST("/api/products/:id/sessions", handler.Create, auth(), isAdmin)
+	e.GET("/api/products/:id/sessions", handler.ReadByProduct, auth(), conditional)
+	e.PUT("/api/products/:id/sessions/:session_id", handler.Update, auth(), isAdmin)
+	e.DELETE("/api/products/:id/sessions/:session_id", handler.Delete, auth(), isAdmin)
+}
+
+func (h *session) Create(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.SessionRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ProductID = convert.Atoi(id)
+
+	err = h.sessionService.Create(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.SessionEntity),
+		Data:    nil,
+	})
+}
+
+func (h *session) ReadByProduct(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.sessionService.ReadByProduct(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.SessionEntity),
+		Data:    data,
+	})
+}
+
+func (h *session) Update(c echo.Context) error {
+	var (
+		ctx       = c.Request().Context()
+		id        = c.Param("id")
+		sessionID = c.Param("session_id")
+		req       model.UpdateSessionRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ID = convert.Atoi(sessionID)
+	req.ProductID = convert.Atoi(id)
+
+	err = h.sessionService.Update(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessUpdate, constans.SessionEntity, sessionID),
+		Data:    nil,
+	})
+}
+
+func (h *session) Delete(c echo.Context) error {
+	var (
+		ctx       = c.Request().Context()
+		id        = c.Param("id")
+		sessionID = c.Param("session_id")
+	)
+
+	err := h.sessionService.Delete(ctx, convert.Atoi(id), convert.Atoi(sessionID))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessDelete, constans.SessionEntity, sessionID),
+		Data:    nil,
+	})
+}
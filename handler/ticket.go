@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+type ticket struct {
+	ticketService service.TicketService
+}
+
+func NewTicketHandler(e *echo.Echo, ts service.TicketService) {
+	handler := &ticket{
+		ticketService: ts,
+	}
+
+	e.GET("/api/transactions/:id/ticket", handler.Download, auth())
+}
+
+// Download sends the PDF ticket of a paid transaction
+func (h *ticket) Download(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	path, err := h.ticketService.Read(ctx, convert.Atoi(id), utils.GetUserByContext(c))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.Attachment(path, fmt.Sprintf("ticket-%s.pdf", id))
+}
@@ -49,6 +49,9 @@ func (u *UserHandler) Create(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
 	}
 
+	// anyone can register, admins are only created with the user command
+	req.Roles = constans.RoleUser
+
 	ctx := c.Request().Context()
 	err = u.userService.Create(ctx, req)
 	if err != nil {
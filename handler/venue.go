@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+type venue struct {
+	venueService service.VenueService
+}
+
+func NewVenueHandler(e *echo.Echo, vs service.VenueService) {
+	handler := &venue{
+		venueService: vs,
+	}
+
+	e.POST("/api/venues", handler.Create, auth(), isAdmin)
+	e.GET("/api/venues", handler.Read, auth(), conditional)
+	e.GET("/api/venues/:id", handler.ReadByID, auth(), conditional)
+	e.POST("/api/venues/:id/seats", handler.CreateSeats, auth(), isAdmin)
+	e.GET("/api/venues/:id/seats", handler.ReadSeats, auth(), conditional)
+	e.POST("/api/products/:id/seats", handler.CreateSeatMap, auth(), isAdmin)
+	e.GET("/api/products/:id/seats", handler.ReadSeatAvailability, auth(), conditional)
+}
+
+func (v *venue) Create(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req model.VenueRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	data, err := v.venueService.Create(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.VenueEntity),
+		Data:    data,
+	})
+}
+
+func (v *venue) Read(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+	)
+
+	data, err := v.venueService.Read(ctx)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.VenueEntity),
+		Data:    data,
+	})
+}
+
+func (v *venue) ReadByID(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := v.venueService.ReadByID(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.VenueEntity, id),
+		Data:    data,
+	})
+}
+
+func (v *venue) CreateSeats(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.SeatRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.VenueID = convert.Atoi(id)
+
+	err = v.venueService.CreateSeats(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.SeatEntity),
+		Data:    nil,
+	})
+}
+
+func (v *venue) ReadSeats(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := v.venueService.ReadSeats(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.SeatEntity),
+		Data:    data,
+	})
+}
+
+func (v *venue) CreateSeatMap(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.SeatMapRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ProductID = convert.Atoi(id)
+
+	err = v.venueService.CreateSeatMap(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.SeatEntity),
+		Data:    nil,
+	})
+}
+
+func (v *venue) ReadSeatAvailability(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := v.venueService.ReadSeatAvailability(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.SeatEntity),
+		Data:    data,
+	})
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+type voucher struct {
+	voucherService service.VoucherService
+}
+
+func NewVoucherHandler(e *echo.Echo, vs service.VoucherService) {
+	handler := &voucher{
+		voucherService: vs,
+	}
+
+	e.POST("/api/vouchers", handler.Create, auth(), isAdmin)
+	e.GET("/api/vouchers", handler.Read, auth(), isAdmin)
+	e.GET("/api/vouchers/:id", handler.ReadByID, auth(), isAdmin)
+	e.GET("/api/vouchers/:id/report", handler.Report, auth(), isAdmin)
+	e.PUT("/api/vouchers/:id", handler.Update, auth(), isAdmin)
+	e.DELETE("/api/vouchers/:id", handler.Delete, auth(), isAdmin)
+}
+
+func (h *voucher) Create(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req model.VoucherRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	data, err := h.voucherService.Create(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.VoucherEntity),
+		Data:    data,
+	})
+}
+
+func (h *voucher) Read(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+	)
+
+	data, err := h.voucherService.Read(ctx)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.VoucherEntity),
+		Data:    data,
+	})
+}
+
+func (h *voucher) ReadByID(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.voucherService.ReadByID(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.VoucherEntity, id),
+		Data:    data,
+	})
+}
+
+func (h *voucher) Update(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.VoucherRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ID = convert.Atoi(id)
+
+	err = h.voucherService.Update(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessUpdate, constans.VoucherEntity, id),
+		Data:    nil,
+	})
+}
+
+func (h *voucher) Delete(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	err := h.voucherService.Delete(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessDelete, constans.VoucherEntity, id),
+		Data:    nil,
+	})
+}
+
+func (h *voucher) Report(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.voucherService.Report(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.VoucherEntity, id),
+		Data:    data,
+	})
+}
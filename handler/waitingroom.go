@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	headerQueueToken     = "X-Queue-Token"
+	headerAdmissionToken = "X-Admission-Token"
+)
+
+type waitingRoom struct {
+	waitingRoomService service.WaitingRoomService
+}
+
+func NewWaitingRoomHandler(e *echo.Echo, ws service.WaitingRoomService) {
+	handler := &waitingRoom{
+		waitingRoomService: ws,
+	}
+
+	e.POST("/api/products/:id/waiting-room", handler.Open, auth(), isAdmin)
+	e.GET("/api/products/:id/waiting-room", handler.Read, auth(), isAdmin)
+	e.PUT("/api/products/:id/waiting-room", handler.Update, auth(), isAdmin)
+	e.DELETE("/api/products/:id/waiting-room", handler.Close, auth(), isAdmin)
+	e.POST("/api/products/:id/queue", handler.Join, auth())
+	e.GET("/api/products/:id/queue", handler.Status, auth())
+}
+
+func (h *waitingRoom) Open(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.WaitingRoomRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ProductID = convert.Atoi(id)
+
+	data, err := h.waitingRoomService.Open(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.WaitingRoomEntity),
+		Data:    data,
+	})
+}
+
+func (h *waitingRoom) Read(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.waitingRoomService.Read(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.WaitingRoomEntity, id),
+		Data:    data,
+	})
+}
+
+// Update pauses, resumes, changes the rate or lets buyers in right away
+func (h *waitingRoom) Update(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.UpdateWaitingRoomRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ProductID = convert.Atoi(id)
+
+	data, err := h.waitingRoomService.Update(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessUpdate, constans.WaitingRoomEntity, id),
+		Data:    data,
+	})
+}
+
+func (h *waitingRoom) Close(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	err := h.waitingRoomService.Close(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessDelete, constans.WaitingRoomEntity, id),
+		Data:    nil,
+	})
+}
+
+func (h *waitingRoom) Join(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.waitingRoomService.Join(ctx, convert.Atoi(id), utils.GetUserByContext(c))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: constans.MessageSuccessJoinQueue,
+		Data:    data,
+	})
+}
+
+// Status reads the queue token from the X-Queue-Token header or the queue_token query parameter
+func (h *waitingRoom) Status(c echo.Context) error {
+	var (
+		ctx   = c.Request().Context()
+		id    = c.Param("id")
+		token = c.Request().Header.Get(headerQueueToken)
+	)
+
+	if token == "" {
+		token = c.QueryParam("queue_token")
+	}
+
+	data, err := h.waitingRoomService.Status(ctx, convert.Atoi(id), utils.GetUserByContext(c), token)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.WaitingRoomEntity, id),
+		Data:    data,
+	})
+}
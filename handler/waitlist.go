@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+type waitlist struct {
+	waitlistService service.WaitlistService
+}
+
+func NewWaitlistHandler(e *echo.Echo, ws service.WaitlistService) {
+	handler := &waitlist{
+		waitlistService: ws,
+	}
+
+	e.POST("/api/products/:id/waitlist", handler.Join, auth())
+	e.GET("/api/products/:id/waitlist", handler.ReadByUser, auth())
+	e.DELETE("/api/products/:id/waitlist", handler.Leave, auth())
+}
+
+// Join puts the user on the waitlist of a product, products with sessions are
+// waited for per session given by the session_id query parameter
+func (h *waitlist) Join(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.waitlistService.Join(ctx, convert.Atoi(id), convert.Atoi(c.QueryParam("session_id")), utils.GetUserByContext(c))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: constans.MessageSuccessJoinWaitlist,
+		Data:    data,
+	})
+}
+
+func (h *waitlist) ReadByUser(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.waitlistService.ReadByUser(ctx, convert.Atoi(id), convert.Atoi(c.QueryParam("session_id")), utils.GetUserByContext(c))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.WaitlistEntity, id),
+		Data:    data,
+	})
+}
+
+func (h *waitlist) Leave(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	err := h.waitlistService.Leave(ctx, convert.Atoi(id), convert.Atoi(c.QueryParam("session_id")), utils.GetUserByContext(c))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessDelete, constans.WaitlistEntity, id),
+		Data:    nil,
+	})
+}
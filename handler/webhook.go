@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+
+	"github.com/labstack/echo"
+)
+
+type webhook struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(e *echo.Echo, ws service.WebhookService) {
+	handler := &webhook{
+		webhookService: ws,
+	}
+
+	e.POST("/api/webhooks", handler.Create, auth(), isAdmin)
+	e.GET("/api/webhooks", handler.Read, auth(), isAdmin)
+	e.GET("/api/webhooks/:id", handler.ReadByID, auth(), isAdmin)
+	e.PUT("/api/webhooks/:id", handler.Update, auth(), isAdmin)
+	e.DELETE("/api/webhooks/:id", handler.Delete, auth(), isAdmin)
+	e.GET("/api/webhooks/:id/deliveries", handler.ReadDeliveries, auth(), isAdmin)
+	e.POST("/api/webhooks/:id/deliveries/:delivery_id/redeliver", handler.Redeliver, auth(), isAdmin)
+}
+
+func (h *webhook) Create(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		req model.WebhookRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	data, err := h.webhookService.Create(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: fmt.Sprintf(constans.MessageSuccessCreate, constans.WebhookEntity),
+		Data:    data,
+	})
+}
+
+func (h *webhook) Read(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+	)
+
+	data, err := h.webhookService.Read(ctx)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadAll, constans.WebhookEntity),
+		Data:    data,
+	})
+}
+
+func (h *webhook) ReadByID(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.webhookService.ReadByID(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.WebhookEntity, id),
+		Data:    data,
+	})
+}
+
+func (h *webhook) Update(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+		req model.WebhookRequest
+	)
+
+	err := c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, model.ResponseError{Message: err.Error()})
+	}
+
+	req.ID = convert.Atoi(id)
+
+	err = h.webhookService.Update(ctx, req)
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessUpdate, constans.WebhookEntity, id),
+		Data:    nil,
+	})
+}
+
+func (h *webhook) Delete(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	err := h.webhookService.Delete(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessDelete, constans.WebhookEntity, id),
+		Data:    nil,
+	})
+}
+
+func (h *webhook) ReadDeliveries(c echo.Context) error {
+	var (
+		ctx = c.Request().Context()
+		id  = c.Param("id")
+	)
+
+	data, err := h.webhookService.ReadDeliveries(ctx, convert.Atoi(id))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessReadByID, constans.WebhookEntity, id),
+		Data:    data,
+	})
+}
+
+func (h *webhook) Redeliver(c echo.Context) error {
+	var (
+		ctx        = c.Request().Context()
+		id         = c.Param("id")
+		deliveryID = c.Param("delivery_id")
+	)
+
+	data, err := h.webhookService.Redeliver(ctx, convert.Atoi(id), convert.Atoi(deliveryID))
+	if err != nil {
+		return c.JSON(utils.SetHTTPStatusCode(err), model.ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: fmt.Sprintf(constans.MessageSuccessRedeliver, constans.WebhookEntity, deliveryID),
+		Data:    data,
+	})
+}
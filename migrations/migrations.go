@@ -0,0 +1,22 @@
+// Package migrations embeds the versioned schema migrations, one directory per
+// database driver. Every version has a <version>_<name>.up.sql file and a
+// matching .down.sql file that reverts it.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite3/*.sql
+var files embed.FS
+
+// For returns the migrations of a database driver
+func For(driver string) (fs.FS, error) {
+	if _, err := fs.Stat(files, driver); err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %s", driver)
+	}
+
+	return fs.Sub(files, driver)
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `product_seat`;
+DROP TABLE IF EXISTS `seat`;
+DROP TABLE IF EXISTS `venue`;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS `venue`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(100) NOT NULL,
+  `address` VARCHAR(255) NOT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+CREATE TABLE IF NOT EXISTS `seat`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `venue_id` BIGINT NOT NULL,
+  `section` VARCHAR(45) NOT NULL,
+  `row_label` VARCHAR(10) NOT NULL,
+  `number` INT(11) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_seat` (`venue_id`, `section`, `row_label`, `number`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+CREATE TABLE IF NOT EXISTS `product_seat`(
+  `product_id` BIGINT NOT NULL,
+  `seat_id` BIGINT NOT NULL,
+  `status` VARCHAR(10) NOT NULL,
+  `transaction_id` BIGINT DEFAULT NULL,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`product_id`, `seat_id`),
+  KEY `idx_product_seat_transaction` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
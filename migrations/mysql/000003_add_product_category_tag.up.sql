@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS `category`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `parent_id` BIGINT DEFAULT NULL,
+  `name` VARCHAR(45) NOT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_category_parent` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+ALTER TABLE `product`
+  ADD COLUMN `category_id` BIGINT DEFAULT NULL,
+  ADD COLUMN `organizer_name` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN `organizer_contact` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN `location` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN `address` VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN `latitude` DOUBLE NOT NULL DEFAULT 0,
+  ADD COLUMN `longitude` DOUBLE NOT NULL DEFAULT 0,
+  ADD KEY `idx_product_category` (`category_id`);
+
+
+CREATE TABLE IF NOT EXISTS `product_tag`(
+  `product_id` BIGINT NOT NULL,
+  `tag` VARCHAR(45) NOT NULL,
+  PRIMARY KEY (`product_id`, `tag`),
+  KEY `idx_product_tag_tag` (`tag`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
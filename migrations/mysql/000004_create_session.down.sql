@@ -0,0 +1,4 @@
+ALTER TABLE `transaction`
+  DROP COLUMN `session_id`;
+
+DROP TABLE IF EXISTS `session`;
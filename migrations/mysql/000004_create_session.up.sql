@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `session`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT NOT NULL,
+  `start_date` DATETIME NOT NULL,
+  `end_date` DATETIME NOT NULL,
+  `capacity` INT(11) NOT NULL,
+  `stock` INT(11) NOT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_session_product_start` (`product_id`, `start_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+ALTER TABLE `transaction`
+  ADD COLUMN `session_id` BIGINT DEFAULT NULL AFTER `product_id`;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `waitlist`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT NOT NULL,
+  `user_id` BIGINT NOT NULL,
+  `status` VARCHAR(10) NOT NULL,
+  `offer_expires_at` DATETIME DEFAULT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_waitlist_product_status` (`product_id`, `status`),
+  KEY `idx_waitlist_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
@@ -0,0 +1,3 @@
+ALTER TABLE `product`
+  DROP KEY `idx_product_deleted_at`,
+  DROP COLUMN `deleted_at`;
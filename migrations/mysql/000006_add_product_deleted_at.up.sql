@@ -0,0 +1,3 @@
+ALTER TABLE `product`
+  ADD COLUMN `deleted_at` DATETIME DEFAULT NULL,
+  ADD KEY `idx_product_deleted_at` (`deleted_at`);
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS `voucher`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `code` VARCHAR(45) NOT NULL,
+  `type` VARCHAR(10) NOT NULL,
+  `value` DECIMAL(15,2) NOT NULL,
+  `product_id` BIGINT DEFAULT NULL,
+  `section` VARCHAR(45) NOT NULL DEFAULT '',
+  `valid_from` DATETIME DEFAULT NULL,
+  `valid_until` DATETIME DEFAULT NULL,
+  `max_redemptions` INT(11) NOT NULL DEFAULT 0,
+  `max_per_user` INT(11) NOT NULL DEFAULT 0,
+  `redeemed` INT(11) NOT NULL DEFAULT 0,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_voucher_code` (`code`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+CREATE TABLE IF NOT EXISTS `voucher_redemption`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `voucher_id` BIGINT NOT NULL,
+  `user_id` BIGINT NOT NULL,
+  `transaction_id` BIGINT NOT NULL,
+  `discount` DECIMAL(15,2) NOT NULL,
+  `status` VARCHAR(10) NOT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_voucher_redemption_transaction` (`transaction_id`),
+  KEY `idx_voucher_redemption_voucher_user` (`voucher_id`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+ALTER TABLE `transaction`
+  ADD COLUMN `discount` BIGINT(20) DEFAULT 0 AFTER `amount`;
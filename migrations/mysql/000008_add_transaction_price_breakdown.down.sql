@@ -0,0 +1,10 @@
+ALTER TABLE `transaction`
+  DROP COLUMN `payment_method`,
+  DROP COLUMN `unit_price`,
+  DROP COLUMN `quantity`,
+  DROP COLUMN `subtotal`,
+  MODIFY COLUMN `discount` BIGINT(20) DEFAULT 0 AFTER `amount`,
+  DROP COLUMN `fee`,
+  DROP COLUMN `surcharge`,
+  DROP COLUMN `tax`,
+  DROP COLUMN `total`;
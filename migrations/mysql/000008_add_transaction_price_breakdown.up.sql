@@ -0,0 +1,10 @@
+ALTER TABLE `transaction`
+  ADD COLUMN `payment_method` VARCHAR(45) NOT NULL DEFAULT '' AFTER `amount`,
+  ADD COLUMN `unit_price` BIGINT(20) NOT NULL DEFAULT 0 AFTER `payment_method`,
+  ADD COLUMN `quantity` INT(11) NOT NULL DEFAULT 1 AFTER `unit_price`,
+  ADD COLUMN `subtotal` BIGINT(20) NOT NULL DEFAULT 0 AFTER `quantity`,
+  MODIFY COLUMN `discount` BIGINT(20) NOT NULL DEFAULT 0 AFTER `subtotal`,
+  ADD COLUMN `fee` BIGINT(20) NOT NULL DEFAULT 0 AFTER `discount`,
+  ADD COLUMN `surcharge` BIGINT(20) NOT NULL DEFAULT 0 AFTER `fee`,
+  ADD COLUMN `tax` BIGINT(20) NOT NULL DEFAULT 0 AFTER `surcharge`,
+  ADD COLUMN `total` BIGINT(20) NOT NULL DEFAULT 0 AFTER `tax`;
@@ -0,0 +1,15 @@
+ALTER TABLE `voucher_redemption`
+  MODIFY COLUMN `discount` DECIMAL(15,2) NOT NULL;
+UPDATE `voucher_redemption` SET `discount` = `discount` / 100;
+ALTER TABLE `voucher`
+  MODIFY COLUMN `value` DECIMAL(15,2) NOT NULL;
+UPDATE `voucher` SET `value` = `value` / 100;
+
+UPDATE `transaction` SET `amount` = ROUND(`amount` / 100);
+ALTER TABLE `transaction`
+  DROP COLUMN `currency`,
+  MODIFY COLUMN `amount` BIGINT(20) DEFAULT 0;
+
+UPDATE `product` SET `price` = ROUND(`price` / 100);
+ALTER TABLE `product`
+  DROP COLUMN `currency`;
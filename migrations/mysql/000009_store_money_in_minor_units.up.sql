@@ -0,0 +1,18 @@
+-- prices and amounts are stored in minor units with their ISO 4217 currency
+ALTER TABLE `product`
+  ADD COLUMN `currency` CHAR(3) NOT NULL DEFAULT 'IDR' AFTER `price`;
+UPDATE `product` SET `price` = `price` * 100;
+
+ALTER TABLE `transaction`
+  MODIFY COLUMN `amount` BIGINT(20) NOT NULL DEFAULT 0,
+  ADD COLUMN `currency` CHAR(3) NOT NULL DEFAULT 'IDR' AFTER `amount`;
+UPDATE `transaction` SET `amount` = `amount` * 100 WHERE `total` = 0;
+UPDATE `transaction` SET `amount` = `total` WHERE `total` <> 0;
+
+-- percentage vouchers move to basis points and fixed vouchers to minor units, both are x100
+UPDATE `voucher` SET `value` = ROUND(`value` * 100);
+ALTER TABLE `voucher`
+  MODIFY COLUMN `value` BIGINT(20) NOT NULL;
+UPDATE `voucher_redemption` SET `discount` = ROUND(`discount` * 100);
+ALTER TABLE `voucher_redemption`
+  MODIFY COLUMN `discount` BIGINT(20) NOT NULL;
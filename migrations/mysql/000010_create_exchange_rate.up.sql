@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `exchange_rate`(
+  `currency` CHAR(3) NOT NULL,
+  `rate` DECIMAL(24,12) NOT NULL,
+  `source` VARCHAR(10) NOT NULL,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`currency`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+ALTER TABLE `transaction`
+  ADD COLUMN `price_rate` DECIMAL(24,12) DEFAULT NULL AFTER `total`,
+  ADD COLUMN `display_currency` CHAR(3) DEFAULT NULL AFTER `price_rate`,
+  ADD COLUMN `display_rate` DECIMAL(24,12) DEFAULT NULL AFTER `display_currency`,
+  ADD COLUMN `display_total` BIGINT(20) DEFAULT NULL AFTER `display_rate`;
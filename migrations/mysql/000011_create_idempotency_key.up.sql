@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `idempotency_key`(
+  `scope` CHAR(64) NOT NULL,
+  `idempotency_key` VARCHAR(255) NOT NULL,
+  `request_hash` CHAR(64) NOT NULL,
+  `status_code` INT(11) DEFAULT NULL,
+  `response` MEDIUMBLOB,
+  `completed` TINYINT(1) NOT NULL DEFAULT 0,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`scope`, `idempotency_key`),
+  KEY `idx_idempotency_key_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `outbox_event`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `type` VARCHAR(45) NOT NULL,
+  `aggregate_id` BIGINT NOT NULL,
+  `payload` JSON NOT NULL,
+  `status` VARCHAR(10) NOT NULL,
+  `attempts` INT(11) NOT NULL DEFAULT 0,
+  `last_error` VARCHAR(1000) NOT NULL DEFAULT '',
+  `next_attempt_at` DATETIME NOT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `dispatched_at` DATETIME DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_outbox_event_pending` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS `webhook`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `url` VARCHAR(255) NOT NULL,
+  `event_types` VARCHAR(255) NOT NULL,
+  `secret` VARCHAR(255) NOT NULL,
+  `active` TINYINT(1) NOT NULL DEFAULT 1,
+  `failure_count` INT(11) NOT NULL DEFAULT 0,
+  `disabled_at` DATETIME DEFAULT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+CREATE TABLE IF NOT EXISTS `webhook_delivery`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `webhook_id` BIGINT NOT NULL,
+  `event_id` BIGINT NOT NULL,
+  `event_type` VARCHAR(45) NOT NULL,
+  `payload` JSON NOT NULL,
+  `status` VARCHAR(10) NOT NULL,
+  `attempts` INT(11) NOT NULL DEFAULT 0,
+  `response_code` INT(11) NOT NULL DEFAULT 0,
+  `last_error` VARCHAR(1000) NOT NULL DEFAULT '',
+  `next_attempt_at` DATETIME NOT NULL,
+  `delivered_at` DATETIME DEFAULT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_webhook_delivery_event` (`webhook_id`, `event_id`),
+  KEY `idx_webhook_delivery_pending` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `job`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `type` VARCHAR(45) NOT NULL,
+  `payload` JSON NOT NULL,
+  `unique_key` VARCHAR(100) DEFAULT NULL,
+  `status` VARCHAR(10) NOT NULL,
+  `attempts` INT(11) NOT NULL DEFAULT 0,
+  `max_attempts` INT(11) NOT NULL,
+  `last_error` VARCHAR(1000) NOT NULL DEFAULT '',
+  `run_at` DATETIME NOT NULL,
+  `lease_token` CHAR(32) DEFAULT NULL,
+  `locked_until` DATETIME DEFAULT NULL,
+  `finished_at` DATETIME DEFAULT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_job_unique_key` (`unique_key`),
+  UNIQUE KEY `uq_job_lease_token` (`lease_token`),
+  KEY `idx_job_due` (`status`, `run_at`),
+  KEY `idx_job_finished` (`status`, `finished_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
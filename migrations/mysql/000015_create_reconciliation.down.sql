@@ -0,0 +1,3 @@
+ALTER TABLE `transaction` DROP KEY `idx_transaction_status_created_at`;
+
+DROP TABLE IF EXISTS `reconciliation`;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `reconciliation`(
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `transaction_id` BIGINT NOT NULL,
+  `provider_status` VARCHAR(45) NOT NULL DEFAULT '',
+  `previous_status` VARCHAR(10) NOT NULL,
+  `status` VARCHAR(10) NOT NULL,
+  `currency` CHAR(3) NOT NULL,
+  `expected_amount` BIGINT(20) NOT NULL,
+  `provider_amount` BIGINT(20) NOT NULL DEFAULT 0,
+  `result` VARCHAR(10) NOT NULL,
+  `message` VARCHAR(1000) NOT NULL DEFAULT '',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_reconciliation_created_at` (`created_at`),
+  KEY `idx_reconciliation_transaction` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+ALTER TABLE `transaction` ADD KEY `idx_transaction_status_created_at` (`status`, `created_at`);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `waiting_room`(
+  `product_id` BIGINT NOT NULL,
+  `rate` BIGINT NOT NULL,
+  `checkout_window` BIGINT NOT NULL,
+  `paused` TINYINT(1) NOT NULL DEFAULT 0,
+  `admitted` BIGINT NOT NULL DEFAULT 0,
+  `admitted_at` DATETIME NOT NULL,
+  `last_position` BIGINT NOT NULL DEFAULT 0,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE IF NOT EXISTS `waiting_room_entry`(
+  `product_id` BIGINT NOT NULL,
+  `user_id` BIGINT NOT NULL,
+  `position` BIGINT NOT NULL,
+  `admitted_at` DATETIME DEFAULT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`product_id`, `user_id`),
+  UNIQUE KEY `idx_waiting_room_entry_position` (`product_id`, `position`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
@@ -0,0 +1,4 @@
+ALTER TABLE `waitlist`
+  DROP KEY `idx_waitlist_product_session_status`,
+  ADD KEY `idx_waitlist_product_status` (`product_id`, `status`),
+  DROP COLUMN `session_id`;
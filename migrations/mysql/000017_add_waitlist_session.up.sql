@@ -0,0 +1,4 @@
+ALTER TABLE `waitlist`
+  ADD COLUMN `session_id` BIGINT NOT NULL DEFAULT 0 AFTER `product_id`,
+  DROP KEY `idx_waitlist_product_status`,
+  ADD KEY `idx_waitlist_product_session_status` (`product_id`, `session_id`, `status`);
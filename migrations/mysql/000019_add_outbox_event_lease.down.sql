@@ -0,0 +1,8 @@
+UPDATE `outbox_event` SET `status`='pending' WHERE `status`='dispatching';
+
+ALTER TABLE `outbox_event`
+  DROP KEY `idx_outbox_event_locked`,
+  DROP KEY `idx_outbox_event_lease_token`,
+  DROP COLUMN `locked_until`,
+  DROP COLUMN `lease_token`,
+  MODIFY COLUMN `status` VARCHAR(10) NOT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE `outbox_event`
+  MODIFY COLUMN `status` VARCHAR(20) NOT NULL,
+  ADD COLUMN `lease_token` CHAR(32) DEFAULT NULL AFTER `next_attempt_at`,
+  ADD COLUMN `locked_until` DATETIME DEFAULT NULL AFTER `lease_token`,
+  ADD KEY `idx_outbox_event_lease_token` (`lease_token`),
+  ADD KEY `idx_outbox_event_locked` (`status`, `locked_until`);
@@ -0,0 +1,7 @@
+UPDATE `webhook_delivery` SET `status`='pending' WHERE `status`='sending';
+
+ALTER TABLE `webhook_delivery`
+  DROP KEY `idx_webhook_delivery_locked`,
+  DROP KEY `idx_webhook_delivery_lease_token`,
+  DROP COLUMN `locked_until`,
+  DROP COLUMN `lease_token`;
@@ -0,0 +1,5 @@
+ALTER TABLE `webhook_delivery`
+  ADD COLUMN `lease_token` CHAR(32) DEFAULT NULL AFTER `next_attempt_at`,
+  ADD COLUMN `locked_until` DATETIME DEFAULT NULL AFTER `lease_token`,
+  ADD KEY `idx_webhook_delivery_lease_token` (`lease_token`),
+  ADD KEY `idx_webhook_delivery_locked` (`status`, `locked_until`);
@@ -0,0 +1,2 @@
+ALTER TABLE `outbox_event`
+  DROP KEY `idx_outbox_event_created`;
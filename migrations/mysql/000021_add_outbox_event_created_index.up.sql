@@ -0,0 +1,2 @@
+ALTER TABLE `outbox_event`
+  ADD KEY `idx_outbox_event_created` (`created_at`, `type`);
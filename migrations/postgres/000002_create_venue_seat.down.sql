@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS product_seat;
+DROP TABLE IF EXISTS seat;
+DROP TABLE IF EXISTS venue;
+DROP FUNCTION IF EXISTS set_updated_at();
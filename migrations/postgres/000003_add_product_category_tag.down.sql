@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS product_tag;
+
+DROP INDEX IF EXISTS idx_product_category;
+ALTER TABLE product
+  DROP COLUMN category_id,
+  DROP COLUMN organizer_name,
+  DROP COLUMN organizer_contact,
+  DROP COLUMN location,
+  DROP COLUMN address,
+  DROP COLUMN latitude,
+  DROP COLUMN longitude;
+
+DROP TABLE IF EXISTS category;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS session(
+  id BIGSERIAL NOT NULL,
+  product_id BIGINT NOT NULL,
+  start_date TIMESTAMP NOT NULL,
+  end_date TIMESTAMP NOT NULL,
+  capacity INTEGER NOT NULL,
+  stock INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_session_product_start ON session (product_id, start_date);
+CREATE TRIGGER trg_session_updated_at BEFORE UPDATE ON session FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+ALTER TABLE transaction
+  ADD COLUMN session_id BIGINT DEFAULT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_product_deleted_at;
+ALTER TABLE product
+  DROP COLUMN deleted_at;
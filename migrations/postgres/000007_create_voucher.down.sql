@@ -0,0 +1,5 @@
+ALTER TABLE transaction
+  DROP COLUMN discount;
+
+DROP TABLE IF EXISTS voucher_redemption;
+DROP TABLE IF EXISTS voucher;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS voucher(
+  id BIGSERIAL NOT NULL,
+  code VARCHAR(45) NOT NULL,
+  type VARCHAR(10) NOT NULL,
+  value DECIMAL(15,2) NOT NULL,
+  product_id BIGINT DEFAULT NULL,
+  section VARCHAR(45) NOT NULL DEFAULT '',
+  valid_from TIMESTAMP DEFAULT NULL,
+  valid_until TIMESTAMP DEFAULT NULL,
+  max_redemptions INTEGER NOT NULL DEFAULT 0,
+  max_per_user INTEGER NOT NULL DEFAULT 0,
+  redeemed INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  CONSTRAINT uq_voucher_code UNIQUE (code)
+);
+CREATE TRIGGER trg_voucher_updated_at BEFORE UPDATE ON voucher FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE IF NOT EXISTS voucher_redemption(
+  id BIGSERIAL NOT NULL,
+  voucher_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  transaction_id BIGINT NOT NULL,
+  discount DECIMAL(15,2) NOT NULL,
+  status VARCHAR(10) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  CONSTRAINT uq_voucher_redemption_transaction UNIQUE (transaction_id)
+);
+CREATE INDEX IF NOT EXISTS idx_voucher_redemption_voucher_user ON voucher_redemption (voucher_id, user_id);
+
+ALTER TABLE transaction
+  ADD COLUMN discount BIGINT DEFAULT 0;
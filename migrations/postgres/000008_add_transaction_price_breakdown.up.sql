@@ -0,0 +1,11 @@
+UPDATE transaction SET discount = 0 WHERE discount IS NULL;
+ALTER TABLE transaction
+  ADD COLUMN payment_method VARCHAR(45) NOT NULL DEFAULT '',
+  ADD COLUMN unit_price BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1,
+  ADD COLUMN subtotal BIGINT NOT NULL DEFAULT 0,
+  ALTER COLUMN discount SET NOT NULL,
+  ADD COLUMN fee BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN surcharge BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN tax BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN total BIGINT NOT NULL DEFAULT 0;
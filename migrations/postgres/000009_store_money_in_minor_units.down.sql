@@ -0,0 +1,15 @@
+ALTER TABLE voucher_redemption
+  ALTER COLUMN discount TYPE DECIMAL(15,2);
+UPDATE voucher_redemption SET discount = discount / 100;
+ALTER TABLE voucher
+  ALTER COLUMN value TYPE DECIMAL(15,2);
+UPDATE voucher SET value = value / 100;
+
+UPDATE transaction SET amount = ROUND(amount / 100.0);
+ALTER TABLE transaction
+  DROP COLUMN currency,
+  ALTER COLUMN amount DROP NOT NULL;
+
+UPDATE product SET price = ROUND(price / 100.0);
+ALTER TABLE product
+  DROP COLUMN currency;
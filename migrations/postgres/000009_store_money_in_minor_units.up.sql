@@ -0,0 +1,19 @@
+-- prices and amounts are stored in minor units with their ISO 4217 currency
+ALTER TABLE product
+  ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+UPDATE product SET price = price * 100;
+
+UPDATE transaction SET amount = 0 WHERE amount IS NULL;
+ALTER TABLE transaction
+  ALTER COLUMN amount SET NOT NULL,
+  ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+UPDATE transaction SET amount = amount * 100 WHERE total = 0;
+UPDATE transaction SET amount = total WHERE total <> 0;
+
+-- percentage vouchers move to basis points and fixed vouchers to minor units, both are x100
+UPDATE voucher SET value = ROUND(value * 100);
+ALTER TABLE voucher
+  ALTER COLUMN value TYPE BIGINT;
+UPDATE voucher_redemption SET discount = ROUND(discount * 100);
+ALTER TABLE voucher_redemption
+  ALTER COLUMN discount TYPE BIGINT;
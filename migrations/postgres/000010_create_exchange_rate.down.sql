@@ -0,0 +1,7 @@
+ALTER TABLE transaction
+  DROP COLUMN price_rate,
+  DROP COLUMN display_currency,
+  DROP COLUMN display_rate,
+  DROP COLUMN display_total;
+
+DROP TABLE IF EXISTS exchange_rate;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS exchange_rate(
+  currency CHAR(3) NOT NULL,
+  rate DECIMAL(24,12) NOT NULL,
+  source VARCHAR(10) NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (currency)
+);
+CREATE TRIGGER trg_exchange_rate_updated_at BEFORE UPDATE ON exchange_rate FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+ALTER TABLE transaction
+  ADD COLUMN price_rate DECIMAL(24,12) DEFAULT NULL,
+  ADD COLUMN display_currency CHAR(3) DEFAULT NULL,
+  ADD COLUMN display_rate DECIMAL(24,12) DEFAULT NULL,
+  ADD COLUMN display_total BIGINT DEFAULT NULL;
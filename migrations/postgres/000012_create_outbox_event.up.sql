@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS outbox_event(
+  id BIGSERIAL NOT NULL,
+  type VARCHAR(45) NOT NULL,
+  aggregate_id BIGINT NOT NULL,
+  payload JSON NOT NULL,
+  status VARCHAR(10) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error VARCHAR(1000) NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  dispatched_at TIMESTAMP DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_event_pending ON outbox_event (status, next_attempt_at);
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS webhook(
+  id BIGSERIAL NOT NULL,
+  url VARCHAR(255) NOT NULL,
+  event_types VARCHAR(255) NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  active SMALLINT NOT NULL DEFAULT 1,
+  failure_count INTEGER NOT NULL DEFAULT 0,
+  disabled_at TIMESTAMP DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+CREATE TRIGGER trg_webhook_updated_at BEFORE UPDATE ON webhook FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE IF NOT EXISTS webhook_delivery(
+  id BIGSERIAL NOT NULL,
+  webhook_id BIGINT NOT NULL,
+  event_id BIGINT NOT NULL,
+  event_type VARCHAR(45) NOT NULL,
+  payload JSON NOT NULL,
+  status VARCHAR(10) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  response_code INTEGER NOT NULL DEFAULT 0,
+  last_error VARCHAR(1000) NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP NOT NULL,
+  delivered_at TIMESTAMP DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  CONSTRAINT uq_webhook_delivery_event UNIQUE (webhook_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_pending ON webhook_delivery (status, next_attempt_at);
+CREATE TRIGGER trg_webhook_delivery_updated_at BEFORE UPDATE ON webhook_delivery FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS job(
+  id BIGSERIAL NOT NULL,
+  type VARCHAR(45) NOT NULL,
+  payload JSON NOT NULL,
+  unique_key VARCHAR(100) DEFAULT NULL,
+  status VARCHAR(10) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL,
+  last_error VARCHAR(1000) NOT NULL DEFAULT '',
+  run_at TIMESTAMP NOT NULL,
+  lease_token CHAR(32) DEFAULT NULL,
+  locked_until TIMESTAMP DEFAULT NULL,
+  finished_at TIMESTAMP DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  CONSTRAINT uq_job_unique_key UNIQUE (unique_key),
+  CONSTRAINT uq_job_lease_token UNIQUE (lease_token)
+);
+CREATE INDEX IF NOT EXISTS idx_job_due ON job (status, run_at);
+CREATE INDEX IF NOT EXISTS idx_job_finished ON job (status, finished_at);
+CREATE TRIGGER trg_job_updated_at BEFORE UPDATE ON job FOR EACH ROW EXECUTE FUNCTION set_updated_at();
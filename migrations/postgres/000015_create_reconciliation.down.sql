@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transaction_status_created_at;
+
+DROP TABLE IF EXISTS reconciliation;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS reconciliation(
+  id BIGSERIAL NOT NULL,
+  transaction_id BIGINT NOT NULL,
+  provider_status VARCHAR(45) NOT NULL DEFAULT '',
+  previous_status VARCHAR(10) NOT NULL,
+  status VARCHAR(10) NOT NULL,
+  currency CHAR(3) NOT NULL,
+  expected_amount BIGINT NOT NULL,
+  provider_amount BIGINT NOT NULL DEFAULT 0,
+  result VARCHAR(10) NOT NULL,
+  message VARCHAR(1000) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_created_at ON reconciliation (created_at);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_transaction ON reconciliation (transaction_id);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_status_created_at ON transaction (status, created_at);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS waiting_room(
+  product_id BIGINT NOT NULL,
+  rate BIGINT NOT NULL,
+  checkout_window BIGINT NOT NULL,
+  paused BOOLEAN NOT NULL DEFAULT FALSE,
+  admitted BIGINT NOT NULL DEFAULT 0,
+  admitted_at TIMESTAMP NOT NULL,
+  last_position BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (product_id)
+);
+CREATE TRIGGER trg_waiting_room_updated_at BEFORE UPDATE ON waiting_room FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE IF NOT EXISTS waiting_room_entry(
+  product_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  position BIGINT NOT NULL,
+  admitted_at TIMESTAMP DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (product_id, user_id),
+  CONSTRAINT idx_waiting_room_entry_position UNIQUE (product_id, position)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_key
+  ADD COLUMN locked_until TIMESTAMP DEFAULT NULL;
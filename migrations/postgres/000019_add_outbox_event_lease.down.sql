@@ -0,0 +1,8 @@
+UPDATE outbox_event SET status='pending' WHERE status='dispatching';
+
+DROP INDEX IF EXISTS idx_outbox_event_locked;
+DROP INDEX IF EXISTS idx_outbox_event_lease_token;
+ALTER TABLE outbox_event
+  DROP COLUMN locked_until,
+  DROP COLUMN lease_token,
+  ALTER COLUMN status TYPE VARCHAR(10);
@@ -0,0 +1,6 @@
+ALTER TABLE outbox_event
+  ALTER COLUMN status TYPE VARCHAR(20),
+  ADD COLUMN lease_token CHAR(32) DEFAULT NULL,
+  ADD COLUMN locked_until TIMESTAMP DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_event_lease_token ON outbox_event (lease_token);
+CREATE INDEX IF NOT EXISTS idx_outbox_event_locked ON outbox_event (status, locked_until);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_outbox_event_created ON outbox_event (created_at, type);
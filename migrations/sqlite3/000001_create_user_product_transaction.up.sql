@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS `user`(
+  id INTEGER NOT NULL,
+  username VARCHAR(45) NOT NULL,
+  password VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  phone VARCHAR(13) NOT NULL,
+  address VARCHAR(255) DEFAULT NULL,
+  roles VARCHAR(10) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS product(
+  id INTEGER NOT NULL,
+  name VARCHAR(45) NOT NULL,
+  description VARCHAR(255) NOT NULL,
+  price BIGINT NOT NULL,
+  stock INTEGER NOT NULL,
+  image_url VARCHAR(255),
+  start_date VARCHAR(255),
+  end_date VARCHAR(255),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS `transaction`(
+  id INTEGER NOT NULL,
+  product_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  amount BIGINT DEFAULT 0,
+  status VARCHAR(10) NOT NULL,
+  code VARCHAR(255),
+  payment_url VARCHAR(255),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
@@ -0,0 +1,30 @@
+-- the triggers keep updated_at current like ON UPDATE CURRENT_TIMESTAMP of MySQL
+CREATE TABLE IF NOT EXISTS venue(
+  id INTEGER NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  address VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS seat(
+  id INTEGER NOT NULL,
+  venue_id BIGINT NOT NULL,
+  section VARCHAR(45) NOT NULL,
+  row_label VARCHAR(10) NOT NULL,
+  number INTEGER NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT uq_seat UNIQUE (venue_id, section, row_label, number)
+);
+
+CREATE TABLE IF NOT EXISTS product_seat(
+  product_id BIGINT NOT NULL,
+  seat_id BIGINT NOT NULL,
+  status VARCHAR(10) NOT NULL,
+  transaction_id BIGINT DEFAULT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (product_id, seat_id)
+);
+CREATE INDEX IF NOT EXISTS idx_product_seat_transaction ON product_seat (transaction_id);
+CREATE TRIGGER IF NOT EXISTS trg_product_seat_updated_at AFTER UPDATE ON product_seat FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at BEGIN UPDATE product_seat SET updated_at = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END;
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS product_tag;
+
+DROP INDEX IF EXISTS idx_product_category;
+ALTER TABLE product DROP COLUMN category_id;
+ALTER TABLE product DROP COLUMN organizer_name;
+ALTER TABLE product DROP COLUMN organizer_contact;
+ALTER TABLE product DROP COLUMN location;
+ALTER TABLE product DROP COLUMN address;
+ALTER TABLE product DROP COLUMN latitude;
+ALTER TABLE product DROP COLUMN longitude;
+
+DROP TABLE IF EXISTS category;
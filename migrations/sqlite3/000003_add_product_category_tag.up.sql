@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS category(
+  id INTEGER NOT NULL,
+  parent_id BIGINT DEFAULT NULL,
+  name VARCHAR(45) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_category_parent ON category (parent_id);
+
+ALTER TABLE product ADD COLUMN category_id BIGINT DEFAULT NULL;
+ALTER TABLE product ADD COLUMN organizer_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE product ADD COLUMN organizer_contact VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE product ADD COLUMN location VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE product ADD COLUMN address VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE product ADD COLUMN latitude REAL NOT NULL DEFAULT 0;
+ALTER TABLE product ADD COLUMN longitude REAL NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_product_category ON product (category_id);
+
+CREATE TABLE IF NOT EXISTS product_tag(
+  product_id BIGINT NOT NULL,
+  tag VARCHAR(45) NOT NULL,
+  PRIMARY KEY (product_id, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_product_tag_tag ON product_tag (tag);
@@ -0,0 +1,3 @@
+ALTER TABLE `transaction` DROP COLUMN session_id;
+
+DROP TABLE IF EXISTS session;
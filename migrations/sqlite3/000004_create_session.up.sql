@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS session(
+  id INTEGER NOT NULL,
+  product_id BIGINT NOT NULL,
+  start_date TIMESTAMP NOT NULL,
+  end_date TIMESTAMP NOT NULL,
+  capacity INTEGER NOT NULL,
+  stock INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_session_product_start ON session (product_id, start_date);
+CREATE TRIGGER IF NOT EXISTS trg_session_updated_at AFTER UPDATE ON session FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at BEGIN UPDATE session SET updated_at = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END;
+
+ALTER TABLE `transaction` ADD COLUMN session_id BIGINT DEFAULT NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS waitlist(
+  id INTEGER NOT NULL,
+  product_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  status VARCHAR(10) NOT NULL,
+  offer_expires_at TIMESTAMP DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_waitlist_product_status ON waitlist (product_id, status);
+CREATE INDEX IF NOT EXISTS idx_waitlist_user ON waitlist (user_id);
+CREATE TRIGGER IF NOT EXISTS trg_waitlist_updated_at AFTER UPDATE ON waitlist FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at BEGIN UPDATE waitlist SET updated_at = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_product_deleted_at;
+ALTER TABLE product DROP COLUMN deleted_at;
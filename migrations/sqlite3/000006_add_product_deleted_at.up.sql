@@ -0,0 +1,2 @@
+ALTER TABLE product ADD COLUMN deleted_at TIMESTAMP DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS idx_product_deleted_at ON product (deleted_at);
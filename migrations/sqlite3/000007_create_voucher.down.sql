@@ -0,0 +1,4 @@
+ALTER TABLE `transaction` DROP COLUMN discount;
+
+DROP TABLE IF EXISTS voucher_redemption;
+DROP TABLE IF EXISTS voucher;
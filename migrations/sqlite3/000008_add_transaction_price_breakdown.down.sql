@@ -0,0 +1,8 @@
+ALTER TABLE `transaction` DROP COLUMN payment_method;
+ALTER TABLE `transaction` DROP COLUMN unit_price;
+ALTER TABLE `transaction` DROP COLUMN quantity;
+ALTER TABLE `transaction` DROP COLUMN subtotal;
+ALTER TABLE `transaction` DROP COLUMN fee;
+ALTER TABLE `transaction` DROP COLUMN surcharge;
+ALTER TABLE `transaction` DROP COLUMN tax;
+ALTER TABLE `transaction` DROP COLUMN total;
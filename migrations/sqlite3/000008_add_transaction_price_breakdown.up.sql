@@ -0,0 +1,9 @@
+-- a column of SQLite can not be changed, discount stays nullable and is always written
+ALTER TABLE `transaction` ADD COLUMN payment_method VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE `transaction` ADD COLUMN unit_price BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE `transaction` ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE `transaction` ADD COLUMN subtotal BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE `transaction` ADD COLUMN fee BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE `transaction` ADD COLUMN surcharge BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE `transaction` ADD COLUMN tax BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE `transaction` ADD COLUMN total BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+UPDATE voucher_redemption SET discount = discount / 100.0;
+UPDATE voucher SET value = value / 100.0;
+
+UPDATE `transaction` SET amount = ROUND(amount / 100.0);
+ALTER TABLE `transaction` DROP COLUMN currency;
+
+UPDATE product SET price = ROUND(price / 100.0);
+ALTER TABLE product DROP COLUMN currency;
@@ -0,0 +1,13 @@
+-- prices and amounts are stored in minor units with their ISO 4217 currency
+ALTER TABLE product ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+UPDATE product SET price = price * 100;
+
+-- a column of SQLite can not be changed, amount stays nullable and is always written
+ALTER TABLE `transaction` ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+UPDATE `transaction` SET amount = amount * 100 WHERE total = 0;
+UPDATE `transaction` SET amount = total WHERE total <> 0;
+
+-- percentage vouchers move to basis points and fixed vouchers to minor units, both are x100.
+-- A column of SQLite holds whole numbers whatever its type, only the values change.
+UPDATE voucher SET value = ROUND(value * 100);
+UPDATE voucher_redemption SET discount = ROUND(discount * 100);
@@ -0,0 +1,6 @@
+ALTER TABLE `transaction` DROP COLUMN price_rate;
+ALTER TABLE `transaction` DROP COLUMN display_currency;
+ALTER TABLE `transaction` DROP COLUMN display_rate;
+ALTER TABLE `transaction` DROP COLUMN display_total;
+
+DROP TABLE IF EXISTS exchange_rate;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS exchange_rate(
+  currency CHAR(3) NOT NULL,
+  -- DECIMAL of SQLite is a float, the rate is kept exactly as written
+  rate TEXT NOT NULL,
+  source VARCHAR(10) NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (currency)
+);
+CREATE TRIGGER IF NOT EXISTS trg_exchange_rate_updated_at AFTER UPDATE ON exchange_rate FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at BEGIN UPDATE exchange_rate SET updated_at = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END;
+
+ALTER TABLE `transaction` ADD COLUMN price_rate DECIMAL(24,12) DEFAULT NULL;
+ALTER TABLE `transaction` ADD COLUMN display_currency CHAR(3) DEFAULT NULL;
+ALTER TABLE `transaction` ADD COLUMN display_rate DECIMAL(24,12) DEFAULT NULL;
+ALTER TABLE `transaction` ADD COLUMN display_total BIGINT DEFAULT NULL;
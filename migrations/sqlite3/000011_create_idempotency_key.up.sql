@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_key(
+  scope CHAR(64) NOT NULL,
+  idempotency_key VARCHAR(255) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+  status_code INTEGER DEFAULT NULL,
+  response BLOB,
+  completed SMALLINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (scope, idempotency_key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_key_created_at ON idempotency_key (created_at);
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS webhook(
+  id INTEGER NOT NULL,
+  url VARCHAR(255) NOT NULL,
+  event_types VARCHAR(255) NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  active SMALLINT NOT NULL DEFAULT 1,
+  failure_count INTEGER NOT NULL DEFAULT 0,
+  disabled_at TIMESTAMP DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+CREATE TRIGGER IF NOT EXISTS trg_webhook_updated_at AFTER UPDATE ON webhook FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at BEGIN UPDATE webhook SET updated_at = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END;
+
+CREATE TABLE IF NOT EXISTS webhook_delivery(
+  id INTEGER NOT NULL,
+  webhook_id BIGINT NOT NULL,
+  event_id BIGINT NOT NULL,
+  event_type VARCHAR(45) NOT NULL,
+  payload TEXT NOT NULL,
+  status VARCHAR(10) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  response_code INTEGER NOT NULL DEFAULT 0,
+  last_error VARCHAR(1000) NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP NOT NULL,
+  delivered_at TIMESTAMP DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  CONSTRAINT uq_webhook_delivery_event UNIQUE (webhook_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_pending ON webhook_delivery (status, next_attempt_at);
+CREATE TRIGGER IF NOT EXISTS trg_webhook_delivery_updated_at AFTER UPDATE ON webhook_delivery FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at BEGIN UPDATE webhook_delivery SET updated_at = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END;
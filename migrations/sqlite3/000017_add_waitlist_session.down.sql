@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_waitlist_product_session_status;
+CREATE INDEX IF NOT EXISTS idx_waitlist_product_status ON waitlist (product_id, status);
+ALTER TABLE waitlist DROP COLUMN session_id;
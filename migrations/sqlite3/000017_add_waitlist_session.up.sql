@@ -0,0 +1,3 @@
+ALTER TABLE waitlist ADD COLUMN session_id BIGINT NOT NULL DEFAULT 0;
+DROP INDEX IF EXISTS idx_waitlist_product_status;
+CREATE INDEX IF NOT EXISTS idx_waitlist_product_session_status ON waitlist (product_id, session_id, status);
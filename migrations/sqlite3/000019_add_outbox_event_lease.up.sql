@@ -0,0 +1,5 @@
+-- SQLite does not limit the length of a VARCHAR, status takes dispatching as it is
+ALTER TABLE outbox_event ADD COLUMN lease_token CHAR(32) DEFAULT NULL;
+ALTER TABLE outbox_event ADD COLUMN locked_until TIMESTAMP DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_event_lease_token ON outbox_event (lease_token);
+CREATE INDEX IF NOT EXISTS idx_outbox_event_locked ON outbox_event (status, locked_until);
@@ -0,0 +1,6 @@
+UPDATE webhook_delivery SET status='pending' WHERE status='sending';
+
+DROP INDEX IF EXISTS idx_webhook_delivery_locked;
+DROP INDEX IF EXISTS idx_webhook_delivery_lease_token;
+ALTER TABLE webhook_delivery DROP COLUMN locked_until;
+ALTER TABLE webhook_delivery DROP COLUMN lease_token;
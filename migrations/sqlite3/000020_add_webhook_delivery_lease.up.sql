@@ -0,0 +1,4 @@
+ALTER TABLE webhook_delivery ADD COLUMN lease_token CHAR(32) DEFAULT NULL;
+ALTER TABLE webhook_delivery ADD COLUMN locked_until TIMESTAMP DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_lease_token ON webhook_delivery (lease_token);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_locked ON webhook_delivery (status, locked_until);
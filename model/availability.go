@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Availability is what is left of a product, streamed to the customers watching it
+type Availability struct {
+	ProductID int64 `json:"product_id"`
+	// Stock is the stock of the product, a product with sessions sells the stock of its sessions instead
+	Stock    int64                 `json:"stock"`
+	Sessions []SessionAvailability `json:"sessions,omitempty"`
+	// SeatsAvailable counts the seats neither held nor sold, for products with a seat map
+	SeatsAvailable *int64    `json:"seats_available,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// StreamToken opens the availability stream of a product, for clients like the
+// EventSource of browsers that cannot send the Authorization header
+type StreamToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SessionAvailability struct {
+	SessionID int64     `json:"session_id"`
+	StartDate time.Time `json:"start_date"`
+	Stock     int64     `json:"stock"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+type Category struct {
+	ID        int64     `json:"id"`
+	ParentID  int64     `json:"parent_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CategoryRequest struct {
+	ID       int64  `json:"-"`
+	ParentID int64  `json:"parent_id"`
+	Name     string `json:"name" validate:"required,min=3,max=45"`
+}
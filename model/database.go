@@ -0,0 +1,39 @@
+package model
+
+import (
+	"database/sql"
+)
+
+// DatabaseStats are the statistics of the database connection pool, durations are in milliseconds
+type DatabaseStats struct {
+	MaxOpenConnections int            `json:"max_open_connections"`
+	OpenConnections    int            `json:"open_connections"`
+	InUse              int            `json:"in_use"`
+	Idle               int            `json:"idle"`
+	WaitCount          int64          `json:"wait_count"`
+	WaitDuration       int64          `json:"wait_duration"`
+	MaxIdleClosed      int64          `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64          `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64          `json:"max_lifetime_closed"`
+	Replicas           []ReplicaStats `json:"replicas,omitempty"`
+}
+
+// ReplicaStats are the statistics of the pool of a read replica
+type ReplicaStats struct {
+	Healthy bool `json:"healthy"`
+	DatabaseStats
+}
+
+func NewDatabaseStats(stats sql.DBStats) DatabaseStats {
+	return DatabaseStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
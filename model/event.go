@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is a domain event kept in the outbox until it is delivered to its subscribers
+type Event struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LeaseToken    string          `json:"-"`
+	LockedUntil   *time.Time      `json:"-"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DispatchedAt  *time.Time      `json:"dispatched_at"`
+}
+
+// ProductSoldOutEvent is the payload of a ProductSoldOut event, SessionID is
+// set when only one session of the product sold out
+type ProductSoldOutEvent struct {
+	ProductID int64 `json:"product_id"`
+	SessionID int64 `json:"session_id"`
+}
+
+// UserRegisteredEvent is the payload of a UserRegistered event
+type UserRegisteredEvent struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// StockUpdatedEvent is the payload of a StockUpdated event, recorded when an
+// admin changes the stock of a product or of its sessions
+type StockUpdatedEvent struct {
+	ProductID int64 `json:"product_id"`
+}
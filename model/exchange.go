@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+// ExchangeRate is how many major units of Currency one major unit of the base currency buys
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      string    `json:"rate"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExchangeRateRequest struct {
+	Currency string `json:"-"`
+	Rate     string `json:"rate" validate:"required"`
+}
+
+// ExchangeSnapshot records the exchange rates a transaction was priced with, rates
+// are relative to the currency the transaction is charged in
+type ExchangeSnapshot struct {
+	// PriceRate converted the product price to the charged currency
+	PriceRate string `json:"price_rate,omitempty"`
+	// DisplayRate converted the total to the currency shown to the customer
+	DisplayRate  string `json:"display_rate,omitempty"`
+	DisplayTotal *Money `json:"display_total,omitempty"`
+}
+
+// Quote is the price a checkout would be charged
+type Quote struct {
+	Breakdown PriceBreakdown    `json:"breakdown"`
+	Exchange  *ExchangeSnapshot `json:"exchange,omitempty"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// IdempotencyKey is a mutating request identified by the Idempotency-Key header
+// of a client, with the response it was answered with once completed
+type IdempotencyKey struct {
+	Key         string `json:"key"`
+	Scope       string `json:"scope"`
+	RequestHash string `json:"request_hash"`
+	StatusCode  int    `json:"status_code"`
+	Response    []byte `json:"response"`
+	Completed   bool   `json:"completed"`
+	// LockedUntil is when a request that is still in progress gives up the key,
+	// a retry takes it over from then on
+	LockedUntil *time.Time `json:"locked_until"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
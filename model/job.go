@@ -0,0 +1,37 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job is a unit of background work stored in the job queue. A leased job is
+// hidden from other workers until LockedUntil, after which it is run again.
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	UniqueKey   string          `json:"unique_key,omitempty"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error"`
+	RunAt       time.Time       `json:"run_at"`
+	LeaseToken  string          `json:"-"`
+	LockedUntil *time.Time      `json:"locked_until"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// NotificationJob is the payload of a queued notification
+type NotificationJob struct {
+	UserID  int64  `json:"user_id"`
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+// TicketJob is the payload of a ticket generation
+type TicketJob struct {
+	TransactionID int64 `json:"transaction_id"`
+}
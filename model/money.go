@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// currencyExponents are the ISO 4217 minor unit exponents of the supported currencies
+var currencyExponents = map[string]int{
+	"AUD": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"IDR": 2,
+	"INR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"MYR": 2,
+	"PHP": 2,
+	"SGD": 2,
+	"THB": 2,
+	"USD": 2,
+	"VND": 0,
+}
+
+// Money is an exact amount in the minor units of an ISO 4217 currency,
+// e.g. Money{Amount: 1500000, Currency: "IDR"} is Rp15.000,00
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney returns an amount of minor units in the given currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ValidCurrency reports whether currency is a supported ISO 4217 code
+func ValidCurrency(currency string) bool {
+	_, ok := currencyExponents[strings.ToUpper(currency)]
+	return ok
+}
+
+// CurrencyExponent returns the number of minor unit digits of a currency
+func CurrencyExponent(currency string) int {
+	return currencyExponents[strings.ToUpper(currency)]
+}
+
+// MinorUnits returns how many minor units make one major unit of a currency
+func MinorUnits(currency string) int64 {
+	units := int64(1)
+	for i := 0; i < CurrencyExponent(currency); i++ {
+		units *= 10
+	}
+
+	return units
+}
+
+// Whole rounds the amount half away from zero to whole major units
+func (m Money) Whole() int64 {
+	units := MinorUnits(m.Currency)
+	if m.Amount < 0 {
+		return -((-m.Amount + units/2) / units)
+	}
+
+	return (m.Amount + units/2) / units
+}
+
+// String formats the amount in major units, e.g. "15000.00 IDR"
+func (m Money) String() string {
+	exponent := CurrencyExponent(m.Currency)
+	if exponent == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	units := MinorUnits(m.Currency)
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/units, exponent, amount%units, m.Currency)
+}
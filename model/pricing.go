@@ -0,0 +1,19 @@
+package model
+
+// PriceBreakdown holds every component of a price in minor units of Currency
+type PriceBreakdown struct {
+	Currency  string `json:"currency"`
+	UnitPrice int64  `json:"unit_price"`
+	Quantity  int64  `json:"quantity"`
+	Subtotal  int64  `json:"subtotal"`
+	Discount  int64  `json:"discount"`
+	Fee       int64  `json:"fee"`
+	Surcharge int64  `json:"surcharge"`
+	Tax       int64  `json:"tax"`
+	Total     int64  `json:"total"`
+}
+
+// TotalMoney returns the total of the breakdown
+func (b PriceBreakdown) TotalMoney() Money {
+	return NewMoney(b.Total, b.Currency)
+}
@@ -5,25 +5,67 @@ import (
 )
 
 type Product struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float32   `json:"price"`
-	Stock       int64     `json:"stock"`
-	ImageURL    string    `json:"image_url"`
-	StartDate   string    `json:"start_date"`
-	EndDate     string    `json:"end_date"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	// DisplayPrice is the price converted to the currency requested by the visitor
+	DisplayPrice     *Money     `json:"display_price,omitempty"`
+	Stock            int64      `json:"stock"`
+	ImageURL         string     `json:"image_url"`
+	StartDate        string     `json:"start_date"`
+	EndDate          string     `json:"end_date"`
+	CategoryID       int64      `json:"category_id"`
+	Tags             []string   `json:"tags"`
+	OrganizerName    string     `json:"organizer_name"`
+	OrganizerContact string     `json:"organizer_contact"`
+	Location         string     `json:"location"`
+	Address          string     `json:"address"`
+	Latitude         float64    `json:"latitude"`
+	Longitude        float64    `json:"longitude"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
 }
 
 type ProductRequest struct {
-	ID          string  `json:"_id"`
-	Name        string  `json:"name" validate:"required,min=3,max=45"`
-	Description string  `json:"description" validate:"required"`
-	Price       float32 `json:"price"`
-	Stock       int64   `json:"stock"`
-	ImageURL    string  `json:"image_url"`
-	StartDate   string  `json:"start_date"`
-	EndDate     string  `json:"end_date"`
+	ID               string   `json:"_id"`
+	Name             string   `json:"name" validate:"required,min=3,max=45"`
+	Description      string   `json:"description" validate:"required"`
+	Price            Money    `json:"price"`
+	Stock            int64    `json:"stock"`
+	ImageURL         string   `json:"image_url"`
+	StartDate        string   `json:"start_date"`
+	EndDate          string   `json:"end_date"`
+	CategoryID       int64    `json:"category_id"`
+	Tags             []string `json:"tags" validate:"dive,required,max=45,excludesall=0x2C"`
+	OrganizerName    string   `json:"organizer_name" validate:"max=100"`
+	OrganizerContact string   `json:"organizer_contact" validate:"max=100"`
+	Location         string   `json:"location" validate:"max=100"`
+	Address          string   `json:"address" validate:"max=255"`
+	Latitude         float64  `json:"latitude" validate:"min=-90,max=90"`
+	Longitude        float64  `json:"longitude" validate:"min=-180,max=180"`
+	// CascadeSessions applies the new stock as capacity of every upcoming session
+	CascadeSessions bool `json:"cascade_sessions"`
+}
+
+type StockRequest struct {
+	ProductID int64 `json:"-"`
+	Quantity  int64 `json:"quantity" validate:"required"`
+}
+
+// ProductFilter narrows down the product listing, zero values are ignored
+type ProductFilter struct {
+	CategoryID  int64   `query:"category_id"`
+	CategoryIDs []int64 `query:"-"`
+	Tag         string  `query:"tag"`
+	Organizer   string  `query:"organizer"`
+	Location    string  `query:"location"`
+	Latitude    float64 `query:"lat"`
+	Longitude   float64 `query:"lng"`
+	RadiusKM    float64 `query:"radius_km"`
+	// WithDeleted includes soft deleted products, it is only honored for admins
+	WithDeleted bool `query:"with_deleted"`
+	// Currency converts the listed prices, it defaults to the Accept-Currency header
+	Currency string `query:"currency"`
 }
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// Reconciliation is the outcome of checking a pending transaction against the
+// payment gateway, amounts are in minor units of the transaction currency
+type Reconciliation struct {
+	ID             int64     `json:"id"`
+	TransactionID  int64     `json:"transaction_id"`
+	ProviderStatus string    `json:"provider_status"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	Currency       string    `json:"currency"`
+	ExpectedAmount int64     `json:"expected_amount"`
+	ProviderAmount int64     `json:"provider_amount"`
+	Result         string    `json:"result"`
+	Message        string    `json:"message"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ReconciliationReport sums up the reconciliations of a day
+type ReconciliationReport struct {
+	Date            string           `json:"date"`
+	Applied         int              `json:"applied"`
+	Paid            int              `json:"paid"`
+	Cancelled       int              `json:"cancelled"`
+	Mismatches      int              `json:"mismatches"`
+	Failures        int              `json:"failures"`
+	Reconciliations []Reconciliation `json:"reconciliations"`
+}
@@ -0,0 +1,55 @@
+package model
+
+// Fixture is the data loaded by the seed command from a YAML or JSON file
+type Fixture struct {
+	Admin        SeedUser         `json:"admin" yaml:"admin"`
+	Users        []SeedUser       `json:"users" yaml:"users"`
+	Products     []SeedProduct    `json:"products" yaml:"products"`
+	Transactions SeedTransactions `json:"transactions" yaml:"transactions"`
+}
+
+type SeedUser struct {
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	Email    string `json:"email" yaml:"email"`
+	Phone    string `json:"phone" yaml:"phone"`
+	Address  string `json:"address" yaml:"address"`
+}
+
+// SeedProduct is a sample event, its dates are relative to the day it is seeded
+// so the demo data never lies in the past
+type SeedProduct struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	Price       int64  `json:"price" yaml:"price"`
+	Currency    string `json:"currency" yaml:"currency"`
+	Stock       int64  `json:"stock" yaml:"stock"`
+	ImageURL    string `json:"image_url" yaml:"image_url"`
+	// StartsIn is how many days from today the event starts, at StartTime
+	StartsIn  int    `json:"starts_in" yaml:"starts_in"`
+	StartTime string `json:"start_time" yaml:"start_time"`
+	// Duration is how many hours the event lasts
+	Duration         int      `json:"duration" yaml:"duration"`
+	Tags             []string `json:"tags" yaml:"tags"`
+	OrganizerName    string   `json:"organizer_name" yaml:"organizer_name"`
+	OrganizerContact string   `json:"organizer_contact" yaml:"organizer_contact"`
+	Location         string   `json:"location" yaml:"location"`
+	Address          string   `json:"address" yaml:"address"`
+	Latitude         float64  `json:"latitude" yaml:"latitude"`
+	Longitude        float64  `json:"longitude" yaml:"longitude"`
+}
+
+// SeedTransactions is the volume of synthetic paid transactions bought by the seeded users
+type SeedTransactions struct {
+	PerProduct int `json:"per_product" yaml:"per_product"`
+	// Tickets also renders the PDF ticket of every seeded transaction
+	Tickets bool `json:"tickets" yaml:"tickets"`
+}
+
+// SeedResult counts what a seed run created, records that already existed are skipped
+type SeedResult struct {
+	Users        int `json:"users"`
+	Products     int `json:"products"`
+	Transactions int `json:"transactions"`
+	Tickets      int `json:"tickets"`
+}
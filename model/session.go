@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+type Session struct {
+	ID        int64     `json:"id"`
+	ProductID int64     `json:"product_id"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Capacity  int64     `json:"capacity"`
+	Stock     int64     `json:"stock"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SessionItem struct {
+	StartDate time.Time `json:"start_date" validate:"required"`
+	EndDate   time.Time `json:"end_date" validate:"required"`
+	Capacity  int64     `json:"capacity" validate:"required,min=1"`
+}
+
+// SessionRequest creates sessions either from an explicit list or from a
+// recurrence rule (e.g. FREQ=WEEKLY;COUNT=10;BYDAY=MO,WE) applied to First
+type SessionRequest struct {
+	ProductID  int64         `json:"-"`
+	Sessions   []SessionItem `json:"sessions" validate:"dive"`
+	Recurrence string        `json:"recurrence"`
+	First      *SessionItem  `json:"first"`
+}
+
+type UpdateSessionRequest struct {
+	ID        int64     `json:"-"`
+	ProductID int64     `json:"-"`
+	StartDate time.Time `json:"start_date" validate:"required"`
+	EndDate   time.Time `json:"end_date" validate:"required"`
+	Capacity  int64     `json:"capacity" validate:"required,min=1"`
+}
@@ -5,19 +5,31 @@ import (
 )
 
 type Transaction struct {
-	ID         int64     `json:"id"`
-	ProductID  int64     `json:"product_id"`
-	UserID     int64     `json:"user_id"`
-	Amount     float32   `json:"amount"`
-	Status     string    `json:"status"`
-	PaymentURL string    `json:"payment_url"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID            int64             `json:"id"`
+	ProductID     int64             `json:"product_id"`
+	SessionID     int64             `json:"session_id"`
+	UserID        int64             `json:"user_id"`
+	Amount        Money             `json:"amount"`
+	PaymentMethod string            `json:"payment_method"`
+	Breakdown     PriceBreakdown    `json:"breakdown"`
+	Exchange      *ExchangeSnapshot `json:"exchange,omitempty"`
+	Status        string            `json:"status"`
+	PaymentURL    string            `json:"payment_url"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
 }
 
 type CreateTransactionRequest struct {
-	ProductID int64 `json:"product_id" validate:"required"`
-	User      User
+	ProductID     int64   `json:"product_id" validate:"required"`
+	SessionID     int64   `json:"session_id"`
+	SeatIDs       []int64 `json:"seat_ids"`
+	PromoCode     string  `json:"promo_code"`
+	PaymentMethod string  `json:"payment_method"`
+	// Currency is the currency the total is shown in, the transaction is charged in the base currency
+	Currency string `json:"currency"`
+	// AdmissionToken is given by the waiting room of the product, when it has one
+	AdmissionToken string `json:"admission_token"`
+	User           User
 }
 
 type UpdateTransactionRequest struct {
@@ -25,4 +37,7 @@ type UpdateTransactionRequest struct {
 	OrderID           string `json:"order_id"`
 	PaymentType       string `json:"payment_type"`
 	FraudStatus       string `json:"fraud_status"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
 }
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+)
+
+type Venue struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type VenueRequest struct {
+	Name    string `json:"name" validate:"required,min=3,max=100"`
+	Address string `json:"address" validate:"required"`
+}
+
+type Seat struct {
+	ID      int64  `json:"id"`
+	VenueID int64  `json:"venue_id"`
+	Section string `json:"section"`
+	Row     string `json:"row"`
+	Number  int64  `json:"number"`
+}
+
+type SeatRequest struct {
+	VenueID int64   `json:"-"`
+	Section string  `json:"section" validate:"required,max=45"`
+	Row     string  `json:"row" validate:"required,max=10"`
+	Numbers []int64 `json:"numbers" validate:"required,min=1"`
+}
+
+// ProductSeat is a seat of a venue as it is sold for a specific product
+type ProductSeat struct {
+	ProductID     int64  `json:"product_id"`
+	SeatID        int64  `json:"seat_id"`
+	Section       string `json:"section"`
+	Row           string `json:"row"`
+	Number        int64  `json:"number"`
+	Status        string `json:"status"`
+	TransactionID int64  `json:"-"`
+}
+
+type SeatMapRequest struct {
+	ProductID int64 `json:"-"`
+	VenueID   int64 `json:"venue_id" validate:"required"`
+}
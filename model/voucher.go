@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+)
+
+// Voucher is a promo code. The Value of a percentage voucher is in basis points
+// (1000 is 10%), the Value of a fixed voucher is in minor units of the price.
+type Voucher struct {
+	ID             int64      `json:"id"`
+	Code           string     `json:"code"`
+	Type           string     `json:"type"`
+	Value          int64      `json:"value"`
+	ProductID      int64      `json:"product_id"`
+	Section        string     `json:"section"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MaxRedemptions int64      `json:"max_redemptions"`
+	MaxPerUser     int64      `json:"max_per_user"`
+	Redeemed       int64      `json:"redeemed"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// VoucherRequest creates or updates a voucher. ProductID and Section scope the
+// voucher to a single product and to one seat section (tier) of it, zero
+// values make the voucher valid for everything.
+type VoucherRequest struct {
+	ID             int64      `json:"-"`
+	Code           string     `json:"code" validate:"required,min=3,max=45,alphanum"`
+	Type           string     `json:"type" validate:"required,oneof=percentage fixed"`
+	Value          int64      `json:"value" validate:"required,gt=0"`
+	ProductID      int64      `json:"product_id"`
+	Section        string     `json:"section" validate:"max=45"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MaxRedemptions int64      `json:"max_redemptions" validate:"min=0"`
+	MaxPerUser     int64      `json:"max_per_user" validate:"min=0"`
+}
+
+type VoucherRedemption struct {
+	ID            int64     `json:"id"`
+	VoucherID     int64     `json:"voucher_id"`
+	UserID        int64     `json:"user_id"`
+	TransactionID int64     `json:"transaction_id"`
+	Discount      int64     `json:"discount"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type VoucherReport struct {
+	Voucher       Voucher             `json:"voucher"`
+	Reserved      int64               `json:"reserved"`
+	Redeemed      int64               `json:"redeemed"`
+	Released      int64               `json:"released"`
+	TotalDiscount int64               `json:"total_discount"`
+	Redemptions   []VoucherRedemption `json:"redemptions"`
+}
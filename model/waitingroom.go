@@ -0,0 +1,92 @@
+package model
+
+import (
+	"time"
+)
+
+// WaitingRoom queues the buyers of a product during an on-sale and lets them
+// into checkout in the order they joined, Rate of them per minute
+type WaitingRoom struct {
+	ProductID int64 `json:"product_id"`
+	// Rate is how many buyers are let in per minute
+	Rate int64 `json:"rate"`
+	// CheckoutWindow is how many minutes a buyer has to check out once let in
+	CheckoutWindow int64 `json:"checkout_window"`
+	Paused         bool  `json:"paused"`
+	// Admitted is the last position let in at AdmittedAt, the positions after
+	// it are let in at Rate from then on unless the room is paused
+	Admitted     int64     `json:"admitted"`
+	AdmittedAt   time.Time `json:"admitted_at"`
+	LastPosition int64     `json:"last_position"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// AdmittedUpTo returns the last position let in at now
+func (w WaitingRoom) AdmittedUpTo(now time.Time) int64 {
+	if w.Paused || !now.After(w.AdmittedAt) {
+		return w.Admitted
+	}
+
+	return w.Admitted + int64(now.Sub(w.AdmittedAt).Minutes()*float64(w.Rate))
+}
+
+// Checkpoint moves Admitted to the last position let in at now, so the rate
+// can change from now on without changing who was let in before. Admissions
+// never run ahead of the queue, a room standing empty does not save them up.
+func (w *WaitingRoom) Checkpoint(now time.Time) {
+	w.Admitted = w.AdmittedUpTo(now)
+	if w.Admitted > w.LastPosition {
+		w.Admitted = w.LastPosition
+	}
+	w.AdmittedAt = now
+}
+
+type WaitingRoomRequest struct {
+	ProductID      int64 `json:"-"`
+	Rate           int64 `json:"rate" validate:"required,min=1"`
+	CheckoutWindow int64 `json:"checkout_window" validate:"min=0"`
+}
+
+// UpdateWaitingRoomRequest adjusts the flow of a waiting room, the fields left out keep their value
+type UpdateWaitingRoomRequest struct {
+	ProductID      int64  `json:"-"`
+	Rate           *int64 `json:"rate" validate:"omitempty,min=1"`
+	CheckoutWindow *int64 `json:"checkout_window" validate:"omitempty,min=1"`
+	Paused         *bool  `json:"paused"`
+	// Admit lets that many more buyers in right away
+	Admit int64 `json:"admit" validate:"min=0"`
+}
+
+// WaitingRoomStats is a waiting room as its admins see it
+type WaitingRoomStats struct {
+	WaitingRoom
+	// AdmittedUpTo is the last position let in right now
+	AdmittedUpTo int64 `json:"admitted_up_to"`
+	// Waiting counts the buyers not let in yet
+	Waiting int64 `json:"waiting"`
+}
+
+type QueueEntry struct {
+	ProductID  int64      `json:"product_id"`
+	UserID     int64      `json:"user_id"`
+	Position   int64      `json:"position"`
+	AdmittedAt *time.Time `json:"admitted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// QueueStatus is where a buyer stands in a waiting room
+type QueueStatus struct {
+	ProductID int64 `json:"product_id"`
+	Position  int64 `json:"position"`
+	// Ahead counts the buyers before this one that are not let in yet
+	Ahead  int64 `json:"ahead"`
+	Paused bool  `json:"paused"`
+	// EstimatedWait is the expected wait in seconds at the current rate, unknown while paused
+	EstimatedWait *int64 `json:"estimated_wait"`
+	// QueueToken proves the position, it is sent back to read the status
+	QueueToken string `json:"queue_token"`
+	// AdmissionToken is given once the buyer is let in, checkout requires it until AdmissionExpiresAt
+	AdmissionToken     string     `json:"admission_token,omitempty"`
+	AdmissionExpiresAt *time.Time `json:"admission_expires_at,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+type Waitlist struct {
+	ID             int64      `json:"id"`
+	ProductID      int64      `json:"product_id"`
+	SessionID      int64      `json:"session_id"`
+	UserID         int64      `json:"user_id"`
+	Status         string     `json:"status"`
+	Position       int64      `json:"position"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// Webhook is a partner endpoint receiving the domain events it subscribed to. The
+// secret signs every delivery, it is only returned when the webhook is created.
+type Webhook struct {
+	ID           int64      `json:"id"`
+	URL          string     `json:"url"`
+	EventTypes   []string   `json:"event_types"`
+	Secret       string     `json:"secret,omitempty"`
+	Active       bool       `json:"active"`
+	FailureCount int64      `json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// WebhookRequest creates or updates a webhook, an empty secret is generated on
+// create and kept on update. Activating a webhook resets its failures.
+type WebhookRequest struct {
+	ID         int64    `json:"-"`
+	URL        string   `json:"url" validate:"required,url,max=255"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,required"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	Active     *bool    `json:"active"`
+}
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its last attempt
+type WebhookDelivery struct {
+	ID            int64      `json:"id"`
+	WebhookID     int64      `json:"webhook_id"`
+	EventID       int64      `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Payload       string     `json:"payload"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ResponseCode  int        `json:"response_code"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LeaseToken    string     `json:"-"`
+	LockedUntil   *time.Time `json:"-"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
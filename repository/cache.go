@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/utils/cache"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// productGenerationKey holds the generation of the cached product listings, a
+	// write moves it on so the listings cached before are never read again
+	productGenerationKey = "product:generation"
+	// productListWrittenKey marks the listings written within the write window
+	productListWrittenKey = "product:list:written"
+)
+
+type cachedProductRepository struct {
+	next  ProductRepository
+	cache cache.Cache
+	ttl   time.Duration
+	group singleflight.Group
+}
+
+// NewCachedProductRepository serves the product reads of a ctx allowing replicas
+// from cache, the other reads and every read of a unit of work go to next. The
+// products a write touches are dropped from the cache once it committed.
+func NewCachedProductRepository(next ProductRepository, c cache.Cache, ttl time.Duration) ProductRepository {
+	return &cachedProductRepository{
+		next:  next,
+		cache: c,
+		ttl:   ttl,
+	}
+}
+
+func (repo *cachedProductRepository) Create(ctx context.Context, product model.Product) error {
+	if err := repo.next.Create(ctx, product); err != nil {
+		return err
+	}
+
+	repo.invalidate(ctx)
+	return nil
+}
+
+func (repo *cachedProductRepository) Read(ctx context.Context, filter model.ProductFilter) ([]model.Product, error) {
+	if !cacheable(ctx) {
+		return repo.next.Read(ctx, filter)
+	}
+
+	key, err := repo.listKey(ctx, filter)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return repo.next.Read(ctx, filter)
+	}
+
+	var products []model.Product
+	err = repo.load(ctx, key, productListWrittenKey, &products, func(ctx context.Context) (interface{}, error) {
+		return repo.next.Read(ctx, filter)
+	})
+
+	return products, err
+}
+
+func (repo *cachedProductRepository) Update(ctx context.Context, product model.Product) error {
+	if err := repo.next.Update(ctx, product); err != nil {
+		return err
+	}
+
+	repo.invalidate(ctx, convert.Atoi(product.ID))
+	return nil
+}
+
+func (repo *cachedProductRepository) Delete(ctx context.Context, productID int64) error {
+	if err := repo.next.Delete(ctx, productID); err != nil {
+		return err
+	}
+
+	repo.invalidate(ctx, productID)
+	return nil
+}
+
+func (repo *cachedProductRepository) ReadByID(ctx context.Context, id int64) (*model.Product, error) {
+	if !cacheable(ctx) {
+		return repo.next.ReadByID(ctx, id)
+	}
+
+	var product *model.Product
+	err := repo.load(ctx, productKey(id), writtenKey(productKey(id)), &product, func(ctx context.Context) (interface{}, error) {
+		return repo.next.ReadByID(ctx, id)
+	})
+
+	return product, err
+}
+
+func (repo *cachedProductRepository) ReadByIDForUpdate(ctx context.Context, id int64) (*model.Product, error) {
+	return repo.next.ReadByIDForUpdate(ctx, id)
+}
+
+func (repo *cachedProductRepository) ReadByName(ctx context.Context, name string) (*model.Product, error) {
+	return repo.next.ReadByName(ctx, name)
+}
+
+func (repo *cachedProductRepository) Restore(ctx context.Context, productID int64) error {
+	if err := repo.next.Restore(ctx, productID); err != nil {
+		return err
+	}
+
+	repo.invalidate(ctx, productID)
+	return nil
+}
+
+func (repo *cachedProductRepository) UpdateStock(ctx context.Context, productID int64, newStock int64) error {
+	if err := repo.next.UpdateStock(ctx, productID, newStock); err != nil {
+		return err
+	}
+
+	repo.invalidate(ctx, productID)
+	return nil
+}
+
+func (repo *cachedProductRepository) TakeStock(ctx context.Context, productID int64, quantity int64) error {
+	if err := repo.next.TakeStock(ctx, productID, quantity); err != nil {
+		return err
+	}
+
+	repo.invalidate(ctx, productID)
+	return nil
+}
+
+// load decodes the cached value of key into dest, reading and caching it on a
+// miss. Concurrent misses of a key share a single read, so a popular product
+// expiring does not send every request waiting for it to the database. The
+// shared read does not stop with the request that started it, a request that
+// gives up waiting only stops waiting.
+func (repo *cachedProductRepository) load(ctx context.Context, key string, written string, dest interface{}, read func(ctx context.Context) (interface{}, error)) error {
+	value, ok, err := repo.cache.Get(ctx, key)
+	if err != nil {
+		logger.Log.Error(err.Error())
+	}
+	if ok && json.Unmarshal(value, dest) == nil {
+		return nil
+	}
+
+	shared := repo.group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.Background(), constans.CacheLoadTimeout)
+		defer cancel()
+
+		return repo.refill(loadCtx, key, written, read)
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-shared:
+		if result.Err != nil {
+			return result.Err
+		}
+
+		// every caller decodes its own copy, the services change the products they get
+		return json.Unmarshal(result.Val.([]byte), dest)
+	}
+}
+
+// refill reads the value of key and caches it. Within the write window of key
+// the value is read from the primary, a lagging replica would otherwise put the
+// value from before the write back for the whole ttl. For the same reason a
+// value read from a replica while a write happened is not cached.
+func (repo *cachedProductRepository) refill(ctx context.Context, key string, written string, read func(ctx context.Context) (interface{}, error)) ([]byte, error) {
+	replica := !repo.written(ctx, written)
+	if replica {
+		ctx = AllowReplica(ctx)
+	}
+
+	result, err := read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	// nothing found is not cached, a product created later may take its place
+	if string(value) == "null" {
+		return value, nil
+	}
+
+	if replica && repo.written(ctx, written) {
+		return value, nil
+	}
+
+	if err := repo.cache.Set(ctx, key, value, repo.ttl); err != nil {
+		logger.Log.Error(err.Error())
+	}
+
+	return value, nil
+}
+
+// written reports whether the marker written is set, a cache that cannot tell
+// is taken as written so the primary is read
+func (repo *cachedProductRepository) written(ctx context.Context, written string) bool {
+	_, ok, err := repo.cache.Get(ctx, written)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return true
+	}
+
+	return ok
+}
+
+// listKey is the key of a product listing in the current generation, the
+// currency only converts the prices after reading so it is left out
+func (repo *cachedProductRepository) listKey(ctx context.Context, filter model.ProductFilter) (string, error) {
+	generation, ok, err := repo.cache.Get(ctx, productGenerationKey)
+	if err != nil {
+		return "", err
+	}
+
+	// a generation that was evicted starts over with a new one, never with one used before
+	if !ok {
+		generation = newGeneration()
+		if err := repo.cache.Set(ctx, productGenerationKey, generation, 0); err != nil {
+			return "", err
+		}
+	}
+
+	filter.Currency = ""
+
+	raw, err := json.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+
+	return fmt.Sprintf("product:list:%s:%s", generation, hex.EncodeToString(sum[:])), nil
+}
+
+// invalidate drops the given products and every listing from the cache once
+// the unit of work in ctx committed. They are marked written first, so the
+// refills following the drop read the primary.
+func (repo *cachedProductRepository) invalidate(ctx context.Context, productIDs ...int64) {
+	AfterCommit(ctx, func() {
+		if err := repo.cache.Set(ctx, productListWrittenKey, []byte{1}, constans.CacheWriteWindow); err != nil {
+			logger.Log.Error(err.Error())
+		}
+
+		keys := make([]string, 0, len(productIDs))
+		for _, id := range productIDs {
+			if err := repo.cache.Set(ctx, writtenKey(productKey(id)), []byte{1}, constans.CacheWriteWindow); err != nil {
+				logger.Log.Error(err.Error())
+			}
+			keys = append(keys, productKey(id))
+		}
+
+		if err := repo.cache.Delete(ctx, keys...); err != nil {
+			logger.Log.Error(err.Error())
+		}
+
+		if err := repo.cache.Set(ctx, productGenerationKey, newGeneration(), 0); err != nil {
+			logger.Log.Error(err.Error())
+		}
+	})
+}
+
+// cacheable reports whether the reads of ctx may be served from cache: they
+// tolerate stale data and do not belong to a unit of work, which reads its own writes
+func cacheable(ctx context.Context) bool {
+	if ctx.Value(commitHooksKey{}) != nil {
+		return false
+	}
+
+	allowed, _ := ctx.Value(replicaKey{}).(bool)
+	return allowed
+}
+
+func productKey(id int64) string {
+	return "product:" + strconv.FormatInt(id, 10)
+}
+
+// writtenKey is the marker of key being written within the write window
+func writtenKey(key string) string {
+	return key + ":written"
+}
+
+func newGeneration() []byte {
+	return []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/utils/cache"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// laggingProducts serves a single product, the replica reads see it as it was
+// before the last update until the replica catches up
+type laggingProducts struct {
+	ProductRepository
+
+	mu      sync.Mutex
+	primary model.Product
+	replica model.Product
+	reads   int
+	// block holds the reads until it is closed
+	block chan struct{}
+}
+
+func newLaggingProducts(name string) *laggingProducts {
+	product := model.Product{ID: "1", Name: name}
+	return &laggingProducts{primary: product, replica: product}
+}
+
+func (repo *laggingProducts) ReadByID(ctx context.Context, id int64) (*model.Product, error) {
+	repo.mu.Lock()
+	repo.reads++
+	block := repo.block
+	repo.mu.Unlock()
+
+	if block != nil {
+		<-block
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	product := repo.primary
+	if allowed, _ := ctx.Value(replicaKey{}).(bool); allowed {
+		product = repo.replica
+	}
+
+	return &product, nil
+}
+
+func (repo *laggingProducts) Read(ctx context.Context, filter model.ProductFilter) ([]model.Product, error) {
+	product, err := repo.ReadByID(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return []model.Product{*product}, nil
+}
+
+func (repo *laggingProducts) Update(ctx context.Context, product model.Product) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.primary = product
+	return nil
+}
+
+func (repo *laggingProducts) readCount() int {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.reads
+}
+
+// testCaches returns the caches the product repository runs on, the Redis one
+// talking to an in process server
+func testCaches(t *testing.T) map[string]cache.Cache {
+	logger.Log = zap.NewNop()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]cache.Cache{
+		constans.CacheLRU:   cache.NewLRU(constans.DefaultCacheSize),
+		constans.CacheRedis: cache.NewRedis(client, constans.CachePrefix),
+	}
+}
+
+func TestCachedProductReadByID(t *testing.T) {
+	for name, c := range testCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			next := newLaggingProducts("concert")
+			repo := NewCachedProductRepository(next, c, time.Minute)
+			ctx := AllowReplica(context.Background())
+
+			for i := 0; i < 3; i++ {
+				product, err := repo.ReadByID(ctx, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if product.Name != "concert" {
+					t.Fatalf("got %s, want concert", product.Name)
+				}
+			}
+
+			if reads := next.readCount(); reads != 1 {
+				t.Fatalf("got %d reads, want 1", reads)
+			}
+		})
+	}
+}
+
+func TestCachedProductRefillsFromPrimaryAfterWrite(t *testing.T) {
+	for name, c := range testCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			next := newLaggingProducts("concert")
+			repo := NewCachedProductRepository(next, c, time.Minute)
+			ctx := AllowReplica(context.Background())
+
+			if _, err := repo.ReadByID(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.Read(ctx, model.ProductFilter{}); err != nil {
+				t.Fatal(err)
+			}
+
+			// the replica has not caught up with the update yet
+			if err := repo.Update(context.Background(), model.Product{ID: "1", Name: "festival"}); err != nil {
+				t.Fatal(err)
+			}
+
+			product, err := repo.ReadByID(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if product.Name != "festival" {
+				t.Fatalf("got %s, want festival", product.Name)
+			}
+
+			products, err := repo.Read(ctx, model.ProductFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(products) != 1 || products[0].Name != "festival" {
+				t.Fatalf("got %v, want festival", products)
+			}
+		})
+	}
+}
+
+func TestCachedProductSkipsReplicaReadDuringWrite(t *testing.T) {
+	for name, c := range testCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			next := newLaggingProducts("concert")
+			next.block = make(chan struct{})
+			repo := NewCachedProductRepository(next, c, time.Minute)
+			ctx := AllowReplica(context.Background())
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := repo.ReadByID(ctx, 1)
+				done <- err
+			}()
+
+			for next.readCount() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			// the update lands while the replica read is running
+			if err := repo.Update(context.Background(), model.Product{ID: "1", Name: "festival"}); err != nil {
+				t.Fatal(err)
+			}
+
+			close(next.block)
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+
+			product, err := repo.ReadByID(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if product.Name != "festival" {
+				t.Fatalf("got %s, want festival", product.Name)
+			}
+		})
+	}
+}
+
+func TestCachedProductReadOutlivesCaller(t *testing.T) {
+	for name, c := range testCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			next := newLaggingProducts("concert")
+			next.block = make(chan struct{})
+			repo := NewCachedProductRepository(next, c, time.Minute)
+
+			ctx, cancel := context.WithCancel(AllowReplica(context.Background()))
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := repo.ReadByID(ctx, 1)
+				done <- err
+			}()
+
+			for next.readCount() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			// the first caller giving up does not fail the read it started
+			cancel()
+			if err := <-done; !errors.Is(err, context.Canceled) {
+				t.Fatalf("got %v, want %v", err, context.Canceled)
+			}
+
+			waiting := make(chan *model.Product, 1)
+			go func() {
+				product, _ := repo.ReadByID(AllowReplica(context.Background()), 1)
+				waiting <- product
+			}()
+
+			close(next.block)
+
+			product := <-waiting
+			if product == nil || product.Name != "concert" {
+				t.Fatalf("got %v, want concert", product)
+			}
+
+			if reads := next.readCount(); reads > 2 {
+				t.Fatalf("got %d reads, want at most 2", reads)
+			}
+		})
+	}
+}
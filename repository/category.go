@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertCategory      = `INSERT INTO category (parent_id, name) VALUES (NULLIF(?, 0),?)`
+	updateCategory      = `UPDATE category SET parent_id=NULLIF(?, 0), name=? WHERE id=?`
+	deleteCategory      = `DELETE FROM category WHERE id=?`
+	readAllCategory     = `SELECT id, COALESCE(parent_id, 0), name, created_at, updated_at FROM category ORDER BY name`
+	readCategoryByID    = `SELECT id, COALESCE(parent_id, 0), name, created_at, updated_at FROM category WHERE id=?`
+	countCategoryUsages = `SELECT (SELECT count(1) FROM category WHERE parent_id=?) + (SELECT count(1) FROM product WHERE category_id=?)`
+)
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category model.Category) (*model.Category, error)
+	Read(context.Context) ([]model.Category, error)
+	ReadByID(ctx context.Context, categoryID int64) (*model.Category, error)
+	Update(ctx context.Context, category model.Category) error
+	Delete(ctx context.Context, categoryID int64) error
+	CountUsages(ctx context.Context, categoryID int64) (int64, error)
+}
+
+type mysqlCategoryRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	replicas *Replicas
+}
+
+func NewCategoryRepository(db *sql.DB, dialect Dialect, replicas *Replicas) CategoryRepository {
+	return &mysqlCategoryRepository{
+		db:       db,
+		dialect:  dialect,
+		replicas: replicas,
+	}
+}
+
+func (repo *mysqlCategoryRepository) Create(ctx context.Context, request model.Category) (*model.Category, error) {
+	id, err := repo.dialect.insert(ctx, conn(ctx, repo.db), insertCategory, request.ParentID, request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ID = id
+
+	return &request, nil
+}
+
+func (repo *mysqlCategoryRepository) Read(ctx context.Context) (response []model.Category, err error) {
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readAllCategory))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c model.Category
+		err = rows.Scan(
+			&c.ID,
+			&c.ParentID,
+			&c.Name,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, c)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlCategoryRepository) ReadByID(ctx context.Context, categoryID int64) (*model.Category, error) {
+	var c model.Category
+	err := repo.replicas.reader(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readCategoryByID), categoryID).Scan(
+		&c.ID,
+		&c.ParentID,
+		&c.Name,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (repo *mysqlCategoryRepository) Update(ctx context.Context, request model.Category) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateCategory))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, request.ParentID, request.Name, request.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlCategoryRepository) Delete(ctx context.Context, categoryID int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(deleteCategory))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountUsages returns the number of child categories and products referencing a category
+func (repo *mysqlCategoryRepository) CountUsages(ctx context.Context, categoryID int64) (total int64, err error) {
+	err = conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(countCategoryUsages), categoryID, categoryID).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
@@ -0,0 +1,1135 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/config"
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/migrations"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/repository/memory"
+	"github.com/cecepsprd/ticketing-api/utils/migrate"
+)
+
+// storage is one implementation of every repository, the conformance tests
+// below run the same checks on each of them
+type storage struct {
+	unitOfWork     repository.UnitOfWork
+	user           repository.UserRepository
+	product        repository.ProductRepository
+	transaction    repository.TransactionRepository
+	venue          repository.VenueRepository
+	seat           repository.SeatRepository
+	category       repository.CategoryRepository
+	session        repository.SessionRepository
+	waitlist       repository.WaitlistRepository
+	voucher        repository.VoucherRepository
+	exchangeRate   repository.ExchangeRateRepository
+	idempotency    repository.IdempotencyRepository
+	outbox         repository.OutboxRepository
+	webhook        repository.WebhookRepository
+	job            repository.JobRepository
+	reconciliation repository.ReconciliationRepository
+	waitingRoom    repository.WaitingRoomRepository
+}
+
+// storages open an empty storage of each implementation
+var storages = map[string]func(t *testing.T) storage{
+	"memory":                memoryStorage,
+	constans.DriverSQLite:   sqliteStorage,
+	constans.DriverPostgres: postgresStorage,
+}
+
+func memoryStorage(t *testing.T) storage {
+	store := memory.NewStore()
+
+	return storage{
+		unitOfWork:     memory.NewUnitOfWork(store),
+		user:           memory.NewUserRepository(store),
+		product:        memory.NewProductRepository(store),
+		transaction:    memory.NewTransactionRepository(store),
+		venue:          memory.NewVenueRepository(store),
+		seat:           memory.NewSeatRepository(store),
+		category:       memory.NewCategoryRepository(store),
+		session:        memory.NewSessionRepository(store),
+		waitlist:       memory.NewWaitlistRepository(store),
+		voucher:        memory.NewVoucherRepository(store),
+		exchangeRate:   memory.NewExchangeRateRepository(store),
+		idempotency:    memory.NewIdempotencyRepository(store),
+		outbox:         memory.NewOutboxRepository(store),
+		webhook:        memory.NewWebhookRepository(store),
+		job:            memory.NewJobRepository(store),
+		reconciliation: memory.NewReconciliationRepository(store),
+		waitingRoom:    memory.NewWaitingRoomRepository(store),
+	}
+}
+
+// sqliteStorage migrates a database file of its own
+func sqliteStorage(t *testing.T) storage {
+	cfg := config.Config{MysqlDB: config.MysqlDB{
+		Driver: constans.DriverSQLite,
+		Name:   filepath.Join(t.TempDir(), "ticketing.db"),
+	}}
+
+	db, err := cfg.SqliteConnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return sqlStorage(t, db, constans.DriverSQLite)
+}
+
+// postgresStorage migrates a schema of its own in the database of
+// TEST_POSTGRES_DSN, a key=value connection string. PostgreSQL is skipped
+// when it is not set.
+func postgresStorage(t *testing.T) storage {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	admin, err := sql.Open(constans.DriverPostgres, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("conformance_%d", time.Now().UnixNano())
+	if _, err = admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	// every connection of the pool works in the schema
+	db, err := sql.Open(constans.DriverPostgres, dsn+" search_path="+schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return sqlStorage(t, db, constans.DriverPostgres)
+}
+
+// sqlStorage migrates an empty database of driver and opens the SQL repositories on it
+func sqlStorage(t *testing.T, db *sql.DB, driver string) storage {
+	files, err := migrations.For(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := migrate.New(db, driver, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dialect := repository.NewDialect(driver)
+
+	return storage{
+		unitOfWork:     repository.NewUnitOfWork(db),
+		user:           repository.NewUserRepository(db, dialect),
+		product:        repository.NewProductRepository(db, dialect, nil),
+		transaction:    repository.NewTransactionRepository(db, dialect),
+		venue:          repository.NewVenueRepository(db, dialect, nil),
+		seat:           repository.NewSeatRepository(db, dialect, nil),
+		category:       repository.NewCategoryRepository(db, dialect, nil),
+		session:        repository.NewSessionRepository(db, dialect, nil),
+		waitlist:       repository.NewWaitlistRepository(db, dialect),
+		voucher:        repository.NewVoucherRepository(db, dialect, nil),
+		exchangeRate:   repository.NewExchangeRateRepository(db, dialect),
+		idempotency:    repository.NewIdempotencyRepository(db, dialect),
+		outbox:         repository.NewOutboxRepository(db, dialect),
+		webhook:        repository.NewWebhookRepository(db, dialect),
+		job:            repository.NewJobRepository(db, dialect),
+		reconciliation: repository.NewReconciliationRepository(db, dialect, nil),
+		waitingRoom:    repository.NewWaitingRoomRepository(db, dialect),
+	}
+}
+
+// conform runs test on an empty storage of every implementation
+func conform(t *testing.T, test func(t *testing.T, ctx context.Context, s storage)) {
+	for name, open := range storages {
+		open := open
+		t.Run(name, func(t *testing.T) {
+			test(t, context.Background(), open(t))
+		})
+	}
+}
+
+// must fails the test on an error
+func must(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wantErr fails the test unless err is want
+func wantErr(t *testing.T, err error, want error) {
+	t.Helper()
+
+	if !errors.Is(err, want) {
+		t.Fatalf("got error %v, want %v", err, want)
+	}
+}
+
+// now is a time every implementation stores without losing precision
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func TestUserConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		created, err := s.user.Create(ctx, model.User{Username: "buyer", Password: "hash", Email: "buyer@mail.com", Phone: "0812", Roles: constans.RoleUser})
+		must(t, err)
+
+		user, err := s.user.ReadByUsername(ctx, "buyer")
+		must(t, err)
+		if user.ID != created.ID || user.Email != "buyer@mail.com" || user.Roles != constans.RoleUser {
+			t.Fatalf("got %+v, want %+v", user, created)
+		}
+
+		total, err := s.user.CountUser(ctx, model.User{Username: "other", Email: "other@mail.com", Phone: "0812"})
+		must(t, err)
+		if total != 1 {
+			t.Fatalf("got %d users with the phone, want 1", total)
+		}
+
+		user.Email = "changed@mail.com"
+		must(t, s.user.Update(ctx, user))
+
+		user, err = s.user.ReadByID(ctx, created.ID)
+		must(t, err)
+		if user.Email != "changed@mail.com" {
+			t.Fatalf("got email %s, want changed@mail.com", user.Email)
+		}
+
+		must(t, s.user.Delete(ctx, created.ID))
+
+		// a missing user is an empty one
+		user, err = s.user.ReadByID(ctx, created.ID)
+		must(t, err)
+		if user.ID != 0 {
+			t.Fatalf("got %+v, want no user", user)
+		}
+	})
+}
+
+func TestProductConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		must(t, s.product.Create(ctx, model.Product{
+			Name:       "concert",
+			Price:      model.Money{Amount: 100000, Currency: constans.DefaultCurrency},
+			Stock:      10,
+			CategoryID: 1,
+			Tags:       []string{"rock", "live"},
+		}))
+
+		product, err := s.product.ReadByName(ctx, "concert")
+		must(t, err)
+		if product == nil {
+			t.Fatal("got no product, want concert")
+		}
+		if product.Price.Amount != 100000 || product.Stock != 10 || len(product.Tags) != 2 || product.Tags[0] != "live" || product.Tags[1] != "rock" {
+			t.Fatalf("got %+v, want the created product with sorted tags", product)
+		}
+
+		products, err := s.product.Read(ctx, model.ProductFilter{Tag: "rock", CategoryID: 1})
+		must(t, err)
+		if len(products) != 1 {
+			t.Fatalf("got %d products tagged rock, want 1", len(products))
+		}
+
+		products, err = s.product.Read(ctx, model.ProductFilter{Tag: "jazz"})
+		must(t, err)
+		if len(products) != 0 {
+			t.Fatalf("got %d products tagged jazz, want none", len(products))
+		}
+
+		missing, err := s.product.ReadByID(ctx, 999)
+		must(t, err)
+		if missing != nil {
+			t.Fatalf("got %+v, want no product", missing)
+		}
+
+		var id int64 = 1
+		wantErr(t, s.product.TakeStock(ctx, id, 11), constans.ErrTicketRunOut)
+		must(t, s.product.TakeStock(ctx, id, 4))
+		must(t, s.product.UpdateStock(ctx, id, 2))
+
+		product, err = s.product.ReadByID(ctx, id)
+		must(t, err)
+		if product.Stock != 8 {
+			t.Fatalf("got stock %d, want 8", product.Stock)
+		}
+
+		// the details are updated without the stock, the taken tickets stay taken
+		must(t, s.product.Update(ctx, model.Product{
+			ID:    product.ID,
+			Name:  "live concert",
+			Price: product.Price,
+			Stock: 10,
+			Tags:  product.Tags,
+		}))
+
+		product, err = s.product.ReadByID(ctx, id)
+		must(t, err)
+		if product.Name != "live concert" || product.Stock != 8 {
+			t.Fatalf("got %+v, want the new name with stock 8", product)
+		}
+
+		must(t, s.product.Delete(ctx, id))
+		wantErr(t, s.product.Delete(ctx, id), constans.ErrNotFound)
+
+		// a deleted product still resolves by id, but is not listed
+		product, err = s.product.ReadByID(ctx, id)
+		must(t, err)
+		if product == nil || product.DeletedAt == nil {
+			t.Fatalf("got %+v, want the deleted product", product)
+		}
+
+		products, err = s.product.Read(ctx, model.ProductFilter{})
+		must(t, err)
+		if len(products) != 0 {
+			t.Fatalf("got %d products, want the deleted one left out", len(products))
+		}
+
+		products, err = s.product.Read(ctx, model.ProductFilter{WithDeleted: true})
+		must(t, err)
+		if len(products) != 1 {
+			t.Fatalf("got %d products, want the deleted one", len(products))
+		}
+
+		must(t, s.product.Restore(ctx, id))
+		wantErr(t, s.product.Restore(ctx, id), constans.ErrNotFound)
+	})
+}
+
+func TestTransactionConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		past, future := now().Add(-2*time.Hour), now().Add(2*time.Hour)
+		must(t, s.session.Create(ctx, []model.Session{
+			{ProductID: 1, StartDate: past.Add(-time.Hour), EndDate: past, Capacity: 5, Stock: 5},
+			{ProductID: 1, StartDate: future, EndDate: future.Add(time.Hour), Capacity: 5, Stock: 5},
+		}))
+
+		sessions, err := s.session.ReadByProduct(ctx, 1)
+		must(t, err)
+
+		var ids []int64
+		for _, session := range sessions {
+			created, err := s.transaction.Create(ctx, model.Transaction{
+				ProductID: 1,
+				SessionID: session.ID,
+				UserID:    1,
+				Amount:    model.Money{Amount: 100000, Currency: constans.DefaultCurrency},
+				Breakdown: model.PriceBreakdown{Currency: constans.DefaultCurrency, UnitPrice: 100000, Quantity: 1, Subtotal: 100000, Total: 100000},
+				Status:    constans.PENDING,
+			})
+			must(t, err)
+			ids = append(ids, created.ID)
+		}
+
+		transaction, err := s.transaction.ReadByID(ctx, ids[0])
+		must(t, err)
+		if transaction.Amount.Amount != 100000 || transaction.Breakdown.Total != 100000 || transaction.Status != constans.PENDING {
+			t.Fatalf("got %+v, want the created transaction", transaction)
+		}
+
+		_, err = s.transaction.ReadByID(ctx, 999)
+		wantErr(t, err, constans.ErrNotFound)
+
+		must(t, s.transaction.UpdateStatus(ctx, ids[0], constans.PAID))
+		must(t, s.transaction.UpdateStatus(ctx, ids[1], constans.PAID))
+
+		count, err := s.transaction.CountByProduct(ctx, 1, constans.PAID)
+		must(t, err)
+		if count != 2 {
+			t.Fatalf("got %d paid transactions, want 2", count)
+		}
+
+		count, err = s.transaction.CountUpcomingByProduct(ctx, 1, constans.PAID, now())
+		must(t, err)
+		if count != 1 {
+			t.Fatalf("got %d upcoming transactions, want the one of the future session", count)
+		}
+
+		must(t, s.transaction.UpdateStatus(ctx, ids[0], constans.PENDING))
+		must(t, s.transaction.UpdateStatus(ctx, ids[1], constans.PENDING))
+
+		// the first transaction waits for a review of its mismatch
+		_, err = s.reconciliation.Create(ctx, model.Reconciliation{
+			TransactionID:  ids[0],
+			PreviousStatus: constans.PENDING,
+			Status:         constans.PENDING,
+			Currency:       constans.DefaultCurrency,
+			Result:         constans.MISMATCH,
+		})
+		must(t, err)
+
+		pending, err := s.transaction.ReadUnreconciled(ctx, now().Add(time.Minute), 10)
+		must(t, err)
+		if len(pending) != 1 || pending[0].ID != ids[1] {
+			t.Fatalf("got %+v, want transaction %d", pending, ids[1])
+		}
+	})
+}
+
+func TestCategoryConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		parent, err := s.category.Create(ctx, model.Category{Name: "music"})
+		must(t, err)
+
+		child, err := s.category.Create(ctx, model.Category{ParentID: parent.ID, Name: "jazz"})
+		must(t, err)
+
+		categories, err := s.category.Read(ctx)
+		must(t, err)
+		if len(categories) != 2 || categories[0].ID != child.ID || categories[0].ParentID != parent.ID {
+			t.Fatalf("got %+v, want jazz before music", categories)
+		}
+
+		usages, err := s.category.CountUsages(ctx, parent.ID)
+		must(t, err)
+		if usages != 1 {
+			t.Fatalf("got %d usages, want the child", usages)
+		}
+
+		child.ParentID = 0
+		must(t, s.category.Update(ctx, *child))
+		must(t, s.category.Delete(ctx, parent.ID))
+
+		missing, err := s.category.ReadByID(ctx, parent.ID)
+		must(t, err)
+		if missing != nil {
+			t.Fatalf("got %+v, want no category", missing)
+		}
+
+		category, err := s.category.ReadByID(ctx, child.ID)
+		must(t, err)
+		if category == nil || category.ParentID != 0 {
+			t.Fatalf("got %+v, want jazz without a parent", category)
+		}
+	})
+}
+
+func TestSessionConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		start := now().Add(24 * time.Hour)
+		must(t, s.session.Create(ctx, []model.Session{
+			{ProductID: 1, StartDate: start.Add(24 * time.Hour), EndDate: start.Add(26 * time.Hour), Capacity: 5, Stock: 5},
+			{ProductID: 1, StartDate: start, EndDate: start.Add(2 * time.Hour), Capacity: 5, Stock: 5},
+		}))
+
+		sessions, err := s.session.ReadByProduct(ctx, 1)
+		must(t, err)
+		if len(sessions) != 2 || !sessions[0].StartDate.Equal(start) {
+			t.Fatalf("got %+v, want the sessions by start date", sessions)
+		}
+
+		count, err := s.session.Count(ctx, 1)
+		must(t, err)
+		if count != 2 {
+			t.Fatalf("got %d sessions, want 2", count)
+		}
+
+		first, second := sessions[0], sessions[1]
+		wantErr(t, s.session.TakeStock(ctx, first.ID, 6), constans.ErrTicketRunOut)
+		must(t, s.session.TakeStock(ctx, first.ID, 4))
+
+		// the stock never goes below zero when the capacity shrinks under the sold tickets
+		first.Capacity = 2
+		must(t, s.session.Update(ctx, first))
+
+		session, err := s.session.ReadByID(ctx, first.ID)
+		must(t, err)
+		if session.Capacity != 2 || session.Stock != 0 {
+			t.Fatalf("got capacity %d and stock %d, want 2 and 0", session.Capacity, session.Stock)
+		}
+
+		must(t, s.session.UpdateFutureCapacity(ctx, 1, 8, start))
+
+		session, err = s.session.ReadByID(ctx, second.ID)
+		must(t, err)
+		if session.Capacity != 8 || session.Stock != 8 {
+			t.Fatalf("got capacity %d and stock %d, want 8 and 8", session.Capacity, session.Stock)
+		}
+
+		session, err = s.session.ReadByID(ctx, first.ID)
+		must(t, err)
+		if session.Capacity != 2 {
+			t.Fatalf("got capacity %d, want the session starting at the time left alone", session.Capacity)
+		}
+
+		must(t, s.session.Delete(ctx, second.ID))
+
+		missing, err := s.session.ReadByID(ctx, second.ID)
+		must(t, err)
+		if missing != nil {
+			t.Fatalf("got %+v, want no session", missing)
+		}
+	})
+}
+
+func TestSeatConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		venue, err := s.venue.Create(ctx, model.Venue{Name: "hall", Address: "street"})
+		must(t, err)
+
+		must(t, s.venue.CreateSeats(ctx, []model.Seat{
+			{VenueID: venue.ID, Section: "A", Row: "1", Number: 2},
+			{VenueID: venue.ID, Section: "A", Row: "1", Number: 1},
+			{VenueID: venue.ID, Section: "A", Row: "2", Number: 1},
+		}))
+
+		if err = s.venue.CreateSeats(ctx, []model.Seat{{VenueID: venue.ID, Section: "A", Row: "1", Number: 1}}); err == nil {
+			t.Fatal("got no error, want the taken seat refused")
+		}
+
+		seats, err := s.venue.ReadSeats(ctx, venue.ID)
+		must(t, err)
+		if len(seats) != 3 || seats[0].Number != 1 || seats[1].Number != 2 || seats[2].Row != "2" {
+			t.Fatalf("got %+v, want the seats by row and number", seats)
+		}
+
+		created, err := s.seat.CreateSeatMap(ctx, 1, venue.ID)
+		must(t, err)
+		if created != 3 {
+			t.Fatalf("got %d seats mapped, want 3", created)
+		}
+
+		if _, err = s.seat.CreateSeatMap(ctx, 1, venue.ID); err == nil {
+			t.Fatal("got no error, want the mapped seats refused")
+		}
+
+		must(t, s.seat.HoldSeats(ctx, 1, 10, []int64{seats[0].ID, seats[1].ID}))
+		wantErr(t, s.seat.HoldSeats(ctx, 1, 11, []int64{seats[2].ID, seats[1].ID}), constans.ErrSeatNotAvailable)
+
+		// a hold that fails takes none of the seats
+		seatMap, err := s.seat.ReadSeatMap(ctx, 1)
+		must(t, err)
+		for _, seat := range seatMap {
+			if seat.SeatID == seats[2].ID && seat.Status != constans.AVAILABLE {
+				t.Fatalf("got %s, want seat %d available", seat.Status, seat.SeatID)
+			}
+		}
+
+		sold, err := s.seat.SellSeats(ctx, 10)
+		must(t, err)
+		if sold != 2 {
+			t.Fatalf("got %d seats sold, want 2", sold)
+		}
+
+		released, err := s.seat.ReleaseSeats(ctx, 10)
+		must(t, err)
+		if released != 2 {
+			t.Fatalf("got %d seats released, want 2", released)
+		}
+
+		seatMap, err = s.seat.ReadSeatMap(ctx, 1)
+		must(t, err)
+		for _, seat := range seatMap {
+			if seat.Status != constans.AVAILABLE || seat.TransactionID != 0 {
+				t.Fatalf("got %+v, want every seat available", seat)
+			}
+		}
+	})
+}
+
+func TestWaitlistConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		first, err := s.waitlist.Create(ctx, model.Waitlist{ProductID: 1, SessionID: 2, UserID: 1, Status: constans.WAITING})
+		must(t, err)
+
+		second, err := s.waitlist.Create(ctx, model.Waitlist{ProductID: 1, SessionID: 2, UserID: 2, Status: constans.WAITING})
+		must(t, err)
+
+		position, err := s.waitlist.Position(ctx, *second)
+		must(t, err)
+		if position != 2 {
+			t.Fatalf("got position %d, want 2", position)
+		}
+
+		next, err := s.waitlist.ReadNext(ctx, 1, 2, 1)
+		must(t, err)
+		if len(next) != 1 || next[0].ID != first.ID {
+			t.Fatalf("got %+v, want the first in line", next)
+		}
+
+		expiresAt := now().Add(time.Hour)
+		offered, err := s.waitlist.Offer(ctx, first.ID, expiresAt)
+		must(t, err)
+		if !offered {
+			t.Fatal("got the offer refused, want it made")
+		}
+
+		offered, err = s.waitlist.Offer(ctx, first.ID, expiresAt)
+		must(t, err)
+		if offered {
+			t.Fatal("got a second offer, want only one")
+		}
+
+		active, err := s.waitlist.ReadActive(ctx, 1, 2, 1)
+		must(t, err)
+		if active == nil || active.Status != constans.OFFERED || active.OfferExpiresAt == nil || !active.OfferExpiresAt.Equal(expiresAt) {
+			t.Fatalf("got %+v, want the offer", active)
+		}
+
+		count, err := s.waitlist.CountActiveOffers(ctx, 1, 2, 2, now())
+		must(t, err)
+		if count != 1 {
+			t.Fatalf("got %d active offers, want 1", count)
+		}
+
+		expired, err := s.waitlist.ReadExpiredOffers(ctx, expiresAt.Add(time.Minute))
+		must(t, err)
+		if len(expired) != 1 || expired[0].ID != first.ID {
+			t.Fatalf("got %+v, want the offer expired", expired)
+		}
+
+		updated, err := s.waitlist.UpdateStatus(ctx, first.ID, constans.OFFERED, constans.PURCHASED)
+		must(t, err)
+		if !updated {
+			t.Fatal("got the offer left alone, want it purchased")
+		}
+
+		updated, err = s.waitlist.UpdateStatus(ctx, first.ID, constans.OFFERED, constans.EXPIRED)
+		must(t, err)
+		if updated {
+			t.Fatal("got the purchased offer expired, want it left alone")
+		}
+	})
+}
+
+func TestVoucherConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		voucher, err := s.voucher.Create(ctx, model.Voucher{Code: "TEN", Type: constans.PERCENTAGE, Value: 1000, MaxRedemptions: 1})
+		must(t, err)
+
+		if _, err = s.voucher.Create(ctx, model.Voucher{Code: "TEN", Type: constans.FIXED, Value: 500}); err == nil {
+			t.Fatal("got no error, want the taken code refused")
+		}
+
+		found, err := s.voucher.ReadByCode(ctx, "TEN")
+		must(t, err)
+		if found == nil || found.ID != voucher.ID || found.Value != 1000 {
+			t.Fatalf("got %+v, want %+v", found, voucher)
+		}
+
+		wantErr(t, s.voucher.Redeem(ctx, model.VoucherRedemption{VoucherID: 999, UserID: 1, TransactionID: 1}), constans.ErrVoucherInvalid)
+
+		must(t, s.voucher.Redeem(ctx, model.VoucherRedemption{VoucherID: voucher.ID, UserID: 1, TransactionID: 1, Discount: 10000}))
+		wantErr(t, s.voucher.Redeem(ctx, model.VoucherRedemption{VoucherID: voucher.ID, UserID: 2, TransactionID: 2}), constans.ErrVoucherExhausted)
+		wantErr(t, s.voucher.Delete(ctx, voucher.ID), constans.ErrConflict)
+
+		// a released redemption frees the voucher again
+		must(t, s.voucher.UpdateRedemption(ctx, 1, constans.RELEASED))
+		must(t, s.voucher.Redeem(ctx, model.VoucherRedemption{VoucherID: voucher.ID, UserID: 2, TransactionID: 2, Discount: 10000}))
+
+		redemptions, err := s.voucher.ReadRedemptions(ctx, voucher.ID)
+		must(t, err)
+		if len(redemptions) != 2 || redemptions[0].Status != constans.RELEASED || redemptions[1].Status != constans.RESERVED {
+			t.Fatalf("got %+v, want the released and the reserved redemption", redemptions)
+		}
+
+		found, err = s.voucher.ReadByID(ctx, voucher.ID)
+		must(t, err)
+		if found.Redeemed != 1 {
+			t.Fatalf("got %d redeemed, want 1", found.Redeemed)
+		}
+	})
+}
+
+func TestExchangeRateConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		must(t, s.exchangeRate.Upsert(ctx, model.ExchangeRate{Currency: "USD", Rate: "0.000064", Source: "manual"}))
+		must(t, s.exchangeRate.Upsert(ctx, model.ExchangeRate{Currency: "USD", Rate: "0.000065", Source: "provider"}))
+
+		rate, err := s.exchangeRate.ReadByCurrency(ctx, "USD")
+		must(t, err)
+
+		got, ok := new(big.Rat).SetString(rate.Rate)
+		if !ok || got.Cmp(big.NewRat(65, 1000000)) != 0 || rate.Source != "provider" {
+			t.Fatalf("got %+v, want the second rate", rate)
+		}
+
+		rates, err := s.exchangeRate.Read(ctx)
+		must(t, err)
+		if len(rates) != 1 {
+			t.Fatalf("got %d rates, want 1", len(rates))
+		}
+
+		must(t, s.exchangeRate.Delete(ctx, "USD"))
+		wantErr(t, s.exchangeRate.Delete(ctx, "USD"), constans.ErrNotFound)
+
+		missing, err := s.exchangeRate.ReadByCurrency(ctx, "USD")
+		must(t, err)
+		if missing != nil {
+			t.Fatalf("got %+v, want no rate", missing)
+		}
+	})
+}
+
+func TestIdempotencyConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		lockedUntil := now().Add(time.Minute)
+		key := model.IdempotencyKey{Scope: "checkout", Key: "k1", RequestHash: "hash", LockedUntil: &lockedUntil}
+
+		created, err := s.idempotency.Create(ctx, key, now().Add(-time.Hour))
+		must(t, err)
+		if !created {
+			t.Fatal("got the key taken, want it reserved")
+		}
+
+		created, err = s.idempotency.Create(ctx, key, now().Add(-time.Hour))
+		must(t, err)
+		if created {
+			t.Fatal("got the key reserved twice, want it taken")
+		}
+
+		relocked := lockedUntil.Add(time.Minute)
+		key.LockedUntil = &relocked
+
+		taken, err := s.idempotency.TakeOver(ctx, key, now())
+		must(t, err)
+		if taken {
+			t.Fatal("got the key taken over while locked, want it refused")
+		}
+
+		taken, err = s.idempotency.TakeOver(ctx, key, lockedUntil.Add(time.Second))
+		must(t, err)
+		if !taken {
+			t.Fatal("got the expired lock kept, want it taken over")
+		}
+
+		key.StatusCode, key.Response = 201, []byte(`{"id":1}`)
+		must(t, s.idempotency.Complete(ctx, key))
+
+		key.StatusCode, key.Response = 500, []byte(`{}`)
+		must(t, s.idempotency.Complete(ctx, key))
+
+		// a completed key is not freed
+		must(t, s.idempotency.Delete(ctx, key.Scope, key.Key))
+
+		stored, err := s.idempotency.Read(ctx, key.Scope, key.Key)
+		must(t, err)
+		if stored == nil || !stored.Completed || stored.StatusCode != 201 || string(stored.Response) != `{"id":1}` || stored.LockedUntil != nil {
+			t.Fatalf("got %+v, want the first response", stored)
+		}
+
+		deleted, err := s.idempotency.DeleteExpired(ctx, now().Add(time.Hour))
+		must(t, err)
+		if deleted != 1 {
+			t.Fatalf("got %d keys deleted, want 1", deleted)
+		}
+
+		missing, err := s.idempotency.Read(ctx, key.Scope, key.Key)
+		must(t, err)
+		if missing != nil {
+			t.Fatalf("got %+v, want no key", missing)
+		}
+	})
+}
+
+func TestOutboxConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		start := now()
+		for i, at := range []time.Time{start.Add(-time.Minute), start, start.Add(time.Hour)} {
+			must(t, s.outbox.Create(ctx, model.Event{
+				Type:          constans.TransactionCreated,
+				AggregateID:   int64(i + 1),
+				Payload:       json.RawMessage(`{"id":1}`),
+				NextAttemptAt: at,
+			}))
+		}
+
+		lockedUntil := start.Add(time.Minute)
+		claimed, err := s.outbox.Claim(ctx, "first", start, lockedUntil, 10)
+		must(t, err)
+		if len(claimed) != 2 || claimed[0].AggregateID != 1 || claimed[1].AggregateID != 2 || claimed[0].Status != constans.DISPATCHING {
+			t.Fatalf("got %+v, want the two due events", claimed)
+		}
+
+		again, err := s.outbox.Claim(ctx, "second", start, lockedUntil, 10)
+		must(t, err)
+		if len(again) != 0 {
+			t.Fatalf("got %+v, want the claimed events hidden", again)
+		}
+
+		// the first lease expired, the events are claimed again
+		reclaimed, err := s.outbox.Claim(ctx, "third", lockedUntil.Add(time.Second), lockedUntil.Add(time.Minute), 1)
+		must(t, err)
+		if len(reclaimed) != 1 || reclaimed[0].ID != claimed[0].ID {
+			t.Fatalf("got %+v, want the oldest expired event", reclaimed)
+		}
+
+		// the lost lease is ignored
+		must(t, s.outbox.MarkDispatched(ctx, claimed[0], start))
+		must(t, s.outbox.MarkDispatched(ctx, reclaimed[0], start))
+
+		failed := claimed[1]
+		failed.Status, failed.Attempts, failed.LastError, failed.NextAttemptAt = constans.PENDING, 1, "unreachable", start.Add(time.Hour)
+		must(t, s.outbox.MarkFailed(ctx, failed))
+
+		events, err := s.outbox.ReadRecent(ctx, start.Add(-time.Hour), []string{constans.TransactionCreated}, 10)
+		must(t, err)
+		if len(events) != 3 {
+			t.Fatalf("got %d events, want 3", len(events))
+		}
+
+		if events[0].Status != constans.DISPATCHED || events[0].Attempts != 1 || events[0].DispatchedAt == nil {
+			t.Fatalf("got %+v, want the event dispatched once", events[0])
+		}
+		if events[1].Status != constans.PENDING || events[1].LastError != "unreachable" || events[1].LeaseToken != "" {
+			t.Fatalf("got %+v, want the event pending again", events[1])
+		}
+
+		newest, err := s.outbox.ReadRecent(ctx, start.Add(-time.Hour), []string{constans.TransactionCreated, constans.TransactionPaid}, 1)
+		must(t, err)
+		if len(newest) != 1 || newest[0].AggregateID != 3 {
+			t.Fatalf("got %+v, want the newest event", newest)
+		}
+
+		none, err := s.outbox.ReadRecent(ctx, start.Add(-time.Hour), []string{constans.TransactionPaid}, 10)
+		must(t, err)
+		if len(none) != 0 {
+			t.Fatalf("got %+v, want no paid events", none)
+		}
+	})
+}
+
+func TestWebhookConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		created, err := s.webhook.Create(ctx, model.Webhook{URL: "https://example.com/created", EventTypes: []string{constans.TransactionCreated, constans.TransactionPaid}, Secret: "secret"})
+		must(t, err)
+
+		paid, err := s.webhook.Create(ctx, model.Webhook{URL: "https://example.com/paid", EventTypes: []string{constans.TransactionPaid}, Secret: "secret"})
+		must(t, err)
+
+		subscribed, err := s.webhook.ReadSubscribed(ctx, constans.TransactionPaid)
+		must(t, err)
+		if len(subscribed) != 2 {
+			t.Fatalf("got %d webhooks, want 2", len(subscribed))
+		}
+
+		subscribed, err = s.webhook.ReadSubscribed(ctx, "Transaction")
+		must(t, err)
+		if len(subscribed) != 0 {
+			t.Fatalf("got %+v, want only whole event types matched", subscribed)
+		}
+
+		must(t, s.webhook.Disable(ctx, paid.ID, now()))
+
+		subscribed, err = s.webhook.ReadSubscribed(ctx, constans.TransactionPaid)
+		must(t, err)
+		if len(subscribed) != 1 || subscribed[0].ID != created.ID {
+			t.Fatalf("got %+v, want the active webhook", subscribed)
+		}
+
+		failures, err := s.webhook.RecordFailure(ctx, created.ID)
+		must(t, err)
+		failures, err = s.webhook.RecordFailure(ctx, created.ID)
+		must(t, err)
+		if failures != 2 {
+			t.Fatalf("got %d failures, want 2", failures)
+		}
+
+		must(t, s.webhook.ResetFailures(ctx, created.ID))
+
+		webhook, err := s.webhook.ReadByID(ctx, created.ID)
+		must(t, err)
+		if webhook.FailureCount != 0 || !webhook.Active || len(webhook.EventTypes) != 2 {
+			t.Fatalf("got %+v, want the failures reset", webhook)
+		}
+
+		start := now()
+		for _, id := range []int64{created.ID, created.ID, paid.ID} {
+			must(t, s.webhook.CreateDelivery(ctx, model.WebhookDelivery{
+				WebhookID:     id,
+				EventID:       1,
+				EventType:     constans.TransactionPaid,
+				Payload:       `{"id":1}`,
+				Status:        constans.PENDING,
+				NextAttemptAt: start,
+			}))
+		}
+
+		deliveries, err := s.webhook.ReadDeliveries(ctx, created.ID, 10)
+		must(t, err)
+		if len(deliveries) != 1 {
+			t.Fatalf("got %d deliveries, want the event delivered once", len(deliveries))
+		}
+
+		// the delivery of the disabled webhook waits
+		lockedUntil := start.Add(time.Minute)
+		claimed, err := s.webhook.ClaimDeliveries(ctx, "first", start, lockedUntil, 10)
+		must(t, err)
+		if len(claimed) != 1 || claimed[0].WebhookID != created.ID || claimed[0].Status != constans.SENDING {
+			t.Fatalf("got %+v, want the delivery of the active webhook", claimed)
+		}
+
+		again, err := s.webhook.ClaimDeliveries(ctx, "second", start, lockedUntil, 10)
+		must(t, err)
+		if len(again) != 0 {
+			t.Fatalf("got %+v, want the claimed delivery hidden", again)
+		}
+
+		reclaimed, err := s.webhook.ClaimDeliveries(ctx, "third", lockedUntil.Add(time.Second), lockedUntil.Add(time.Minute), 10)
+		must(t, err)
+		if len(reclaimed) != 1 {
+			t.Fatalf("got %+v, want the expired lease claimed again", reclaimed)
+		}
+
+		// the outcome of the lost lease is ignored
+		lost := claimed[0]
+		lost.Status, lost.Attempts, lost.LastError = constans.FAILED, 1, "lost"
+		must(t, s.webhook.UpdateDelivery(ctx, lost))
+
+		delivered := reclaimed[0]
+		delivered.Status, delivered.Attempts, delivered.ResponseCode, delivered.DeliveredAt = constans.DELIVERED, 1, 200, &start
+		must(t, s.webhook.UpdateDelivery(ctx, delivered))
+
+		delivery, err := s.webhook.ReadDelivery(ctx, delivered.ID)
+		must(t, err)
+		if delivery.Status != constans.DELIVERED || delivery.ResponseCode != 200 || delivery.LeaseToken != "" || delivery.DeliveredAt == nil {
+			t.Fatalf("got %+v, want the delivery delivered", delivery)
+		}
+
+		must(t, s.webhook.Delete(ctx, created.ID))
+
+		missing, err := s.webhook.ReadByID(ctx, created.ID)
+		must(t, err)
+		if missing != nil {
+			t.Fatalf("got %+v, want no webhook", missing)
+		}
+
+		deliveries, err = s.webhook.ReadDeliveries(ctx, created.ID, 10)
+		must(t, err)
+		if len(deliveries) != 0 {
+			t.Fatalf("got %+v, want the deliveries deleted with the webhook", deliveries)
+		}
+	})
+}
+
+func TestJobConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		start := now()
+		for _, job := range []model.Job{
+			{Type: "expire", UniqueKey: "expire:1", RunAt: start},
+			{Type: "expire", UniqueKey: "expire:1", RunAt: start},
+			{Type: "mail", RunAt: start.Add(-time.Minute)},
+			{Type: "mail", RunAt: start.Add(time.Hour)},
+		} {
+			job.Payload, job.MaxAttempts = json.RawMessage(`{}`), 3
+			must(t, s.job.Create(ctx, job))
+		}
+
+		pending, err := s.job.ReadByStatus(ctx, constans.PENDING, 10)
+		must(t, err)
+		if len(pending) != 3 {
+			t.Fatalf("got %d jobs, want the unique key queued once", len(pending))
+		}
+
+		lockedUntil := start.Add(time.Minute)
+		first, err := s.job.Lease(ctx, "first", start, lockedUntil)
+		must(t, err)
+		if first == nil || first.Type != "mail" || first.Status != constans.RUNNING || first.Attempts != 1 {
+			t.Fatalf("got %+v, want the earliest due job", first)
+		}
+
+		second, err := s.job.Lease(ctx, "second", start, lockedUntil)
+		must(t, err)
+		if second == nil || second.UniqueKey != "expire:1" {
+			t.Fatalf("got %+v, want the next due job", second)
+		}
+
+		none, err := s.job.Lease(ctx, "third", start, lockedUntil)
+		must(t, err)
+		if none != nil {
+			t.Fatalf("got %+v, want no due job", none)
+		}
+
+		// the first lease expired, the job is run again and the late completion is ignored
+		again, err := s.job.Lease(ctx, "fourth", lockedUntil.Add(time.Second), lockedUntil.Add(time.Minute))
+		must(t, err)
+		if again == nil || again.ID != first.ID || again.Attempts != 2 {
+			t.Fatalf("got %+v, want job %d leased again", again, first.ID)
+		}
+
+		must(t, s.job.Complete(ctx, *first, start))
+
+		job, err := s.job.ReadByID(ctx, first.ID)
+		must(t, err)
+		if job.Status != constans.RUNNING || job.LeaseToken != "fourth" {
+			t.Fatalf("got %+v, want the job still running under the new lease", job)
+		}
+
+		must(t, s.job.Complete(ctx, *again, start))
+
+		second.Status, second.LastError, second.RunAt = constans.DEAD, "broken", start
+		must(t, s.job.Fail(ctx, *second, start))
+
+		retried, err := s.job.Retry(ctx, second.ID, start)
+		must(t, err)
+		if !retried {
+			t.Fatal("got the dead job left alone, want it retried")
+		}
+
+		retried, err = s.job.Retry(ctx, second.ID, start)
+		must(t, err)
+		if retried {
+			t.Fatal("got a pending job retried, want only dead ones")
+		}
+
+		job, err = s.job.ReadByID(ctx, second.ID)
+		must(t, err)
+		if job.Status != constans.PENDING || job.Attempts != 0 || job.FinishedAt != nil {
+			t.Fatalf("got %+v, want the job queued again", job)
+		}
+
+		must(t, s.job.DeleteFinished(ctx, start.Add(time.Second)))
+
+		missing, err := s.job.ReadByID(ctx, first.ID)
+		must(t, err)
+		if missing != nil {
+			t.Fatalf("got %+v, want the completed job deleted", missing)
+		}
+	})
+}
+
+func TestReconciliationConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		for _, result := range []string{constans.APPLIED, constans.MISMATCH} {
+			_, err := s.reconciliation.Create(ctx, model.Reconciliation{
+				TransactionID:  1,
+				PreviousStatus: constans.PENDING,
+				Status:         constans.PAID,
+				Currency:       constans.DefaultCurrency,
+				ExpectedAmount: 100000,
+				Result:         result,
+			})
+			must(t, err)
+		}
+
+		reconciliations, err := s.reconciliation.ReadBetween(ctx, now().Add(-time.Hour), now().Add(time.Hour))
+		must(t, err)
+		if len(reconciliations) != 2 || reconciliations[0].Result != constans.APPLIED || reconciliations[1].Result != constans.MISMATCH {
+			t.Fatalf("got %+v, want both reconciliations in order", reconciliations)
+		}
+
+		reconciliations, err = s.reconciliation.ReadBetween(ctx, now().Add(time.Hour), now().Add(2*time.Hour))
+		must(t, err)
+		if len(reconciliations) != 0 {
+			t.Fatalf("got %+v, want none", reconciliations)
+		}
+	})
+}
+
+func TestWaitingRoomConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		room := model.WaitingRoom{ProductID: 1, Rate: 10, CheckoutWindow: 5, AdmittedAt: now()}
+		must(t, s.waitingRoom.Create(ctx, room))
+
+		if err := s.waitingRoom.Create(ctx, room); err == nil {
+			t.Fatal("got no error, want the second room refused")
+		}
+
+		room.Paused, room.Admitted, room.LastPosition = true, 1, 2
+		must(t, s.waitingRoom.Update(ctx, room))
+
+		stored, err := s.waitingRoom.ReadByProduct(ctx, 1)
+		must(t, err)
+		if stored == nil || !stored.Paused || stored.Admitted != 1 || stored.LastPosition != 2 || !stored.AdmittedAt.Equal(room.AdmittedAt) {
+			t.Fatalf("got %+v, want %+v", stored, room)
+		}
+
+		must(t, s.waitingRoom.CreateEntry(ctx, model.QueueEntry{ProductID: 1, UserID: 1, Position: 1}))
+
+		if err = s.waitingRoom.CreateEntry(ctx, model.QueueEntry{ProductID: 1, UserID: 2, Position: 1}); err == nil {
+			t.Fatal("got no error, want the taken position refused")
+		}
+		if err = s.waitingRoom.CreateEntry(ctx, model.QueueEntry{ProductID: 1, UserID: 1, Position: 2}); err == nil {
+			t.Fatal("got no error, want the user queued once")
+		}
+
+		admitted, err := s.waitingRoom.Admit(ctx, 1, 1, now())
+		must(t, err)
+		if !admitted {
+			t.Fatal("got the entry left out, want it admitted")
+		}
+
+		admitted, err = s.waitingRoom.Admit(ctx, 1, 1, now())
+		must(t, err)
+		if admitted {
+			t.Fatal("got the entry admitted twice, want once")
+		}
+
+		must(t, s.waitingRoom.Requeue(ctx, model.QueueEntry{ProductID: 1, UserID: 1, Position: 3}))
+
+		entry, err := s.waitingRoom.ReadEntry(ctx, 1, 1)
+		must(t, err)
+		if entry == nil || entry.Position != 3 || entry.AdmittedAt != nil {
+			t.Fatalf("got %+v, want the entry at the back of the queue", entry)
+		}
+
+		must(t, s.waitingRoom.Delete(ctx, 1))
+
+		stored, err = s.waitingRoom.ReadByProduct(ctx, 1)
+		must(t, err)
+		if stored != nil {
+			t.Fatalf("got %+v, want no room", stored)
+		}
+
+		entry, err = s.waitingRoom.ReadEntry(ctx, 1, 1)
+		must(t, err)
+		if entry != nil {
+			t.Fatalf("got %+v, want the queue deleted with the room", entry)
+		}
+	})
+}
+
+func TestUnitOfWorkConformance(t *testing.T) {
+	conform(t, func(t *testing.T, ctx context.Context, s storage) {
+		errRollback := errors.New("rollback")
+
+		err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			must(t, s.product.Create(ctx, model.Product{Name: "rolled back", Price: model.Money{Currency: constans.DefaultCurrency}}))
+			must(t, s.outbox.Create(ctx, model.Event{Type: constans.TransactionCreated, Payload: json.RawMessage(`{}`), NextAttemptAt: now()}))
+
+			return errRollback
+		})
+		wantErr(t, err, errRollback)
+
+		err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			return s.product.Create(ctx, model.Product{Name: "committed", Price: model.Money{Currency: constans.DefaultCurrency}, Stock: 1})
+		})
+		must(t, err)
+
+		products, err := s.product.Read(ctx, model.ProductFilter{})
+		must(t, err)
+		if len(products) != 1 || products[0].Name != "committed" {
+			t.Fatalf("got %+v, want only the committed product", products)
+		}
+
+		events, err := s.outbox.ReadRecent(ctx, now().Add(-time.Hour), []string{constans.TransactionCreated}, 10)
+		must(t, err)
+		if len(events) != 0 {
+			t.Fatalf("got %+v, want the event rolled back", events)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+)
+
+var (
+	orderedGroupConcat = regexp.MustCompile(`GROUP_CONCAT\((\w+) ORDER BY (\w+)\)`)
+	// user and transaction are keywords of PostgreSQL and SQLite
+	reservedTables = regexp.MustCompile(`\b(FROM|INTO|UPDATE|JOIN) (user|transaction)\b`)
+	// an UPDATE of the first rows in an order, like the claims of the queues
+	orderedUpdate = regexp.MustCompile(`(?s)^UPDATE (\w+) SET (.*?)\s+WHERE (.*)\s+ORDER BY (.*) LIMIT (\?|\d+)$`)
+	findInSet     = regexp.MustCompile(`FIND_IN_SET\(\?, (\w+)\) > 0`)
+	insertIgnore  = regexp.MustCompile(`^INSERT IGNORE INTO`)
+	upsertValues  = regexp.MustCompile(`VALUES\((\w+)\)`)
+)
+
+// Dialect adapts the queries of the repositories, written for MySQL, to the
+// configured database driver
+type Dialect struct {
+	driver string
+}
+
+func NewDialect(driver string) Dialect {
+	if driver == "" {
+		driver = constans.DriverMySQL
+	}
+
+	return Dialect{driver: driver}
+}
+
+// Query rewrites a MySQL query for the driver
+func (d Dialect) Query(query string) string {
+	switch d.driver {
+	case constans.DriverPostgres:
+		query = orderedGroupConcat.ReplaceAllString(query, "STRING_AGG($1, ',' ORDER BY $2)")
+		query = reservedTables.ReplaceAllString(query, `$1 "$2"`)
+		query = findInSet.ReplaceAllString(query, findInList)
+		if insertIgnore.MatchString(query) {
+			query = insertIgnore.ReplaceAllString(query, "INSERT INTO") + " ON CONFLICT DO NOTHING"
+		}
+		// concurrent claims skip the rows another one is taking instead of taking them too
+		query = orderedUpdate.ReplaceAllString(query, "UPDATE $1 SET $2 WHERE id IN (SELECT id FROM $1 WHERE $3 ORDER BY $4 LIMIT $5 FOR UPDATE SKIP LOCKED)")
+		query = rebind(query)
+	case constans.DriverSQLite:
+		// the order is restored when the row is scanned
+		query = orderedGroupConcat.ReplaceAllString(query, "GROUP_CONCAT($1)")
+		query = reservedTables.ReplaceAllString(query, `$1 "$2"`)
+		query = findInSet.ReplaceAllString(query, findInList)
+		query = insertIgnore.ReplaceAllString(query, "INSERT OR IGNORE INTO")
+		query = orderedUpdate.ReplaceAllString(query, "UPDATE $1 SET $2 WHERE id IN (SELECT id FROM $1 WHERE $3 ORDER BY $4 LIMIT $5)")
+		// MAX of SQLite takes several values like GREATEST
+		query = strings.ReplaceAll(query, "GREATEST(", "MAX(")
+		// a transaction of SQLite locks the whole database when it begins
+		query = strings.ReplaceAll(query, " FOR UPDATE", "")
+	}
+
+	return query
+}
+
+// findInList matches a value in a comma separated column like FIND_IN_SET
+const findInList = `(',' || $1 || ',') LIKE ('%,' || CAST(? AS TEXT) || ',%')`
+
+// upsert rewrites an INSERT ... ON DUPLICATE KEY UPDATE col=VALUES(col) for the
+// driver, the others need the columns of the unique key the insert conflicts on
+func (d Dialect) upsert(query string, conflict string) string {
+	if d.driver != constans.DriverPostgres && d.driver != constans.DriverSQLite {
+		return query
+	}
+
+	query = strings.Replace(query, "ON DUPLICATE KEY UPDATE", "ON CONFLICT ("+conflict+") DO UPDATE SET", 1)
+	query = upsertValues.ReplaceAllString(query, "excluded.$1")
+
+	return d.Query(query)
+}
+
+// insert runs an INSERT and returns the id of the new row, PostgreSQL has no
+// LastInsertId so the id is returned by the statement itself
+func (d Dialect) insert(ctx context.Context, exec executor, query string, args ...interface{}) (id int64, err error) {
+	if d.driver == constans.DriverPostgres {
+		err = exec.QueryRowContext(ctx, d.Query(query)+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := exec.ExecContext(ctx, d.Query(query), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// withinRadius returns the condition that a row's latitude and longitude lie
+// within meters of a point
+func (d Dialect) withinRadius(latitude, longitude, meters float64) (string, []interface{}) {
+	switch d.driver {
+	case constans.DriverPostgres:
+		return "6371008.8 * 2 * ASIN(SQRT(POWER(SIN(RADIANS(latitude - ?) / 2), 2) + COS(RADIANS(?)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - ?) / 2), 2))) <= ?",
+			[]interface{}{latitude, latitude, longitude, meters}
+	case constans.DriverSQLite:
+		// registered by the driver, see config.SqliteConnect
+		return "distance_sphere(longitude, latitude, ?, ?) <= ?", []interface{}{longitude, latitude, meters}
+	}
+
+	return "ST_Distance_Sphere(POINT(longitude, latitude), POINT(?, ?)) <= ?", []interface{}{longitude, latitude, meters}
+}
+
+// rebind numbers the ? placeholders of a query as $1, $2...
+func rebind(query string) string {
+	var (
+		b     strings.Builder
+		index int
+	)
+
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+
+		index++
+		b.WriteString("$")
+		b.WriteString(strconv.Itoa(index))
+	}
+
+	return b.String()
+}
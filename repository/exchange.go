@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	upsertExchangeRate = `INSERT INTO exchange_rate (currency, rate, source) VALUES (?,?,?)
+		ON DUPLICATE KEY UPDATE rate=VALUES(rate), source=VALUES(source)`
+	deleteExchangeRate       = `DELETE FROM exchange_rate WHERE currency=?`
+	readAllExchangeRate      = `SELECT currency, rate, source, updated_at FROM exchange_rate ORDER BY currency`
+	readExchangeRateCurrency = `SELECT currency, rate, source, updated_at FROM exchange_rate WHERE currency=?`
+)
+
+type ExchangeRateRepository interface {
+	Read(context.Context) ([]model.ExchangeRate, error)
+	ReadByCurrency(ctx context.Context, currency string) (*model.ExchangeRate, error)
+	Upsert(ctx context.Context, rate model.ExchangeRate) error
+	Delete(ctx context.Context, currency string) error
+}
+
+type mysqlExchangeRateRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewExchangeRateRepository(db *sql.DB, dialect Dialect) ExchangeRateRepository {
+	return &mysqlExchangeRateRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func (repo *mysqlExchangeRateRepository) Read(ctx context.Context) (response []model.ExchangeRate, err error) {
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readAllExchangeRate))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r model.ExchangeRate
+		err = rows.Scan(
+			&r.Currency,
+			&r.Rate,
+			&r.Source,
+			&r.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, r)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlExchangeRateRepository) ReadByCurrency(ctx context.Context, currency string) (*model.ExchangeRate, error) {
+	var r model.ExchangeRate
+	err := conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readExchangeRateCurrency), currency).Scan(
+		&r.Currency,
+		&r.Rate,
+		&r.Source,
+		&r.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (repo *mysqlExchangeRateRepository) Upsert(ctx context.Context, request model.ExchangeRate) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.upsert(upsertExchangeRate, "currency"))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, request.Currency, request.Rate, request.Source)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlExchangeRateRepository) Delete(ctx context.Context, currency string) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(deleteExchangeRate))
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, currency)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return constans.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertIdempotencyKey = `INSERT IGNORE INTO idempotency_key (scope, idempotency_key, request_hash, locked_until) VALUES (?,?,?,?)`
+	readIdempotencyKey   = `SELECT scope, idempotency_key, request_hash, COALESCE(status_code, 0), response, completed, locked_until, created_at
+		FROM idempotency_key WHERE scope=? AND idempotency_key=?`
+	lockIdempotencyKey = `UPDATE idempotency_key SET locked_until=? WHERE scope=? AND idempotency_key=? AND request_hash=? AND completed=0
+		AND (locked_until IS NULL OR locked_until < ?)`
+	completeIdempotencyKey = `UPDATE idempotency_key SET status_code=?, response=?, completed=1, locked_until=NULL WHERE scope=? AND idempotency_key=? AND completed=0`
+	deleteIdempotencyKey   = `DELETE FROM idempotency_key WHERE scope=? AND idempotency_key=? AND completed=0`
+	deleteExpiredKey       = `DELETE FROM idempotency_key WHERE scope=? AND idempotency_key=? AND created_at < ?`
+	deleteExpiredKeys      = `DELETE FROM idempotency_key WHERE created_at < ?`
+)
+
+type IdempotencyRepository interface {
+	// Create reserves a key, it returns false when the key is already taken
+	Create(ctx context.Context, key model.IdempotencyKey, expiredBefore time.Time) (bool, error)
+	Read(ctx context.Context, scope string, key string) (*model.IdempotencyKey, error)
+	// TakeOver locks a key until key.LockedUntil for the same request when the
+	// request holding it let its lock expire before completing
+	TakeOver(ctx context.Context, key model.IdempotencyKey, now time.Time) (bool, error)
+	// Complete stores the response of a key, the first response is kept
+	Complete(ctx context.Context, key model.IdempotencyKey) error
+	// Delete frees a key which is not completed
+	Delete(ctx context.Context, scope string, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type mysqlIdempotencyRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewIdempotencyRepository(db *sql.DB, dialect Dialect) IdempotencyRepository {
+	return &mysqlIdempotencyRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func (repo *mysqlIdempotencyRepository) Create(ctx context.Context, key model.IdempotencyKey, expiredBefore time.Time) (bool, error) {
+	// an expired key can be used again
+	if _, err := conn(ctx, repo.db).ExecContext(ctx, repo.dialect.Query(deleteExpiredKey), key.Scope, key.Key, expiredBefore); err != nil {
+		return false, err
+	}
+
+	result, err := conn(ctx, repo.db).ExecContext(ctx, repo.dialect.Query(insertIdempotencyKey), key.Scope, key.Key, key.RequestHash, key.LockedUntil)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (repo *mysqlIdempotencyRepository) Read(ctx context.Context, scope string, key string) (*model.IdempotencyKey, error) {
+	var (
+		k           model.IdempotencyKey
+		lockedUntil sql.NullTime
+	)
+
+	err := conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readIdempotencyKey), scope, key).Scan(
+		&k.Scope,
+		&k.Key,
+		&k.RequestHash,
+		&k.StatusCode,
+		&k.Response,
+		&k.Completed,
+		&lockedUntil,
+		&k.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		k.LockedUntil = &lockedUntil.Time
+	}
+
+	return &k, nil
+}
+
+func (repo *mysqlIdempotencyRepository) TakeOver(ctx context.Context, key model.IdempotencyKey, now time.Time) (bool, error) {
+	result, err := conn(ctx, repo.db).ExecContext(ctx, repo.dialect.Query(lockIdempotencyKey), key.LockedUntil, key.Scope, key.Key, key.RequestHash, now)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *mysqlIdempotencyRepository) Complete(ctx context.Context, key model.IdempotencyKey) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(completeIdempotencyKey))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, key.StatusCode, key.Response, key.Scope, key.Key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlIdempotencyRepository) Delete(ctx context.Context, scope string, key string) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(deleteIdempotencyKey))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, scope, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlIdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := conn(ctx, repo.db).ExecContext(ctx, repo.dialect.Query(deleteExpiredKeys), before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
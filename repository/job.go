@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertJob = `INSERT IGNORE INTO job (type, payload, unique_key, status, max_attempts, run_at) VALUES (?,?,NULLIF(?, ''),?,?,?)`
+	leaseJob  = `UPDATE job SET status=?, lease_token=?, locked_until=?, attempts=attempts+1
+		WHERE (status=? AND run_at <= ?) OR (status=? AND locked_until < ?) ORDER BY run_at, id LIMIT 1`
+	selectJob = `SELECT id, type, payload, COALESCE(unique_key, ''), status, attempts, max_attempts, last_error, run_at,
+		COALESCE(lease_token, ''), locked_until, finished_at, created_at, updated_at FROM job`
+	readJobByID        = selectJob + ` WHERE id=?`
+	readJobByLease     = selectJob + ` WHERE lease_token=?`
+	readJobByStatus    = selectJob + ` WHERE status=? ORDER BY id DESC LIMIT ?`
+	completeJob        = `UPDATE job SET status=?, last_error='', lease_token=NULL, locked_until=NULL, finished_at=? WHERE id=? AND lease_token=?`
+	failJob            = `UPDATE job SET status=?, last_error=?, run_at=?, lease_token=NULL, locked_until=NULL, finished_at=? WHERE id=? AND lease_token=?`
+	retryJob           = `UPDATE job SET status=?, attempts=0, run_at=?, finished_at=NULL WHERE id=? AND status=?`
+	deleteFinishedJobs = `DELETE FROM job WHERE status=? AND finished_at < ?`
+)
+
+type JobRepository interface {
+	// Create adds a job to the queue, a job with the unique key of a queued job is ignored.
+	// Inside a unit of work the job is only queued when the unit of work commits.
+	Create(ctx context.Context, job model.Job) error
+	// Lease takes the next due job, or a running job whose lease expired, and hides
+	// it from other workers until lockedUntil. It returns nil when no job is due.
+	Lease(ctx context.Context, token string, now time.Time, lockedUntil time.Time) (*model.Job, error)
+	ReadByID(ctx context.Context, jobID int64) (*model.Job, error)
+	ReadByStatus(ctx context.Context, status string, limit int) ([]model.Job, error)
+	// Complete marks a leased job as completed, it is ignored when the lease was lost
+	Complete(ctx context.Context, job model.Job, finishedAt time.Time) error
+	// Fail records a failed run of a leased job, it is run again at job.RunAt while its status is pending
+	Fail(ctx context.Context, job model.Job, finishedAt time.Time) error
+	// Retry queues a dead job again
+	Retry(ctx context.Context, jobID int64, runAt time.Time) (bool, error)
+	DeleteFinished(ctx context.Context, before time.Time) error
+}
+
+type mysqlJobRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewJobRepository(db *sql.DB, dialect Dialect) JobRepository {
+	return &mysqlJobRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func (repo *mysqlJobRepository) Create(ctx context.Context, job model.Job) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(insertJob))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, job.Type, []byte(job.Payload), job.UniqueKey, constans.PENDING, job.MaxAttempts, job.RunAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Lease claims a job with a single update, so concurrent workers never take the
+// same job, and reads it back by its lease token
+func (repo *mysqlJobRepository) Lease(ctx context.Context, token string, now time.Time, lockedUntil time.Time) (*model.Job, error) {
+	result, err := conn(ctx, repo.db).ExecContext(ctx, repo.dialect.Query(leaseJob),
+		constans.RUNNING, token, lockedUntil,
+		constans.PENDING, now,
+		constans.RUNNING, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, nil
+	}
+
+	return scanJob(conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readJobByLease), token))
+}
+
+func (repo *mysqlJobRepository) ReadByID(ctx context.Context, jobID int64) (*model.Job, error) {
+	job, err := scanJob(conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readJobByID), jobID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (repo *mysqlJobRepository) ReadByStatus(ctx context.Context, status string, limit int) (response []model.Job, err error) {
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readJobByStatus), status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *job)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlJobRepository) Complete(ctx context.Context, job model.Job, finishedAt time.Time) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(completeJob))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, constans.COMPLETED, finishedAt, job.ID, job.LeaseToken)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlJobRepository) Fail(ctx context.Context, job model.Job, finishedAt time.Time) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(failJob))
+	if err != nil {
+		return err
+	}
+
+	var finished *time.Time
+	if job.Status == constans.DEAD {
+		finished = &finishedAt
+	}
+
+	_, err = stmt.ExecContext(ctx, job.Status, job.LastError, job.RunAt, finished, job.ID, job.LeaseToken)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlJobRepository) Retry(ctx context.Context, jobID int64, runAt time.Time) (bool, error) {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(retryJob))
+	if err != nil {
+		return false, err
+	}
+
+	result, err := stmt.ExecContext(ctx, constans.PENDING, runAt, jobID, constans.DEAD)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (repo *mysqlJobRepository) DeleteFinished(ctx context.Context, before time.Time) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(deleteFinishedJobs))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, constans.COMPLETED, before)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanJob(row scanner) (*model.Job, error) {
+	var (
+		job         model.Job
+		payload     []byte
+		lockedUntil sql.NullTime
+		finishedAt  sql.NullTime
+	)
+
+	err := row.Scan(
+		&job.ID,
+		&job.Type,
+		&payload,
+		&job.UniqueKey,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.RunAt,
+		&job.LeaseToken,
+		&lockedUntil,
+		&finishedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Payload = payload
+
+	if lockedUntil.Valid {
+		job.LockedUntil = &lockedUntil.Time
+	}
+
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return &job, nil
+}
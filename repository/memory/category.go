@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryCategoryRepository struct {
+	store *Store
+}
+
+func NewCategoryRepository(store *Store) repository.CategoryRepository {
+	return &memoryCategoryRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryCategoryRepository) Create(ctx context.Context, request model.Category) (*model.Category, error) {
+	defer repo.store.lock(ctx)()
+
+	now := time.Now()
+	request.ID = repo.store.nextID("category")
+	request.CreatedAt = now
+	request.UpdatedAt = now
+	repo.store.data.categories[request.ID] = request
+
+	return &request, nil
+}
+
+func (repo *memoryCategoryRepository) Read(ctx context.Context) (response []model.Category, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, c := range repo.store.data.categories {
+		response = append(response, c)
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		if response[i].Name != response[j].Name {
+			return response[i].Name < response[j].Name
+		}
+		return response[i].ID < response[j].ID
+	})
+
+	return response, nil
+}
+
+func (repo *memoryCategoryRepository) ReadByID(ctx context.Context, categoryID int64) (*model.Category, error) {
+	defer repo.store.lock(ctx)()
+
+	c, ok := repo.store.data.categories[categoryID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &c, nil
+}
+
+func (repo *memoryCategoryRepository) Update(ctx context.Context, request model.Category) error {
+	defer repo.store.lock(ctx)()
+
+	c, ok := repo.store.data.categories[request.ID]
+	if !ok {
+		return nil
+	}
+
+	c.ParentID = request.ParentID
+	c.Name = request.Name
+	c.UpdatedAt = time.Now()
+	repo.store.data.categories[c.ID] = c
+
+	return nil
+}
+
+func (repo *memoryCategoryRepository) Delete(ctx context.Context, categoryID int64) error {
+	defer repo.store.lock(ctx)()
+
+	delete(repo.store.data.categories, categoryID)
+
+	return nil
+}
+
+// CountUsages returns the number of child categories and products referencing a category
+func (repo *memoryCategoryRepository) CountUsages(ctx context.Context, categoryID int64) (total int64, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, c := range repo.store.data.categories {
+		if c.ParentID != 0 && c.ParentID == categoryID {
+			total++
+		}
+	}
+
+	for _, p := range repo.store.data.products {
+		if p.CategoryID != 0 && p.CategoryID == categoryID {
+			total++
+		}
+	}
+
+	return total, nil
+}
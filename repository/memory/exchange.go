@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryExchangeRateRepository struct {
+	store *Store
+}
+
+func NewExchangeRateRepository(store *Store) repository.ExchangeRateRepository {
+	return &memoryExchangeRateRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryExchangeRateRepository) Read(ctx context.Context) (response []model.ExchangeRate, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, r := range repo.store.data.exchangeRates {
+		response = append(response, r)
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Currency < response[j].Currency
+	})
+
+	return response, nil
+}
+
+func (repo *memoryExchangeRateRepository) ReadByCurrency(ctx context.Context, currency string) (*model.ExchangeRate, error) {
+	defer repo.store.lock(ctx)()
+
+	r, ok := repo.store.data.exchangeRates[currency]
+	if !ok {
+		return nil, nil
+	}
+
+	return &r, nil
+}
+
+func (repo *memoryExchangeRateRepository) Upsert(ctx context.Context, request model.ExchangeRate) error {
+	defer repo.store.lock(ctx)()
+
+	request.UpdatedAt = time.Now()
+	repo.store.data.exchangeRates[request.Currency] = request
+
+	return nil
+}
+
+func (repo *memoryExchangeRateRepository) Delete(ctx context.Context, currency string) error {
+	defer repo.store.lock(ctx)()
+
+	if _, ok := repo.store.data.exchangeRates[currency]; !ok {
+		return constans.ErrNotFound
+	}
+
+	delete(repo.store.data.exchangeRates, currency)
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryIdempotencyRepository struct {
+	store *Store
+}
+
+func NewIdempotencyRepository(store *Store) repository.IdempotencyRepository {
+	return &memoryIdempotencyRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryIdempotencyRepository) Create(ctx context.Context, key model.IdempotencyKey, expiredBefore time.Time) (bool, error) {
+	defer repo.store.lock(ctx)()
+
+	id := idempotencyKey{scope: key.Scope, key: key.Key}
+
+	existing, ok := repo.store.data.idempotencyKeys[id]
+	// an expired key can be used again
+	if ok && !existing.CreatedAt.Before(expiredBefore) {
+		return false, nil
+	}
+
+	repo.store.data.idempotencyKeys[id] = model.IdempotencyKey{
+		Scope:       key.Scope,
+		Key:         key.Key,
+		RequestHash: key.RequestHash,
+		LockedUntil: copyTime(key.LockedUntil),
+		CreatedAt:   time.Now(),
+	}
+
+	return true, nil
+}
+
+func (repo *memoryIdempotencyRepository) Read(ctx context.Context, scope string, key string) (*model.IdempotencyKey, error) {
+	defer repo.store.lock(ctx)()
+
+	k, ok := repo.store.data.idempotencyKeys[idempotencyKey{scope: scope, key: key}]
+	if !ok {
+		return nil, nil
+	}
+
+	k.Response = copyBytes(k.Response)
+	k.LockedUntil = copyTime(k.LockedUntil)
+	return &k, nil
+}
+
+func (repo *memoryIdempotencyRepository) TakeOver(ctx context.Context, key model.IdempotencyKey, now time.Time) (bool, error) {
+	defer repo.store.lock(ctx)()
+
+	id := idempotencyKey{scope: key.Scope, key: key.Key}
+
+	k, ok := repo.store.data.idempotencyKeys[id]
+	if !ok || k.Completed || k.RequestHash != key.RequestHash || (k.LockedUntil != nil && !k.LockedUntil.Before(now)) {
+		return false, nil
+	}
+
+	k.LockedUntil = copyTime(key.LockedUntil)
+	repo.store.data.idempotencyKeys[id] = k
+
+	return true, nil
+}
+
+func (repo *memoryIdempotencyRepository) Complete(ctx context.Context, key model.IdempotencyKey) error {
+	defer repo.store.lock(ctx)()
+
+	id := idempotencyKey{scope: key.Scope, key: key.Key}
+
+	k, ok := repo.store.data.idempotencyKeys[id]
+	if !ok || k.Completed {
+		return nil
+	}
+
+	k.StatusCode = key.StatusCode
+	k.Response = copyBytes(key.Response)
+	k.Completed = true
+	k.LockedUntil = nil
+	repo.store.data.idempotencyKeys[id] = k
+
+	return nil
+}
+
+func (repo *memoryIdempotencyRepository) Delete(ctx context.Context, scope string, key string) error {
+	defer repo.store.lock(ctx)()
+
+	id := idempotencyKey{scope: scope, key: key}
+	if k, ok := repo.store.data.idempotencyKeys[id]; ok && !k.Completed {
+		delete(repo.store.data.idempotencyKeys, id)
+	}
+
+	return nil
+}
+
+func (repo *memoryIdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (deleted int64, err error) {
+	defer repo.store.lock(ctx)()
+
+	for id, k := range repo.store.data.idempotencyKeys {
+		if k.CreatedAt.Before(before) {
+			delete(repo.store.data.idempotencyKeys, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryJobRepository struct {
+	store *Store
+}
+
+func NewJobRepository(store *Store) repository.JobRepository {
+	return &memoryJobRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryJobRepository) Create(ctx context.Context, job model.Job) error {
+	defer repo.store.lock(ctx)()
+
+	if job.UniqueKey != "" {
+		for _, j := range repo.store.data.jobs {
+			if j.UniqueKey == job.UniqueKey {
+				return nil
+			}
+		}
+	}
+
+	now := time.Now()
+	id := repo.store.nextID("job")
+	repo.store.data.jobs[id] = model.Job{
+		ID:          id,
+		Type:        job.Type,
+		Payload:     copyRaw(job.Payload),
+		UniqueKey:   job.UniqueKey,
+		Status:      constans.PENDING,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	return nil
+}
+
+// Lease takes the due job that should have run first, the store lock keeps
+// concurrent workers from taking the same job
+func (repo *memoryJobRepository) Lease(ctx context.Context, token string, now time.Time, lockedUntil time.Time) (*model.Job, error) {
+	defer repo.store.lock(ctx)()
+
+	var next *model.Job
+	for _, j := range repo.store.data.jobs {
+		due := j.Status == constans.PENDING && !j.RunAt.After(now)
+		expired := j.Status == constans.RUNNING && j.LockedUntil != nil && j.LockedUntil.Before(now)
+		if !due && !expired {
+			continue
+		}
+
+		if next == nil || j.RunAt.Before(next.RunAt) || (j.RunAt.Equal(next.RunAt) && j.ID < next.ID) {
+			j := j
+			next = &j
+		}
+	}
+
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Status = constans.RUNNING
+	next.LeaseToken = token
+	next.LockedUntil = &lockedUntil
+	next.Attempts++
+	next.UpdatedAt = time.Now()
+	repo.store.data.jobs[next.ID] = *next
+
+	leased := readJob(*next)
+	return &leased, nil
+}
+
+func (repo *memoryJobRepository) ReadByID(ctx context.Context, jobID int64) (*model.Job, error) {
+	defer repo.store.lock(ctx)()
+
+	j, ok := repo.store.data.jobs[jobID]
+	if !ok {
+		return nil, nil
+	}
+
+	j = readJob(j)
+	return &j, nil
+}
+
+func (repo *memoryJobRepository) ReadByStatus(ctx context.Context, status string, limit int) (response []model.Job, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, j := range repo.store.data.jobs {
+		if j.Status == status {
+			response = append(response, readJob(j))
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID > response[j].ID
+	})
+
+	if len(response) > limit {
+		response = response[:limit]
+	}
+
+	return response, nil
+}
+
+func (repo *memoryJobRepository) Complete(ctx context.Context, job model.Job, finishedAt time.Time) error {
+	defer repo.store.lock(ctx)()
+
+	j, ok := repo.leased(job)
+	if !ok {
+		return nil
+	}
+
+	j.Status = constans.COMPLETED
+	j.LastError = ""
+	j.LeaseToken = ""
+	j.LockedUntil = nil
+	j.FinishedAt = &finishedAt
+	j.UpdatedAt = time.Now()
+	repo.store.data.jobs[j.ID] = j
+
+	return nil
+}
+
+func (repo *memoryJobRepository) Fail(ctx context.Context, job model.Job, finishedAt time.Time) error {
+	defer repo.store.lock(ctx)()
+
+	j, ok := repo.leased(job)
+	if !ok {
+		return nil
+	}
+
+	j.Status = job.Status
+	j.LastError = job.LastError
+	j.RunAt = job.RunAt
+	j.LeaseToken = ""
+	j.LockedUntil = nil
+	j.FinishedAt = nil
+	if job.Status == constans.DEAD {
+		j.FinishedAt = &finishedAt
+	}
+	j.UpdatedAt = time.Now()
+	repo.store.data.jobs[j.ID] = j
+
+	return nil
+}
+
+func (repo *memoryJobRepository) Retry(ctx context.Context, jobID int64, runAt time.Time) (bool, error) {
+	defer repo.store.lock(ctx)()
+
+	j, ok := repo.store.data.jobs[jobID]
+	if !ok || j.Status != constans.DEAD {
+		return false, nil
+	}
+
+	j.Status = constans.PENDING
+	j.Attempts = 0
+	j.RunAt = runAt
+	j.FinishedAt = nil
+	j.UpdatedAt = time.Now()
+	repo.store.data.jobs[jobID] = j
+
+	return true, nil
+}
+
+func (repo *memoryJobRepository) DeleteFinished(ctx context.Context, before time.Time) error {
+	defer repo.store.lock(ctx)()
+
+	for id, j := range repo.store.data.jobs {
+		if j.Status == constans.COMPLETED && j.FinishedAt != nil && j.FinishedAt.Before(before) {
+			delete(repo.store.data.jobs, id)
+		}
+	}
+
+	return nil
+}
+
+// leased returns a stored job when the lease of job still holds it
+func (repo *memoryJobRepository) leased(job model.Job) (model.Job, bool) {
+	j, ok := repo.store.data.jobs[job.ID]
+	if !ok || j.LeaseToken == "" || j.LeaseToken != job.LeaseToken {
+		return model.Job{}, false
+	}
+
+	return j, true
+}
+
+func readJob(j model.Job) model.Job {
+	j.Payload = copyRaw(j.Payload)
+	j.LockedUntil = copyTime(j.LockedUntil)
+	j.FinishedAt = copyTime(j.FinishedAt)
+
+	return j
+}
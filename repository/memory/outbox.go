@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryOutboxRepository struct {
+	store *Store
+}
+
+func NewOutboxRepository(store *Store) repository.OutboxRepository {
+	return &memoryOutboxRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryOutboxRepository) Create(ctx context.Context, event model.Event) error {
+	defer repo.store.lock(ctx)()
+
+	id := repo.store.nextID("outbox_event")
+	repo.store.data.events[id] = model.Event{
+		ID:            id,
+		Type:          event.Type,
+		AggregateID:   event.AggregateID,
+		Payload:       copyRaw(event.Payload),
+		Status:        constans.PENDING,
+		NextAttemptAt: event.NextAttemptAt,
+		CreatedAt:     time.Now(),
+	}
+
+	return nil
+}
+
+// Claim takes the oldest due events, the store lock keeps concurrent
+// dispatchers from taking the same event
+func (repo *memoryOutboxRepository) Claim(ctx context.Context, token string, now time.Time, lockedUntil time.Time, limit int) (response []model.Event, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, e := range repo.store.data.events {
+		due := e.Status == constans.PENDING && !e.NextAttemptAt.After(now)
+		expired := e.Status == constans.DISPATCHING && e.LockedUntil != nil && e.LockedUntil.Before(now)
+		if due || expired {
+			response = append(response, e)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	if len(response) > limit {
+		response = response[:limit]
+	}
+
+	for i, e := range response {
+		e.Status = constans.DISPATCHING
+		e.LeaseToken = token
+		e.LockedUntil = &lockedUntil
+		repo.store.data.events[e.ID] = e
+
+		e.Payload = copyRaw(e.Payload)
+		e.LockedUntil = copyTime(e.LockedUntil)
+		e.DispatchedAt = copyTime(e.DispatchedAt)
+		response[i] = e
+	}
+
+	return response, nil
+}
+
+func (repo *memoryOutboxRepository) ReadRecent(ctx context.Context, since time.Time, types []string, limit int) (response []model.Event, err error) {
+	defer repo.store.lock(ctx)()
+
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	for _, e := range repo.store.data.events {
+		if wanted[e.Type] && !e.CreatedAt.Before(since) {
+			e.Payload = copyRaw(e.Payload)
+			e.LockedUntil = copyTime(e.LockedUntil)
+			e.DispatchedAt = copyTime(e.DispatchedAt)
+			response = append(response, e)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	if len(response) > limit {
+		response = response[len(response)-limit:]
+	}
+
+	return response, nil
+}
+
+func (repo *memoryOutboxRepository) MarkDispatched(ctx context.Context, event model.Event, dispatchedAt time.Time) error {
+	defer repo.store.lock(ctx)()
+
+	e, ok := repo.store.data.events[event.ID]
+	if !ok || e.LeaseToken != event.LeaseToken {
+		return nil
+	}
+
+	e.Status = constans.DISPATCHED
+	e.Attempts++
+	e.LastError = ""
+	e.LeaseToken = ""
+	e.LockedUntil = nil
+	e.DispatchedAt = &dispatchedAt
+	repo.store.data.events[event.ID] = e
+
+	return nil
+}
+
+func (repo *memoryOutboxRepository) MarkFailed(ctx context.Context, event model.Event) error {
+	defer repo.store.lock(ctx)()
+
+	e, ok := repo.store.data.events[event.ID]
+	if !ok || e.LeaseToken != event.LeaseToken {
+		return nil
+	}
+
+	e.Status = event.Status
+	e.Attempts = event.Attempts
+	e.LastError = event.LastError
+	e.NextAttemptAt = event.NextAttemptAt
+	e.LeaseToken = ""
+	e.LockedUntil = nil
+	repo.store.data.events[event.ID] = e
+
+	return nil
+}
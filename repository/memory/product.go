@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryProductRepository struct {
+	store *Store
+}
+
+func NewProductRepository(store *Store) repository.ProductRepository {
+	return &memoryProductRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryProductRepository) Create(ctx context.Context, request model.Product) error {
+	defer repo.store.lock(ctx)()
+
+	tags, err := productTags(request.Tags)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	id := repo.store.nextID("product")
+	request.ID = strconv.FormatInt(id, 10)
+	request.Tags = tags
+	request.DisplayPrice = nil
+	request.DeletedAt = nil
+	request.CreatedAt = now
+	request.UpdatedAt = now
+	repo.store.data.products[id] = request
+
+	return nil
+}
+
+func (repo *memoryProductRepository) Read(ctx context.Context, filter model.ProductFilter) (response []model.Product, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, p := range repo.store.data.products {
+		if matchProduct(p, filter) {
+			response = append(response, readProduct(p))
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return productID(response[i]) < productID(response[j])
+	})
+
+	return response, nil
+}
+
+func (repo *memoryProductRepository) Update(ctx context.Context, request model.Product) error {
+	defer repo.store.lock(ctx)()
+
+	id := productID(request)
+	p, ok := repo.store.data.products[id]
+	if !ok {
+		return nil
+	}
+
+	tags, err := productTags(request.Tags)
+	if err != nil {
+		return err
+	}
+
+	p.Name = request.Name
+	p.Description = request.Description
+	p.Price = request.Price
+	p.ImageURL = request.ImageURL
+	p.StartDate = request.StartDate
+	p.EndDate = request.EndDate
+	p.CategoryID = request.CategoryID
+	p.Tags = tags
+	p.OrganizerName = request.OrganizerName
+	p.OrganizerContact = request.OrganizerContact
+	p.Location = request.Location
+	p.Address = request.Address
+	p.Latitude = request.Latitude
+	p.Longitude = request.Longitude
+	p.UpdatedAt = time.Now()
+	repo.store.data.products[id] = p
+
+	return nil
+}
+
+// Delete soft deletes a product by setting its deleted_at
+func (repo *memoryProductRepository) Delete(ctx context.Context, productID int64) error {
+	defer repo.store.lock(ctx)()
+
+	p, ok := repo.store.data.products[productID]
+	if !ok || p.DeletedAt != nil {
+		return constans.ErrNotFound
+	}
+
+	now := time.Now()
+	p.DeletedAt = &now
+	p.UpdatedAt = now
+	repo.store.data.products[productID] = p
+
+	return nil
+}
+
+func (repo *memoryProductRepository) Restore(ctx context.Context, productID int64) error {
+	defer repo.store.lock(ctx)()
+
+	p, ok := repo.store.data.products[productID]
+	if !ok || p.DeletedAt == nil {
+		return constans.ErrNotFound
+	}
+
+	p.DeletedAt = nil
+	p.UpdatedAt = time.Now()
+	repo.store.data.products[productID] = p
+
+	return nil
+}
+
+func (repo *memoryProductRepository) ReadByID(ctx context.Context, productID int64) (*model.Product, error) {
+	defer repo.store.lock(ctx)()
+
+	p, ok := repo.store.data.products[productID]
+	if !ok {
+		return nil, nil
+	}
+
+	p = readProduct(p)
+	return &p, nil
+}
+
+// ReadByIDForUpdate needs no row lock, a unit of work already holds the store lock
+func (repo *memoryProductRepository) ReadByIDForUpdate(ctx context.Context, productID int64) (*model.Product, error) {
+	return repo.ReadByID(ctx, productID)
+}
+
+func (repo *memoryProductRepository) ReadByName(ctx context.Context, name string) (*model.Product, error) {
+	defer repo.store.lock(ctx)()
+
+	var found *model.Product
+	for _, p := range repo.store.data.products {
+		if p.Name != name || p.DeletedAt != nil {
+			continue
+		}
+
+		if found == nil || productID(p) < productID(*found) {
+			p := readProduct(p)
+			found = &p
+		}
+	}
+
+	return found, nil
+}
+
+func (repo *memoryProductRepository) UpdateStock(ctx context.Context, productID int64, newStock int64) error {
+	defer repo.store.lock(ctx)()
+
+	p, ok := repo.store.data.products[productID]
+	if !ok {
+		return nil
+	}
+
+	p.Stock += newStock
+	p.UpdatedAt = time.Now()
+	repo.store.data.products[productID] = p
+
+	return nil
+}
+
+func (repo *memoryProductRepository) TakeStock(ctx context.Context, productID int64, quantity int64) error {
+	defer repo.store.lock(ctx)()
+
+	p, ok := repo.store.data.products[productID]
+	if !ok || p.Stock < quantity {
+		return constans.ErrTicketRunOut
+	}
+
+	p.Stock -= quantity
+	p.UpdatedAt = time.Now()
+	repo.store.data.products[productID] = p
+
+	return nil
+}
+
+func productID(p model.Product) int64 {
+	id, _ := strconv.ParseInt(p.ID, 10, 64)
+	return id
+}
+
+// readProduct returns a copy of a stored product the caller is free to change
+func readProduct(p model.Product) model.Product {
+	p.Tags = copyStrings(p.Tags)
+	p.DeletedAt = copyTime(p.DeletedAt)
+
+	return p
+}
+
+// productTags sorts the tags of a product, a tag can only be given once
+func productTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	sorted := copyStrings(tags)
+	sort.Strings(sorted)
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i] == sorted[i-1] {
+			return nil, constans.ErrConflict
+		}
+	}
+
+	return sorted, nil
+}
+
+// matchProduct reports whether a product passes the filter, with the same rules
+// as the WHERE clause built by the SQL repository
+func matchProduct(p model.Product, filter model.ProductFilter) bool {
+	if !filter.WithDeleted && p.DeletedAt != nil {
+		return false
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		found := false
+		for _, id := range filter.CategoryIDs {
+			if p.CategoryID != 0 && p.CategoryID == id {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if filter.Tag != "" {
+		found := false
+		for _, tag := range p.Tags {
+			if tag == filter.Tag {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if filter.Organizer != "" && !contains(p.OrganizerName, filter.Organizer) {
+		return false
+	}
+
+	if filter.Location != "" && !contains(p.Location, filter.Location) && !contains(p.Address, filter.Location) {
+		return false
+	}
+
+	if filter.RadiusKM > 0 && distanceSphere(p.Longitude, p.Latitude, filter.Longitude, filter.Latitude) > filter.RadiusKM*1000 {
+		return false
+	}
+
+	return true
+}
+
+// contains matches like a LIKE '%substr%' of MySQL, which ignores the case
+func contains(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
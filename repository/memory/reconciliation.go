@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryReconciliationRepository struct {
+	store *Store
+}
+
+func NewReconciliationRepository(store *Store) repository.ReconciliationRepository {
+	return &memoryReconciliationRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryReconciliationRepository) Create(ctx context.Context, request model.Reconciliation) (*model.Reconciliation, error) {
+	defer repo.store.lock(ctx)()
+
+	request.ID = repo.store.nextID("reconciliation")
+	request.CreatedAt = time.Now()
+	repo.store.data.reconciliations[request.ID] = request
+
+	return &request, nil
+}
+
+func (repo *memoryReconciliationRepository) ReadBetween(ctx context.Context, start time.Time, end time.Time) (response []model.Reconciliation, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, r := range repo.store.data.reconciliations {
+		if !r.CreatedAt.Before(start) && r.CreatedAt.Before(end) {
+			response = append(response, r)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	return response, nil
+}
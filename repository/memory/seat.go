@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memorySeatRepository struct {
+	store *Store
+}
+
+func NewSeatRepository(store *Store) repository.SeatRepository {
+	return &memorySeatRepository{
+		store: store,
+	}
+}
+
+func (repo *memorySeatRepository) CreateSeatMap(ctx context.Context, productID int64, venueID int64) (int64, error) {
+	defer repo.store.lock(ctx)()
+
+	var seats []model.Seat
+	for _, s := range repo.store.data.seats {
+		if s.VenueID != venueID {
+			continue
+		}
+
+		if _, ok := repo.store.data.productSeats[productSeatKey{productID, s.ID}]; ok {
+			return 0, constans.ErrConflict
+		}
+
+		seats = append(seats, s)
+	}
+
+	for _, s := range seats {
+		repo.store.data.productSeats[productSeatKey{productID, s.ID}] = model.ProductSeat{
+			ProductID: productID,
+			SeatID:    s.ID,
+			Section:   s.Section,
+			Row:       s.Row,
+			Number:    s.Number,
+			Status:    constans.AVAILABLE,
+		}
+	}
+
+	return int64(len(seats)), nil
+}
+
+func (repo *memorySeatRepository) ReadSeatMap(ctx context.Context, productID int64) (response []model.ProductSeat, err error) {
+	defer repo.store.lock(ctx)()
+
+	for key, s := range repo.store.data.productSeats {
+		if key.productID == productID {
+			response = append(response, s)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return seatLess(response[i].Section, response[i].Row, response[i].Number, response[j].Section, response[j].Row, response[j].Number)
+	})
+
+	return response, nil
+}
+
+func (repo *memorySeatRepository) CountSeatMap(ctx context.Context, productID int64) (total int64, err error) {
+	defer repo.store.lock(ctx)()
+
+	for key := range repo.store.data.productSeats {
+		if key.productID == productID {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+// HoldSeats locks every requested seat for the given transaction. The seats are
+// only taken when all of them are still available.
+func (repo *memorySeatRepository) HoldSeats(ctx context.Context, productID int64, transactionID int64, seatIDs []int64) error {
+	defer repo.store.lock(ctx)()
+
+	held := map[int64]bool{}
+	for _, seatID := range seatIDs {
+		s, ok := repo.store.data.productSeats[productSeatKey{productID, seatID}]
+		if !ok || s.Status != constans.AVAILABLE || held[seatID] {
+			return constans.ErrSeatNotAvailable
+		}
+		held[seatID] = true
+	}
+
+	for seatID := range held {
+		key := productSeatKey{productID, seatID}
+		s := repo.store.data.productSeats[key]
+		s.Status = constans.HELD
+		s.TransactionID = transactionID
+		repo.store.data.productSeats[key] = s
+	}
+
+	return nil
+}
+
+func (repo *memorySeatRepository) SellSeats(ctx context.Context, transactionID int64) (sold int64, err error) {
+	defer repo.store.lock(ctx)()
+
+	for key, s := range repo.store.data.productSeats {
+		if s.TransactionID != transactionID || s.Status != constans.HELD {
+			continue
+		}
+
+		s.Status = constans.SOLD
+		repo.store.data.productSeats[key] = s
+		sold++
+	}
+
+	return sold, nil
+}
+
+func (repo *memorySeatRepository) ReleaseSeats(ctx context.Context, transactionID int64) (released int64, err error) {
+	defer repo.store.lock(ctx)()
+
+	for key, s := range repo.store.data.productSeats {
+		if s.TransactionID != transactionID || (s.Status != constans.HELD && s.Status != constans.SOLD) {
+			continue
+		}
+
+		s.Status = constans.AVAILABLE
+		s.TransactionID = 0
+		repo.store.data.productSeats[key] = s
+		released++
+	}
+
+	return released, nil
+}
@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memorySessionRepository struct {
+	store *Store
+}
+
+func NewSessionRepository(store *Store) repository.SessionRepository {
+	return &memorySessionRepository{
+		store: store,
+	}
+}
+
+func (repo *memorySessionRepository) Create(ctx context.Context, sessions []model.Session) error {
+	defer repo.store.lock(ctx)()
+
+	now := time.Now()
+	for _, session := range sessions {
+		session.ID = repo.store.nextID("session")
+		session.Stock = session.Capacity
+		session.CreatedAt = now
+		session.UpdatedAt = now
+		repo.store.data.sessions[session.ID] = session
+	}
+
+	return nil
+}
+
+func (repo *memorySessionRepository) ReadByProduct(ctx context.Context, productID int64) (response []model.Session, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, s := range repo.store.data.sessions {
+		if s.ProductID == productID {
+			response = append(response, s)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		if !response[i].StartDate.Equal(response[j].StartDate) {
+			return response[i].StartDate.Before(response[j].StartDate)
+		}
+		return response[i].ID < response[j].ID
+	})
+
+	return response, nil
+}
+
+func (repo *memorySessionRepository) ReadByID(ctx context.Context, sessionID int64) (*model.Session, error) {
+	defer repo.store.lock(ctx)()
+
+	s, ok := repo.store.data.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+// ReadByIDForUpdate needs no row lock, a unit of work already holds the store lock
+func (repo *memorySessionRepository) ReadByIDForUpdate(ctx context.Context, sessionID int64) (*model.Session, error) {
+	return repo.ReadByID(ctx, sessionID)
+}
+
+func (repo *memorySessionRepository) Count(ctx context.Context, productID int64) (total int64, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, s := range repo.store.data.sessions {
+		if s.ProductID == productID {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+// Update changes the schedule and capacity of a session, the stock follows the
+// capacity change so tickets already sold stay accounted for
+func (repo *memorySessionRepository) Update(ctx context.Context, session model.Session) error {
+	defer repo.store.lock(ctx)()
+
+	s, ok := repo.store.data.sessions[session.ID]
+	if !ok {
+		return nil
+	}
+
+	s.StartDate = session.StartDate
+	s.EndDate = session.EndDate
+	s = resize(s, session.Capacity)
+	repo.store.data.sessions[s.ID] = s
+
+	return nil
+}
+
+func (repo *memorySessionRepository) UpdateFutureCapacity(ctx context.Context, productID int64, capacity int64, after time.Time) error {
+	defer repo.store.lock(ctx)()
+
+	for id, s := range repo.store.data.sessions {
+		if s.ProductID == productID && s.StartDate.After(after) {
+			repo.store.data.sessions[id] = resize(s, capacity)
+		}
+	}
+
+	return nil
+}
+
+func (repo *memorySessionRepository) Delete(ctx context.Context, sessionID int64) error {
+	defer repo.store.lock(ctx)()
+
+	delete(repo.store.data.sessions, sessionID)
+
+	return nil
+}
+
+func (repo *memorySessionRepository) UpdateStock(ctx context.Context, sessionID int64, newStock int64) error {
+	defer repo.store.lock(ctx)()
+
+	s, ok := repo.store.data.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+
+	s.Stock += newStock
+	s.UpdatedAt = time.Now()
+	repo.store.data.sessions[sessionID] = s
+
+	return nil
+}
+
+func (repo *memorySessionRepository) TakeStock(ctx context.Context, sessionID int64, quantity int64) error {
+	defer repo.store.lock(ctx)()
+
+	s, ok := repo.store.data.sessions[sessionID]
+	if !ok || s.Stock < quantity {
+		return constans.ErrTicketRunOut
+	}
+
+	s.Stock -= quantity
+	s.UpdatedAt = time.Now()
+	repo.store.data.sessions[sessionID] = s
+
+	return nil
+}
+
+// resize sets the capacity of a session and moves its stock by the same amount, never below zero
+func resize(s model.Session, capacity int64) model.Session {
+	s.Stock += capacity - s.Capacity
+	if s.Stock < 0 {
+		s.Stock = 0
+	}
+
+	s.Capacity = capacity
+	s.UpdatedAt = time.Now()
+
+	return s
+}
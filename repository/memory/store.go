@@ -0,0 +1,285 @@
+// Package memory implements the repositories in process memory. It backs the
+// memory storage mode of the server, which needs no database and forgets
+// everything on restart, and follows the semantics of the SQL repositories.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type txKey struct{}
+
+// Store holds the records of every memory repository. A single lock guards all
+// of them, a unit of work keeps it until it ends so its changes are isolated.
+type Store struct {
+	mu   sync.Mutex
+	data *data
+	// ids are never given back by a rollback, like an auto increment column
+	ids map[string]int64
+}
+
+type data struct {
+	users           map[int64]model.User
+	products        map[int64]model.Product
+	transactions    map[int64]model.Transaction
+	categories      map[int64]model.Category
+	venues          map[int64]model.Venue
+	seats           map[int64]model.Seat
+	productSeats    map[productSeatKey]model.ProductSeat
+	sessions        map[int64]model.Session
+	waitlists       map[int64]model.Waitlist
+	vouchers        map[int64]model.Voucher
+	redemptions     map[int64]model.VoucherRedemption
+	exchangeRates   map[string]model.ExchangeRate
+	idempotencyKeys map[idempotencyKey]model.IdempotencyKey
+	events          map[int64]model.Event
+	webhooks        map[int64]model.Webhook
+	deliveries      map[int64]model.WebhookDelivery
+	jobs            map[int64]model.Job
+	reconciliations map[int64]model.Reconciliation
+	waitingRooms    map[int64]model.WaitingRoom
+	queueEntries    map[queueEntryKey]model.QueueEntry
+}
+
+type productSeatKey struct {
+	productID int64
+	seatID    int64
+}
+
+type queueEntryKey struct {
+	productID int64
+	userID    int64
+}
+
+type idempotencyKey struct {
+	scope string
+	key   string
+}
+
+func NewStore() *Store {
+	return &Store{
+		data: &data{
+			users:           map[int64]model.User{},
+			products:        map[int64]model.Product{},
+			transactions:    map[int64]model.Transaction{},
+			categories:      map[int64]model.Category{},
+			venues:          map[int64]model.Venue{},
+			seats:           map[int64]model.Seat{},
+			productSeats:    map[productSeatKey]model.ProductSeat{},
+			sessions:        map[int64]model.Session{},
+			waitlists:       map[int64]model.Waitlist{},
+			vouchers:        map[int64]model.Voucher{},
+			redemptions:     map[int64]model.VoucherRedemption{},
+			exchangeRates:   map[string]model.ExchangeRate{},
+			idempotencyKeys: map[idempotencyKey]model.IdempotencyKey{},
+			events:          map[int64]model.Event{},
+			webhooks:        map[int64]model.Webhook{},
+			deliveries:      map[int64]model.WebhookDelivery{},
+			jobs:            map[int64]model.Job{},
+			reconciliations: map[int64]model.Reconciliation{},
+			waitingRooms:    map[int64]model.WaitingRoom{},
+			queueEntries:    map[queueEntryKey]model.QueueEntry{},
+		},
+		ids: map[string]int64{},
+	}
+}
+
+// lock takes the store lock and returns its release, inside a unit of work of
+// the store the lock is already held and nothing is done
+func (s *Store) lock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) == s {
+		return func() {}
+	}
+
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// nextID returns the next id of a table, the lock must be held
+func (s *Store) nextID(table string) int64 {
+	s.ids[table]++
+	return s.ids[table]
+}
+
+// clone copies every table, the records themselves are never changed in place
+// so copying the maps is enough to restore them
+func (d *data) clone() *data {
+	c := &data{
+		users:           make(map[int64]model.User, len(d.users)),
+		products:        make(map[int64]model.Product, len(d.products)),
+		transactions:    make(map[int64]model.Transaction, len(d.transactions)),
+		categories:      make(map[int64]model.Category, len(d.categories)),
+		venues:          make(map[int64]model.Venue, len(d.venues)),
+		seats:           make(map[int64]model.Seat, len(d.seats)),
+		productSeats:    make(map[productSeatKey]model.ProductSeat, len(d.productSeats)),
+		sessions:        make(map[int64]model.Session, len(d.sessions)),
+		waitlists:       make(map[int64]model.Waitlist, len(d.waitlists)),
+		vouchers:        make(map[int64]model.Voucher, len(d.vouchers)),
+		redemptions:     make(map[int64]model.VoucherRedemption, len(d.redemptions)),
+		exchangeRates:   make(map[string]model.ExchangeRate, len(d.exchangeRates)),
+		idempotencyKeys: make(map[idempotencyKey]model.IdempotencyKey, len(d.idempotencyKeys)),
+		events:          make(map[int64]model.Event, len(d.events)),
+		webhooks:        make(map[int64]model.Webhook, len(d.webhooks)),
+		deliveries:      make(map[int64]model.WebhookDelivery, len(d.deliveries)),
+		jobs:            make(map[int64]model.Job, len(d.jobs)),
+		reconciliations: make(map[int64]model.Reconciliation, len(d.reconciliations)),
+		waitingRooms:    make(map[int64]model.WaitingRoom, len(d.waitingRooms)),
+		queueEntries:    make(map[queueEntryKey]model.QueueEntry, len(d.queueEntries)),
+	}
+
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+	for k, v := range d.products {
+		c.products[k] = v
+	}
+	for k, v := range d.transactions {
+		c.transactions[k] = v
+	}
+	for k, v := range d.categories {
+		c.categories[k] = v
+	}
+	for k, v := range d.venues {
+		c.venues[k] = v
+	}
+	for k, v := range d.seats {
+		c.seats[k] = v
+	}
+	for k, v := range d.productSeats {
+		c.productSeats[k] = v
+	}
+	for k, v := range d.sessions {
+		c.sessions[k] = v
+	}
+	for k, v := range d.waitlists {
+		c.waitlists[k] = v
+	}
+	for k, v := range d.vouchers {
+		c.vouchers[k] = v
+	}
+	for k, v := range d.redemptions {
+		c.redemptions[k] = v
+	}
+	for k, v := range d.exchangeRates {
+		c.exchangeRates[k] = v
+	}
+	for k, v := range d.idempotencyKeys {
+		c.idempotencyKeys[k] = v
+	}
+	for k, v := range d.events {
+		c.events[k] = v
+	}
+	for k, v := range d.webhooks {
+		c.webhooks[k] = v
+	}
+	for k, v := range d.deliveries {
+		c.deliveries[k] = v
+	}
+	for k, v := range d.jobs {
+		c.jobs[k] = v
+	}
+	for k, v := range d.reconciliations {
+		c.reconciliations[k] = v
+	}
+	for k, v := range d.waitingRooms {
+		c.waitingRooms[k] = v
+	}
+	for k, v := range d.queueEntries {
+		c.queueEntries[k] = v
+	}
+
+	return c
+}
+
+type memoryUnitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) repository.UnitOfWork {
+	return &memoryUnitOfWork{
+		store: store,
+	}
+}
+
+// Do holds the store lock while fn runs and restores the records it found when
+// fn fails. Nested calls join the unit of work already running.
+func (u *memoryUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) == u.store {
+		return fn(ctx)
+	}
+
+	ctx, committed := repository.WithCommitHooks(ctx)
+	if err := u.run(ctx, fn); err != nil {
+		return err
+	}
+
+	committed()
+	return nil
+}
+
+// run calls fn with the store lock held and restores the records when it fails
+func (u *memoryUnitOfWork) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	snapshot := u.store.data.clone()
+
+	if err := fn(context.WithValue(ctx, txKey{}, u.store)); err != nil {
+		u.store.data = snapshot
+		return err
+	}
+
+	return nil
+}
+
+// copyStrings and the other copy helpers keep the slices and pointers of a
+// stored record apart from the ones the caller holds
+
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	return append([]string{}, values...)
+}
+
+func copyBytes(value []byte) []byte {
+	if value == nil {
+		return nil
+	}
+
+	return append([]byte{}, value...)
+}
+
+func copyRaw(value json.RawMessage) json.RawMessage {
+	return json.RawMessage(copyBytes(value))
+}
+
+func copyTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+
+	t := *value
+	return &t
+}
+
+// distanceSphere is the distance in meters between two points, as ST_Distance_Sphere computes it
+func distanceSphere(lng1, lat1, lng2, lat2 float64) float64 {
+	const earthRadius = 6371008.8
+
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Pow(math.Sin(dLng/2), 2)
+
+	return earthRadius * 2 * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryTrxRepository struct {
+	store *Store
+}
+
+func NewTransactionRepository(store *Store) repository.TransactionRepository {
+	return &memoryTrxRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryTrxRepository) Create(ctx context.Context, request model.Transaction) (*model.Transaction, error) {
+	defer repo.store.lock(ctx)()
+
+	now := time.Now()
+	request.ID = repo.store.nextID("transaction")
+	request.CreatedAt = now
+	request.UpdatedAt = now
+
+	stored := request
+	stored.Exchange = copyExchange(request.Exchange)
+	repo.store.data.transactions[request.ID] = stored
+
+	return &request, nil
+}
+
+func (repo *memoryTrxRepository) Update(ctx context.Context, request model.Transaction) error {
+	defer repo.store.lock(ctx)()
+
+	transaction, ok := repo.store.data.transactions[request.ID]
+	if !ok {
+		return nil
+	}
+
+	transaction.PaymentURL = request.PaymentURL
+	transaction.UpdatedAt = time.Now()
+	repo.store.data.transactions[transaction.ID] = transaction
+
+	return nil
+}
+
+func (repo *memoryTrxRepository) UpdateStatus(ctx context.Context, transactionID int64, status string) error {
+	defer repo.store.lock(ctx)()
+
+	transaction, ok := repo.store.data.transactions[transactionID]
+	if !ok {
+		return nil
+	}
+
+	transaction.Status = status
+	transaction.UpdatedAt = time.Now()
+	repo.store.data.transactions[transactionID] = transaction
+
+	return nil
+}
+
+func (repo *memoryTrxRepository) ReadByID(ctx context.Context, transactionID int64) (*model.Transaction, error) {
+	defer repo.store.lock(ctx)()
+
+	transaction, ok := repo.store.data.transactions[transactionID]
+	if !ok {
+		return nil, constans.ErrNotFound
+	}
+
+	transaction = readTransaction(transaction)
+	return &transaction, nil
+}
+
+// ReadByIDForUpdate needs no row lock, a unit of work already holds the store lock
+func (repo *memoryTrxRepository) ReadByIDForUpdate(ctx context.Context, transactionID int64) (*model.Transaction, error) {
+	return repo.ReadByID(ctx, transactionID)
+}
+
+func (repo *memoryTrxRepository) CountByProduct(ctx context.Context, productID int64, status string) (total int64, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, transaction := range repo.store.data.transactions {
+		if transaction.ProductID == productID && transaction.Status == status {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (repo *memoryTrxRepository) CountUpcomingByProduct(ctx context.Context, productID int64, status string, now time.Time) (total int64, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, transaction := range repo.store.data.transactions {
+		if transaction.ProductID != productID || transaction.Status != status {
+			continue
+		}
+
+		if session, ok := repo.store.data.sessions[transaction.SessionID]; ok && !session.EndDate.After(now) {
+			continue
+		}
+
+		total++
+	}
+
+	return total, nil
+}
+
+func (repo *memoryTrxRepository) ReadUnreconciled(ctx context.Context, before time.Time, limit int) (response []model.Transaction, err error) {
+	defer repo.store.lock(ctx)()
+
+	unresolved := map[int64]bool{}
+	for _, r := range repo.store.data.reconciliations {
+		if r.Result == constans.MISMATCH || r.Result == constans.FAILED {
+			unresolved[r.TransactionID] = true
+		}
+	}
+
+	for _, transaction := range repo.store.data.transactions {
+		if transaction.Status == constans.PENDING && transaction.CreatedAt.Before(before) && !unresolved[transaction.ID] {
+			response = append(response, readTransaction(transaction))
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	if len(response) > limit {
+		response = response[:limit]
+	}
+
+	return response, nil
+}
+
+// readTransaction returns a copy of a stored transaction shaped like a scanned
+// row, the breakdown is in the currency of the amount and an empty snapshot is nil
+func readTransaction(transaction model.Transaction) model.Transaction {
+	transaction.Breakdown.Currency = transaction.Amount.Currency
+	transaction.Exchange = copyExchange(transaction.Exchange)
+
+	if transaction.Exchange != nil && transaction.Exchange.PriceRate == "" && transaction.Exchange.DisplayRate == "" {
+		transaction.Exchange = nil
+	}
+
+	return transaction
+}
+
+func copyExchange(exchange *model.ExchangeSnapshot) *model.ExchangeSnapshot {
+	if exchange == nil {
+		return nil
+	}
+
+	c := *exchange
+	if exchange.DisplayTotal != nil {
+		total := *exchange.DisplayTotal
+		c.DisplayTotal = &total
+	}
+
+	return &c
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryUserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &memoryUserRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryUserRepository) Create(ctx context.Context, request model.User) (*model.User, error) {
+	defer repo.store.lock(ctx)()
+
+	now := time.Now()
+	request.ID = repo.store.nextID("user")
+	request.CreatedAt = now
+	request.UpdatedAt = now
+	repo.store.data.users[request.ID] = request
+
+	return &request, nil
+}
+
+func (repo *memoryUserRepository) Read(ctx context.Context) (response []model.User, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, user := range repo.store.data.users {
+		response = append(response, user)
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	return response, nil
+}
+
+func (repo *memoryUserRepository) Update(ctx context.Context, request model.User) error {
+	defer repo.store.lock(ctx)()
+
+	user, ok := repo.store.data.users[request.ID]
+	if !ok {
+		return nil
+	}
+
+	user.Username = request.Username
+	user.Email = request.Email
+	user.Password = request.Password
+	user.Phone = request.Phone
+	user.Address = request.Address
+	user.Roles = request.Roles
+	user.UpdatedAt = time.Now()
+	repo.store.data.users[user.ID] = user
+
+	return nil
+}
+
+func (repo *memoryUserRepository) Delete(ctx context.Context, userid int64) error {
+	defer repo.store.lock(ctx)()
+
+	delete(repo.store.data.users, userid)
+
+	return nil
+}
+
+// ReadByID returns an empty user when there is none, like the SQL repository
+func (repo *memoryUserRepository) ReadByID(ctx context.Context, userid int64) (model.User, error) {
+	defer repo.store.lock(ctx)()
+
+	return repo.store.data.users[userid], nil
+}
+
+func (repo *memoryUserRepository) ReadByUsername(ctx context.Context, username string) (model.User, error) {
+	defer repo.store.lock(ctx)()
+
+	var found model.User
+	for _, user := range repo.store.data.users {
+		if user.Username == username && (found.ID == 0 || user.ID < found.ID) {
+			found = user
+		}
+	}
+
+	return found, nil
+}
+
+func (repo *memoryUserRepository) CountUser(ctx context.Context, request model.User) (total int32, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, user := range repo.store.data.users {
+		if user.Username == request.Username || user.Email == request.Email || user.Phone == request.Phone {
+			total++
+		}
+	}
+
+	return total, nil
+}
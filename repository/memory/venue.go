@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryVenueRepository struct {
+	store *Store
+}
+
+func NewVenueRepository(store *Store) repository.VenueRepository {
+	return &memoryVenueRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryVenueRepository) Create(ctx context.Context, request model.Venue) (*model.Venue, error) {
+	defer repo.store.lock(ctx)()
+
+	now := time.Now()
+	request.ID = repo.store.nextID("venue")
+	request.CreatedAt = now
+	request.UpdatedAt = now
+	repo.store.data.venues[request.ID] = request
+
+	return &request, nil
+}
+
+func (repo *memoryVenueRepository) Read(ctx context.Context) (response []model.Venue, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, v := range repo.store.data.venues {
+		response = append(response, v)
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	return response, nil
+}
+
+func (repo *memoryVenueRepository) ReadByID(ctx context.Context, venueID int64) (*model.Venue, error) {
+	defer repo.store.lock(ctx)()
+
+	v, ok := repo.store.data.venues[venueID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &v, nil
+}
+
+// CreateSeats adds all given seats or none of them, a duplicated seat rejects
+// the whole batch
+func (repo *memoryVenueRepository) CreateSeats(ctx context.Context, seats []model.Seat) error {
+	defer repo.store.lock(ctx)()
+
+	type seatKey struct {
+		venueID int64
+		section string
+		row     string
+		number  int64
+	}
+
+	taken := map[seatKey]bool{}
+	for _, s := range repo.store.data.seats {
+		taken[seatKey{s.VenueID, s.Section, s.Row, s.Number}] = true
+	}
+
+	for _, s := range seats {
+		key := seatKey{s.VenueID, s.Section, s.Row, s.Number}
+		if taken[key] {
+			return constans.ErrConflict
+		}
+		taken[key] = true
+	}
+
+	for _, s := range seats {
+		s.ID = repo.store.nextID("seat")
+		repo.store.data.seats[s.ID] = s
+	}
+
+	return nil
+}
+
+func (repo *memoryVenueRepository) ReadSeats(ctx context.Context, venueID int64) (response []model.Seat, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, s := range repo.store.data.seats {
+		if s.VenueID == venueID {
+			response = append(response, s)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return seatLess(response[i].Section, response[i].Row, response[i].Number, response[j].Section, response[j].Row, response[j].Number)
+	})
+
+	return response, nil
+}
+
+// seatLess orders seats by section, row and number
+func seatLess(section1, row1 string, number1 int64, section2, row2 string, number2 int64) bool {
+	if section1 != section2 {
+		return section1 < section2
+	}
+
+	if row1 != row2 {
+		return row1 < row2
+	}
+
+	return number1 < number2
+}
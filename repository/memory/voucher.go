@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryVoucherRepository struct {
+	store *Store
+}
+
+func NewVoucherRepository(store *Store) repository.VoucherRepository {
+	return &memoryVoucherRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryVoucherRepository) Create(ctx context.Context, request model.Voucher) (*model.Voucher, error) {
+	defer repo.store.lock(ctx)()
+
+	if repo.codeTaken(request.Code, 0) {
+		return nil, constans.ErrConflict
+	}
+
+	now := time.Now()
+	request.ID = repo.store.nextID("voucher")
+	request.Redeemed = 0
+	request.CreatedAt = now
+	request.UpdatedAt = now
+
+	stored := request
+	stored.ValidFrom = copyTime(request.ValidFrom)
+	stored.ValidUntil = copyTime(request.ValidUntil)
+	repo.store.data.vouchers[request.ID] = stored
+
+	return &request, nil
+}
+
+func (repo *memoryVoucherRepository) Read(ctx context.Context) (response []model.Voucher, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, v := range repo.store.data.vouchers {
+		response = append(response, readVoucher(v))
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID > response[j].ID
+	})
+
+	return response, nil
+}
+
+func (repo *memoryVoucherRepository) ReadByID(ctx context.Context, voucherID int64) (*model.Voucher, error) {
+	defer repo.store.lock(ctx)()
+
+	v, ok := repo.store.data.vouchers[voucherID]
+	if !ok {
+		return nil, nil
+	}
+
+	v = readVoucher(v)
+	return &v, nil
+}
+
+func (repo *memoryVoucherRepository) ReadByCode(ctx context.Context, code string) (*model.Voucher, error) {
+	defer repo.store.lock(ctx)()
+
+	for _, v := range repo.store.data.vouchers {
+		if v.Code == code {
+			v = readVoucher(v)
+			return &v, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repo *memoryVoucherRepository) Update(ctx context.Context, request model.Voucher) error {
+	defer repo.store.lock(ctx)()
+
+	v, ok := repo.store.data.vouchers[request.ID]
+	if !ok {
+		return nil
+	}
+
+	if repo.codeTaken(request.Code, request.ID) {
+		return constans.ErrConflict
+	}
+
+	v.Code = request.Code
+	v.Type = request.Type
+	v.Value = request.Value
+	v.ProductID = request.ProductID
+	v.Section = request.Section
+	v.ValidFrom = copyTime(request.ValidFrom)
+	v.ValidUntil = copyTime(request.ValidUntil)
+	v.MaxRedemptions = request.MaxRedemptions
+	v.MaxPerUser = request.MaxPerUser
+	v.UpdatedAt = time.Now()
+	repo.store.data.vouchers[v.ID] = v
+
+	return nil
+}
+
+// Delete removes a voucher which has never been redeemed
+func (repo *memoryVoucherRepository) Delete(ctx context.Context, voucherID int64) error {
+	defer repo.store.lock(ctx)()
+
+	v, ok := repo.store.data.vouchers[voucherID]
+	if !ok || v.Redeemed != 0 {
+		return constans.ErrConflict
+	}
+
+	delete(repo.store.data.vouchers, voucherID)
+
+	return nil
+}
+
+// Redeem reserves one redemption of a voucher, the store lock is held while the
+// global and per user limits are checked
+func (repo *memoryVoucherRepository) Redeem(ctx context.Context, redemption model.VoucherRedemption) error {
+	defer repo.store.lock(ctx)()
+
+	v, ok := repo.store.data.vouchers[redemption.VoucherID]
+	if !ok {
+		return constans.ErrVoucherInvalid
+	}
+
+	if v.MaxRedemptions > 0 && v.Redeemed >= v.MaxRedemptions {
+		return constans.ErrVoucherExhausted
+	}
+
+	if v.MaxPerUser > 0 {
+		var used int64
+		for _, r := range repo.store.data.redemptions {
+			if r.VoucherID == redemption.VoucherID && r.UserID == redemption.UserID && r.Status != constans.RELEASED {
+				used++
+			}
+		}
+
+		if used >= v.MaxPerUser {
+			return constans.ErrVoucherExhausted
+		}
+	}
+
+	for _, r := range repo.store.data.redemptions {
+		if r.TransactionID == redemption.TransactionID {
+			return constans.ErrConflict
+		}
+	}
+
+	redemption.ID = repo.store.nextID("voucher_redemption")
+	redemption.Status = constans.RESERVED
+	redemption.CreatedAt = time.Now()
+	repo.store.data.redemptions[redemption.ID] = redemption
+
+	v.Redeemed++
+	repo.store.data.vouchers[v.ID] = v
+
+	return nil
+}
+
+// UpdateRedemption moves the redemption of a transaction to the given status,
+// releasing a redemption gives it back to the voucher limits
+func (repo *memoryVoucherRepository) UpdateRedemption(ctx context.Context, transactionID int64, status string) error {
+	defer repo.store.lock(ctx)()
+
+	for id, r := range repo.store.data.redemptions {
+		if r.TransactionID != transactionID {
+			continue
+		}
+
+		if r.Status == status || r.Status == constans.RELEASED {
+			return nil
+		}
+
+		r.Status = status
+		repo.store.data.redemptions[id] = r
+
+		if v, ok := repo.store.data.vouchers[r.VoucherID]; ok && status == constans.RELEASED && v.Redeemed > 0 {
+			v.Redeemed--
+			repo.store.data.vouchers[v.ID] = v
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+func (repo *memoryVoucherRepository) ReadRedemptions(ctx context.Context, voucherID int64) (response []model.VoucherRedemption, err error) {
+	defer repo.store.lock(ctx)()
+
+	for _, r := range repo.store.data.redemptions {
+		if r.VoucherID == voucherID {
+			response = append(response, r)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	return response, nil
+}
+
+// codeTaken reports whether another voucher than exceptID uses the code
+func (repo *memoryVoucherRepository) codeTaken(code string, exceptID int64) bool {
+	for _, v := range repo.store.data.vouchers {
+		if v.Code == code && v.ID != exceptID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readVoucher(v model.Voucher) model.Voucher {
+	v.ValidFrom = copyTime(v.ValidFrom)
+	v.ValidUntil = copyTime(v.ValidUntil)
+
+	return v
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryWaitingRoomRepository struct {
+	store *Store
+}
+
+func NewWaitingRoomRepository(store *Store) repository.WaitingRoomRepository {
+	return &memoryWaitingRoomRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryWaitingRoomRepository) Create(ctx context.Context, room model.WaitingRoom) error {
+	defer repo.store.lock(ctx)()
+
+	if _, ok := repo.store.data.waitingRooms[room.ProductID]; ok {
+		return constans.ErrConflict
+	}
+
+	now := time.Now()
+	room.CreatedAt = now
+	room.UpdatedAt = now
+	repo.store.data.waitingRooms[room.ProductID] = room
+
+	return nil
+}
+
+func (repo *memoryWaitingRoomRepository) ReadByProduct(ctx context.Context, productID int64) (*model.WaitingRoom, error) {
+	defer repo.store.lock(ctx)()
+
+	room, ok := repo.store.data.waitingRooms[productID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &room, nil
+}
+
+// ReadByProductForUpdate needs no row lock, a unit of work holds the whole store
+func (repo *memoryWaitingRoomRepository) ReadByProductForUpdate(ctx context.Context, productID int64) (*model.WaitingRoom, error) {
+	return repo.ReadByProduct(ctx, productID)
+}
+
+func (repo *memoryWaitingRoomRepository) Update(ctx context.Context, room model.WaitingRoom) error {
+	defer repo.store.lock(ctx)()
+
+	stored, ok := repo.store.data.waitingRooms[room.ProductID]
+	if !ok {
+		return nil
+	}
+
+	room.CreatedAt = stored.CreatedAt
+	room.UpdatedAt = time.Now()
+	repo.store.data.waitingRooms[room.ProductID] = room
+
+	return nil
+}
+
+func (repo *memoryWaitingRoomRepository) Delete(ctx context.Context, productID int64) error {
+	defer repo.store.lock(ctx)()
+
+	for key := range repo.store.data.queueEntries {
+		if key.productID == productID {
+			delete(repo.store.data.queueEntries, key)
+		}
+	}
+	delete(repo.store.data.waitingRooms, productID)
+
+	return nil
+}
+
+func (repo *memoryWaitingRoomRepository) CreateEntry(ctx context.Context, entry model.QueueEntry) error {
+	defer repo.store.lock(ctx)()
+
+	key := queueEntryKey{productID: entry.ProductID, userID: entry.UserID}
+	if _, ok := repo.store.data.queueEntries[key]; ok || repo.positionTaken(entry) {
+		return constans.ErrConflict
+	}
+
+	entry.AdmittedAt = nil
+	entry.CreatedAt = time.Now()
+	repo.store.data.queueEntries[key] = entry
+
+	return nil
+}
+
+func (repo *memoryWaitingRoomRepository) ReadEntry(ctx context.Context, productID int64, userID int64) (*model.QueueEntry, error) {
+	defer repo.store.lock(ctx)()
+
+	entry, ok := repo.store.data.queueEntries[queueEntryKey{productID: productID, userID: userID}]
+	if !ok {
+		return nil, nil
+	}
+
+	entry.AdmittedAt = copyTime(entry.AdmittedAt)
+	return &entry, nil
+}
+
+func (repo *memoryWaitingRoomRepository) Requeue(ctx context.Context, entry model.QueueEntry) error {
+	defer repo.store.lock(ctx)()
+
+	key := queueEntryKey{productID: entry.ProductID, userID: entry.UserID}
+	stored, ok := repo.store.data.queueEntries[key]
+	if !ok {
+		return nil
+	}
+
+	if repo.positionTaken(entry) {
+		return constans.ErrConflict
+	}
+
+	stored.Position = entry.Position
+	stored.AdmittedAt = nil
+	repo.store.data.queueEntries[key] = stored
+
+	return nil
+}
+
+func (repo *memoryWaitingRoomRepository) Admit(ctx context.Context, productID int64, userID int64, at time.Time) (bool, error) {
+	defer repo.store.lock(ctx)()
+
+	key := queueEntryKey{productID: productID, userID: userID}
+	entry, ok := repo.store.data.queueEntries[key]
+	if !ok || entry.AdmittedAt != nil {
+		return false, nil
+	}
+
+	entry.AdmittedAt = &at
+	repo.store.data.queueEntries[key] = entry
+
+	return true, nil
+}
+
+// positionTaken reports whether another user than the one of entry holds its position
+func (repo *memoryWaitingRoomRepository) positionTaken(entry model.QueueEntry) bool {
+	for key, e := range repo.store.data.queueEntries {
+		if key.productID == entry.ProductID && key.userID != entry.UserID && e.Position == entry.Position {
+			return true
+		}
+	}
+
+	return false
+}
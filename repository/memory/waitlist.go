@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryWaitlistRepository struct {
+	store *Store
+}
+
+func NewWaitlistRepository(store *Store) repository.WaitlistRepository {
+	return &memoryWaitlistRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryWaitlistRepository) Create(ctx context.Context, request model.Waitlist) (*model.Waitlist, error) {
+	defer repo.store.lock(ctx)()
+
+	now := time.Now()
+	request.ID = repo.store.nextID("waitlist")
+	request.OfferExpiresAt = nil
+	request.CreatedAt = now
+	request.UpdatedAt = now
+	repo.store.data.waitlists[request.ID] = request
+
+	return &request, nil
+}
+
+// ReadActive returns the waiting or offered entry of a user for a product session
+func (repo *memoryWaitlistRepository) ReadActive(ctx context.Context, productID int64, sessionID int64, userID int64) (*model.Waitlist, error) {
+	entries := repo.query(ctx, func(w model.Waitlist) bool {
+		return w.ProductID == productID && w.SessionID == sessionID && w.UserID == userID && (w.Status == constans.WAITING || w.Status == constans.OFFERED)
+	})
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return &entries[0], nil
+}
+
+func (repo *memoryWaitlistRepository) Position(ctx context.Context, waitlist model.Waitlist) (int64, error) {
+	entries := repo.query(ctx, func(w model.Waitlist) bool {
+		return w.ProductID == waitlist.ProductID && w.SessionID == waitlist.SessionID && w.Status == constans.WAITING && w.ID <= waitlist.ID
+	})
+
+	return int64(len(entries)), nil
+}
+
+// ReadNext returns the oldest waiting entries of a product session, first in first out
+func (repo *memoryWaitlistRepository) ReadNext(ctx context.Context, productID int64, sessionID int64, limit int64) ([]model.Waitlist, error) {
+	entries := repo.query(ctx, func(w model.Waitlist) bool {
+		return w.ProductID == productID && w.SessionID == sessionID && w.Status == constans.WAITING
+	})
+
+	if int64(len(entries)) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+func (repo *memoryWaitlistRepository) ReadExpiredOffers(ctx context.Context, now time.Time) ([]model.Waitlist, error) {
+	return repo.query(ctx, func(w model.Waitlist) bool {
+		return w.Status == constans.OFFERED && w.OfferExpiresAt != nil && w.OfferExpiresAt.Before(now)
+	}), nil
+}
+
+func (repo *memoryWaitlistRepository) CountActiveOffers(ctx context.Context, productID int64, sessionID int64, exceptUserID int64, now time.Time) (int64, error) {
+	entries := repo.query(ctx, func(w model.Waitlist) bool {
+		return w.ProductID == productID && w.SessionID == sessionID && w.Status == constans.OFFERED && w.OfferExpiresAt != nil && !w.OfferExpiresAt.Before(now) && w.UserID != exceptUserID
+	})
+
+	return int64(len(entries)), nil
+}
+
+// Offer gives a waiting entry its purchase window, it reports false when the
+// entry was not waiting anymore
+func (repo *memoryWaitlistRepository) Offer(ctx context.Context, waitlistID int64, expiresAt time.Time) (bool, error) {
+	defer repo.store.lock(ctx)()
+
+	w, ok := repo.store.data.waitlists[waitlistID]
+	if !ok || w.Status != constans.WAITING {
+		return false, nil
+	}
+
+	w.Status = constans.OFFERED
+	w.OfferExpiresAt = &expiresAt
+	w.UpdatedAt = time.Now()
+	repo.store.data.waitlists[waitlistID] = w
+
+	return true, nil
+}
+
+func (repo *memoryWaitlistRepository) UpdateStatus(ctx context.Context, waitlistID int64, from string, to string) (bool, error) {
+	defer repo.store.lock(ctx)()
+
+	w, ok := repo.store.data.waitlists[waitlistID]
+	if !ok || w.Status != from {
+		return false, nil
+	}
+
+	w.Status = to
+	w.UpdatedAt = time.Now()
+	repo.store.data.waitlists[waitlistID] = w
+
+	return true, nil
+}
+
+// query returns the entries matching a condition, oldest first
+func (repo *memoryWaitlistRepository) query(ctx context.Context, match func(model.Waitlist) bool) (response []model.Waitlist) {
+	defer repo.store.lock(ctx)()
+
+	for _, w := range repo.store.data.waitlists {
+		if match(w) {
+			w.OfferExpiresAt = copyTime(w.OfferExpiresAt)
+			response = append(response, w)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	return response
+}
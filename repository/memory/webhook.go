@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+)
+
+type memoryWebhookRepository struct {
+	store *Store
+}
+
+func NewWebhookRepository(store *Store) repository.WebhookRepository {
+	return &memoryWebhookRepository{
+		store: store,
+	}
+}
+
+func (repo *memoryWebhookRepository) Create(ctx context.Context, request model.Webhook) (*model.Webhook, error) {
+	defer repo.store.lock(ctx)()
+
+	now := time.Now()
+	request.ID = repo.store.nextID("webhook")
+	request.Active = true
+	request.FailureCount = 0
+	request.DisabledAt = nil
+	request.CreatedAt = now
+	request.UpdatedAt = now
+
+	stored := request
+	stored.EventTypes = copyStrings(request.EventTypes)
+	repo.store.data.webhooks[request.ID] = stored
+
+	return &request, nil
+}
+
+func (repo *memoryWebhookRepository) Read(ctx context.Context) ([]model.Webhook, error) {
+	return repo.query(ctx, func(model.Webhook) bool {
+		return true
+	}), nil
+}
+
+func (repo *memoryWebhookRepository) ReadByID(ctx context.Context, webhookID int64) (*model.Webhook, error) {
+	defer repo.store.lock(ctx)()
+
+	w, ok := repo.store.data.webhooks[webhookID]
+	if !ok {
+		return nil, nil
+	}
+
+	w = readWebhook(w)
+	return &w, nil
+}
+
+func (repo *memoryWebhookRepository) ReadSubscribed(ctx context.Context, eventType string) ([]model.Webhook, error) {
+	return repo.query(ctx, func(w model.Webhook) bool {
+		if !w.Active {
+			return false
+		}
+
+		for _, t := range w.EventTypes {
+			if t == eventType {
+				return true
+			}
+		}
+
+		return false
+	}), nil
+}
+
+func (repo *memoryWebhookRepository) query(ctx context.Context, match func(model.Webhook) bool) (response []model.Webhook) {
+	defer repo.store.lock(ctx)()
+
+	for _, w := range repo.store.data.webhooks {
+		if match(w) {
+			response = append(response, readWebhook(w))
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	return response
+}
+
+func (repo *memoryWebhookRepository) Update(ctx context.Context, request model.Webhook) error {
+	return repo.update(ctx, request.ID, func(w *model.Webhook) {
+		w.URL = request.URL
+		w.EventTypes = copyStrings(request.EventTypes)
+		w.Secret = request.Secret
+	})
+}
+
+// Delete removes a webhook with its delivery log
+func (repo *memoryWebhookRepository) Delete(ctx context.Context, webhookID int64) error {
+	defer repo.store.lock(ctx)()
+
+	for id, d := range repo.store.data.deliveries {
+		if d.WebhookID == webhookID {
+			delete(repo.store.data.deliveries, id)
+		}
+	}
+
+	delete(repo.store.data.webhooks, webhookID)
+
+	return nil
+}
+
+func (repo *memoryWebhookRepository) Enable(ctx context.Context, webhookID int64) error {
+	return repo.update(ctx, webhookID, func(w *model.Webhook) {
+		w.Active = true
+		w.FailureCount = 0
+		w.DisabledAt = nil
+	})
+}
+
+func (repo *memoryWebhookRepository) Disable(ctx context.Context, webhookID int64, disabledAt time.Time) error {
+	return repo.update(ctx, webhookID, func(w *model.Webhook) {
+		w.Active = false
+		if w.DisabledAt == nil {
+			w.DisabledAt = &disabledAt
+		}
+	})
+}
+
+func (repo *memoryWebhookRepository) ResetFailures(ctx context.Context, webhookID int64) error {
+	return repo.update(ctx, webhookID, func(w *model.Webhook) {
+		w.FailureCount = 0
+	})
+}
+
+func (repo *memoryWebhookRepository) RecordFailure(ctx context.Context, webhookID int64) (int64, error) {
+	defer repo.store.lock(ctx)()
+
+	w, ok := repo.store.data.webhooks[webhookID]
+	if !ok {
+		return 0, constans.ErrNotFound
+	}
+
+	w.FailureCount++
+	w.UpdatedAt = time.Now()
+	repo.store.data.webhooks[webhookID] = w
+
+	return w.FailureCount, nil
+}
+
+// update applies fn to a copy of a stored webhook and stores it back
+func (repo *memoryWebhookRepository) update(ctx context.Context, webhookID int64, fn func(w *model.Webhook)) error {
+	defer repo.store.lock(ctx)()
+
+	w, ok := repo.store.data.webhooks[webhookID]
+	if !ok {
+		return nil
+	}
+
+	fn(&w)
+	w.UpdatedAt = time.Now()
+	repo.store.data.webhooks[webhookID] = w
+
+	return nil
+}
+
+func (repo *memoryWebhookRepository) CreateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	defer repo.store.lock(ctx)()
+
+	for _, d := range repo.store.data.deliveries {
+		if d.WebhookID == delivery.WebhookID && d.EventID == delivery.EventID {
+			return nil
+		}
+	}
+
+	now := time.Now()
+	id := repo.store.nextID("webhook_delivery")
+	repo.store.data.deliveries[id] = model.WebhookDelivery{
+		ID:            id,
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        constans.PENDING,
+		NextAttemptAt: delivery.NextAttemptAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	return nil
+}
+
+func (repo *memoryWebhookRepository) ReadDelivery(ctx context.Context, deliveryID int64) (*model.WebhookDelivery, error) {
+	defer repo.store.lock(ctx)()
+
+	d, ok := repo.store.data.deliveries[deliveryID]
+	if !ok {
+		return nil, nil
+	}
+
+	d = readDelivery(d)
+	return &d, nil
+}
+
+func (repo *memoryWebhookRepository) ReadDeliveries(ctx context.Context, webhookID int64, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := repo.queryDeliveries(ctx, func(d model.WebhookDelivery) bool {
+		return d.WebhookID == webhookID
+	})
+
+	// newest first
+	for i, j := 0, len(deliveries)-1; i < j; i, j = i+1, j-1 {
+		deliveries[i], deliveries[j] = deliveries[j], deliveries[i]
+	}
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDeliveries takes the oldest due deliveries, the store lock keeps
+// concurrent senders from taking the same delivery
+func (repo *memoryWebhookRepository) ClaimDeliveries(ctx context.Context, token string, now time.Time, lockedUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := repo.queryDeliveries(ctx, func(d model.WebhookDelivery) bool {
+		due := d.Status == constans.PENDING && !d.NextAttemptAt.After(now)
+		expired := d.Status == constans.SENDING && d.LockedUntil != nil && d.LockedUntil.Before(now)
+		return (due || expired) && repo.store.data.webhooks[d.WebhookID].Active
+	})
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	defer repo.store.lock(ctx)()
+
+	for i, d := range deliveries {
+		stored, ok := repo.store.data.deliveries[d.ID]
+		// claimed by someone else in between
+		if !ok || stored.Status != d.Status || stored.LeaseToken != d.LeaseToken {
+			continue
+		}
+
+		stored.Status = constans.SENDING
+		stored.LeaseToken = token
+		stored.LockedUntil = &lockedUntil
+		stored.UpdatedAt = time.Now()
+		repo.store.data.deliveries[d.ID] = stored
+
+		deliveries[i] = readDelivery(stored)
+	}
+
+	return repo.claimed(deliveries, token), nil
+}
+
+// claimed keeps the deliveries claimed with token
+func (repo *memoryWebhookRepository) claimed(deliveries []model.WebhookDelivery, token string) (response []model.WebhookDelivery) {
+	for _, d := range deliveries {
+		if d.LeaseToken == token {
+			response = append(response, d)
+		}
+	}
+
+	return response
+}
+
+// queryDeliveries returns the deliveries matching a condition, oldest first
+func (repo *memoryWebhookRepository) queryDeliveries(ctx context.Context, match func(model.WebhookDelivery) bool) (response []model.WebhookDelivery) {
+	defer repo.store.lock(ctx)()
+
+	for _, d := range repo.store.data.deliveries {
+		if match(d) {
+			response = append(response, readDelivery(d))
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+
+	return response
+}
+
+func (repo *memoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	defer repo.store.lock(ctx)()
+
+	d, ok := repo.store.data.deliveries[delivery.ID]
+	if !ok || d.LeaseToken != delivery.LeaseToken {
+		return nil
+	}
+
+	d.Status = delivery.Status
+	d.Attempts = delivery.Attempts
+	d.ResponseCode = delivery.ResponseCode
+	d.LastError = delivery.LastError
+	d.NextAttemptAt = delivery.NextAttemptAt
+	d.DeliveredAt = copyTime(delivery.DeliveredAt)
+	d.LeaseToken = ""
+	d.LockedUntil = nil
+	d.UpdatedAt = time.Now()
+	repo.store.data.deliveries[d.ID] = d
+
+	return nil
+}
+
+func readDelivery(d model.WebhookDelivery) model.WebhookDelivery {
+	d.LockedUntil = copyTime(d.LockedUntil)
+	d.DeliveredAt = copyTime(d.DeliveredAt)
+	return d
+}
+
+func readWebhook(w model.Webhook) model.Webhook {
+	w.EventTypes = copyStrings(w.EventTypes)
+	w.DisabledAt = copyTime(w.DisabledAt)
+
+	return w
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertOutboxEvent = `INSERT INTO outbox_event (type, aggregate_id, payload, status, next_attempt_at) VALUES (?,?,?,?,?)`
+	claimEvents       = `UPDATE outbox_event SET status=?, lease_token=?, locked_until=?
+		WHERE (status=? AND next_attempt_at <= ?) OR (status=? AND locked_until < ?) ORDER BY id LIMIT ?`
+	readEventsByLease = `SELECT id, type, aggregate_id, payload, status, attempts, last_error, next_attempt_at, COALESCE(lease_token, ''), locked_until,
+		created_at, dispatched_at FROM outbox_event WHERE lease_token=? ORDER BY id`
+	markEventDispatched = `UPDATE outbox_event SET status=?, attempts=attempts+1, last_error='', lease_token=NULL, locked_until=NULL, dispatched_at=?
+		WHERE id=? AND lease_token=?`
+	markEventFailed = `UPDATE outbox_event SET status=?, attempts=?, last_error=?, next_attempt_at=?, lease_token=NULL, locked_until=NULL
+		WHERE id=? AND lease_token=?`
+	readRecentEvents = `SELECT id, type, aggregate_id, payload, status, attempts, last_error, next_attempt_at, COALESCE(lease_token, ''), locked_until,
+		created_at, dispatched_at FROM outbox_event WHERE created_at >= ? AND type IN (%s) ORDER BY id DESC LIMIT ?`
+)
+
+type OutboxRepository interface {
+	// Create stores an event, inside a unit of work it is committed together with the state change
+	Create(ctx context.Context, event model.Event) error
+	// Claim takes up to limit due events, and events whose lease expired, and
+	// hides them from other instances until lockedUntil
+	Claim(ctx context.Context, token string, now time.Time, lockedUntil time.Time, limit int) ([]model.Event, error)
+	// MarkDispatched marks a claimed event as dispatched, it is ignored when the lease was lost
+	MarkDispatched(ctx context.Context, event model.Event, dispatchedAt time.Time) error
+	// MarkFailed records a failed delivery of a claimed event, the event is retried
+	// at event.NextAttemptAt while its status is pending
+	MarkFailed(ctx context.Context, event model.Event) error
+	// ReadRecent returns the newest limit events of the given types recorded
+	// since, whatever their status, oldest first
+	ReadRecent(ctx context.Context, since time.Time, types []string, limit int) ([]model.Event, error)
+}
+
+type mysqlOutboxRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewOutboxRepository(db *sql.DB, dialect Dialect) OutboxRepository {
+	return &mysqlOutboxRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func (repo *mysqlOutboxRepository) Create(ctx context.Context, event model.Event) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(insertOutboxEvent))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, event.Type, event.AggregateID, []byte(event.Payload), constans.PENDING, event.NextAttemptAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Claim leases a batch with a single update, so concurrent instances never
+// take the same event, and reads it back by its lease token
+func (repo *mysqlOutboxRepository) Claim(ctx context.Context, token string, now time.Time, lockedUntil time.Time, limit int) ([]model.Event, error) {
+	result, err := conn(ctx, repo.db).ExecContext(ctx, repo.dialect.Query(claimEvents),
+		constans.DISPATCHING, token, lockedUntil,
+		constans.PENDING, now,
+		constans.DISPATCHING, now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, nil
+	}
+
+	return repo.queryEvents(ctx, readEventsByLease, token)
+}
+
+func (repo *mysqlOutboxRepository) ReadRecent(ctx context.Context, since time.Time, types []string, limit int) ([]model.Event, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{since}
+	for _, t := range types {
+		args = append(args, t)
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(readRecentEvents, strings.TrimSuffix(strings.Repeat("?,", len(types)), ","))
+
+	events, err := repo.queryEvents(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+
+	return events, nil
+}
+
+func (repo *mysqlOutboxRepository) queryEvents(ctx context.Context, query string, args ...interface{}) (response []model.Event, err error) {
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			e            model.Event
+			payload      []byte
+			lockedUntil  sql.NullTime
+			dispatchedAt sql.NullTime
+		)
+
+		err = rows.Scan(
+			&e.ID,
+			&e.Type,
+			&e.AggregateID,
+			&payload,
+			&e.Status,
+			&e.Attempts,
+			&e.LastError,
+			&e.NextAttemptAt,
+			&e.LeaseToken,
+			&lockedUntil,
+			&e.CreatedAt,
+			&dispatchedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		e.Payload = payload
+		if lockedUntil.Valid {
+			e.LockedUntil = &lockedUntil.Time
+		}
+		if dispatchedAt.Valid {
+			e.DispatchedAt = &dispatchedAt.Time
+		}
+
+		response = append(response, e)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlOutboxRepository) MarkDispatched(ctx context.Context, event model.Event, dispatchedAt time.Time) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(markEventDispatched))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, constans.DISPATCHED, dispatchedAt, event.ID, event.LeaseToken)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlOutboxRepository) MarkFailed(ctx context.Context, event model.Event) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(markEventFailed))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, event.Status, event.Attempts, event.LastError, event.NextAttemptAt, event.ID, event.LeaseToken)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,159 +3,390 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"sort"
+	"strings"
 
+	"github.com/cecepsprd/ticketing-api/constans"
 	"github.com/cecepsprd/ticketing-api/model"
 )
 
 var (
-	insertProduct   = `INSERT INTO product (name, description, price, stock, image_url, start_date, end_date) VALUES (?,?,?,?,?,?,?)`
-	updateProduct   = `UPDATE product SET (name, description, price, stock, image_url, start_date, end_date) VALUES (?,?,?,?,?,?,?)`
-	readAllProduct  = `SELECT id, name, description, price, stock, image_url, start_date, end_date, created_at, updated_at FROM product`
-	deleteProduct   = `DELETE FROM product WHERE id = ?`
-	readProductByID = `SELECT id, name, description, price, stock, image_url, start_date, end_date, created_at, updated_at FROM product WHERE id=?`
-	updateStock     = `UPDATE product SET stock=(stock+?) WHERE id=?`
+	insertProduct = `INSERT INTO product (name, description, price, currency, stock, image_url, start_date, end_date,
+		category_id, organizer_name, organizer_contact, location, address, latitude, longitude) VALUES (?,?,?,?,?,?,?,?,NULLIF(?, 0),?,?,?,?,?,?)`
+	updateProduct = `UPDATE product SET name=?, description=?, price=?, currency=?, image_url=?, start_date=?, end_date=?,
+		category_id=NULLIF(?, 0), organizer_name=?, organizer_contact=?, location=?, address=?, latitude=?, longitude=? WHERE id=?`
+	selectProduct = `SELECT id, name, description, price, currency, stock, image_url, start_date, end_date,
+		COALESCE(category_id, 0), organizer_name, organizer_contact, location, address, latitude, longitude,
+		COALESCE((SELECT GROUP_CONCAT(tag ORDER BY tag) FROM product_tag WHERE product_tag.product_id = product.id), ''),
+		created_at, updated_at, deleted_at FROM product`
+	readAllProduct    = selectProduct
+	deleteProduct     = `UPDATE product SET deleted_at=CURRENT_TIMESTAMP WHERE id=? AND deleted_at IS NULL`
+	restoreProduct    = `UPDATE product SET deleted_at=NULL WHERE id=? AND deleted_at IS NOT NULL`
+	readProductByID   = selectProduct + ` WHERE id=?`
+	readProductByName = selectProduct + ` WHERE name=? AND deleted_at IS NULL ORDER BY id LIMIT 1`
+	lockProduct       = `SELECT id FROM product WHERE id=? FOR UPDATE`
+	updateStock       = `UPDATE product SET stock=(stock+?) WHERE id=?`
+	takeStock         = `UPDATE product SET stock=(stock-?) WHERE id=? AND stock>=?`
+	insertProductTag  = `INSERT INTO product_tag (product_id, tag) VALUES (?,?)`
+	deleteProductTag  = `DELETE FROM product_tag WHERE product_id=?`
 )
 
 type ProductRepository interface {
 	Create(ctx context.Context, product model.Product) error
-	Read(context.Context) ([]model.Product, error)
+	Read(ctx context.Context, filter model.ProductFilter) ([]model.Product, error)
 	Update(ctx context.Context, product model.Product) error
 	Delete(ctx context.Context, productID int64) error
+	// ReadByID also returns soft deleted products, so transactions keep resolving their product
 	ReadByID(ctx context.Context, id int64) (*model.Product, error)
+	// ReadByIDForUpdate reads a product and locks it until the unit of work ends
+	ReadByIDForUpdate(ctx context.Context, id int64) (*model.Product, error)
+	// ReadByName returns the oldest product with the given name that is not deleted
+	ReadByName(ctx context.Context, name string) (*model.Product, error)
+	Restore(ctx context.Context, productID int64) error
 	UpdateStock(ctx context.Context, productID int64, newStock int64) error
+	// TakeStock takes quantity tickets off the stock, failing with ErrTicketRunOut
+	// rather than letting the stock go below zero
+	TakeStock(ctx context.Context, productID int64, quantity int64) error
 }
 
 type mysqlProductRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	dialect  Dialect
+	replicas *Replicas
 }
 
-func NewProductRepository(db *sql.DB) ProductRepository {
+func NewProductRepository(db *sql.DB, dialect Dialect, replicas *Replicas) ProductRepository {
 	return &mysqlProductRepository{
-		db: db,
+		db:       db,
+		dialect:  dialect,
+		replicas: replicas,
 	}
 }
 
 func (repo *mysqlProductRepository) Create(ctx context.Context, request model.Product) error {
-	stmt, err := repo.db.PrepareContext(ctx, insertProduct)
+	tx, err := beginTx(ctx, repo.db)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	_, err = stmt.ExecContext(
+	productID, err := repo.dialect.insert(
 		ctx,
+		tx,
+		insertProduct,
 		request.Name,
 		request.Description,
-		request.Price,
+		request.Price.Amount,
+		request.Price.Currency,
 		request.Stock,
 		request.ImageURL,
 		request.StartDate,
 		request.EndDate,
+		request.CategoryID,
+		request.OrganizerName,
+		request.OrganizerContact,
+		request.Location,
+		request.Address,
+		request.Latitude,
+		request.Longitude,
 	)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if err = repo.replaceTags(ctx, tx, productID, request.Tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (repo *mysqlProductRepository) Read(ctx context.Context) (response []model.Product, err error) {
-	rows, err := repo.db.QueryContext(ctx, readAllProduct)
+func (repo *mysqlProductRepository) Read(ctx context.Context, filter model.ProductFilter) (response []model.Product, err error) {
+	query, args := buildProductFilter(repo.dialect, readAllProduct, filter)
+
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(query), args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var p model.Product
-		err = rows.Scan(
-			&p.ID,
-			&p.Name,
-			&p.Description,
-			&p.Price,
-			&p.Stock,
-			&p.ImageURL,
-			&p.StartDate,
-			&p.EndDate,
-			&p.CreatedAt,
-			&p.UpdatedAt,
-		)
+		p, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
-		response = append(response, p)
+		response = append(response, *p)
 	}
 
 	return response, nil
 }
 
 func (repo *mysqlProductRepository) Update(ctx context.Context, request model.Product) error {
-	stmt, err := repo.db.PrepareContext(ctx, updateProduct)
+	tx, err := beginTx(ctx, repo.db)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	_, err = stmt.ExecContext(
+	_, err = tx.ExecContext(
 		ctx,
+		repo.dialect.Query(updateProduct),
 		request.Name,
 		request.Description,
-		request.Price,
-		request.Stock,
+		request.Price.Amount,
+		request.Price.Currency,
 		request.ImageURL,
 		request.StartDate,
 		request.EndDate,
+		request.CategoryID,
+		request.OrganizerName,
+		request.OrganizerContact,
+		request.Location,
+		request.Address,
+		request.Latitude,
+		request.Longitude,
+		request.ID,
 	)
 	if err != nil {
 		return err
 	}
 
+	if err = repo.replaceTags(ctx, tx, request.ID, request.Tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete soft deletes a product by setting its deleted_at
+func (repo *mysqlProductRepository) Delete(ctx context.Context, productID int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(deleteProduct))
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return constans.ErrNotFound
+	}
+
 	return nil
 }
 
-func (repo *mysqlProductRepository) Delete(ctx context.Context, userid int64) error {
-	stmt, err := repo.db.PrepareContext(ctx, deleteProduct)
+func (repo *mysqlProductRepository) Restore(ctx context.Context, productID int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(restoreProduct))
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.ExecContext(ctx, userid)
+	result, err := stmt.ExecContext(ctx, productID)
 	if err != nil {
 		return err
 	}
 
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return constans.ErrNotFound
+	}
+
 	return nil
 }
 
+func (m *mysqlProductRepository) ReadByIDForUpdate(ctx context.Context, productID int64) (*model.Product, error) {
+	var id int64
+	err := conn(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(lockProduct), productID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ReadByID(ctx, productID)
+}
+
 func (m *mysqlProductRepository) ReadByID(ctx context.Context, productID int64) (*model.Product, error) {
-	var p model.Product
-	err := m.db.QueryRowContext(ctx, readProductByID, productID).Scan(
+	p, err := scanProduct(m.replicas.reader(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(readProductByID), productID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (m *mysqlProductRepository) ReadByName(ctx context.Context, name string) (*model.Product, error) {
+	p, err := scanProduct(m.replicas.reader(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(readProductByName), name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (repo *mysqlProductRepository) UpdateStock(ctx context.Context, productID int64, newStock int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateStock))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, newStock, productID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlProductRepository) TakeStock(ctx context.Context, productID int64, quantity int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(takeStock))
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, quantity, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return constans.ErrTicketRunOut
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProduct(row scanner) (*model.Product, error) {
+	var (
+		p         model.Product
+		tags      string
+		deletedAt sql.NullTime
+	)
+
+	err := row.Scan(
 		&p.ID,
 		&p.Name,
 		&p.Description,
-		&p.Price,
+		&p.Price.Amount,
+		&p.Price.Currency,
 		&p.Stock,
 		&p.ImageURL,
 		&p.StartDate,
 		&p.EndDate,
+		&p.CategoryID,
+		&p.OrganizerName,
+		&p.OrganizerContact,
+		&p.Location,
+		&p.Address,
+		&p.Latitude,
+		&p.Longitude,
+		&tags,
 		&p.CreatedAt,
 		&p.UpdatedAt,
+		&deletedAt,
 	)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, nil
+	if err != nil {
+		return nil, err
+	}
+
+	if tags != "" {
+		p.Tags = strings.Split(tags, ",")
+		sort.Strings(p.Tags)
+	}
+
+	if deletedAt.Valid {
+		p.DeletedAt = &deletedAt.Time
 	}
 
 	return &p, nil
 }
 
-func (repo *mysqlProductRepository) UpdateStock(ctx context.Context, productID int64, newStock int64) error {
-	stmt, err := repo.db.PrepareContext(ctx, updateStock)
-	if err != nil {
+// replaceTags drops every tag of a product and inserts the given ones
+func (repo *mysqlProductRepository) replaceTags(ctx context.Context, tx executor, productID interface{}, tags []string) error {
+	if _, err := tx.ExecContext(ctx, repo.dialect.Query(deleteProductTag), productID); err != nil {
 		return err
 	}
 
-	_, err = stmt.ExecContext(ctx, newStock, productID)
-	if err != nil {
-		return err
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, repo.dialect.Query(insertProductTag), productID, tag); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// buildProductFilter appends the WHERE clause of the given filter to a product query
+func buildProductFilter(dialect Dialect, query string, filter model.ProductFilter) (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if !filter.WithDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		placeholders := make([]string, 0, len(filter.CategoryIDs))
+		for _, id := range filter.CategoryIDs {
+			placeholders = append(placeholders, "?")
+			args = append(args, id)
+		}
+		conditions = append(conditions, "category_id IN ("+strings.Join(placeholders, ",")+")")
+	}
+
+	if filter.Tag != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM product_tag WHERE product_tag.product_id = product.id AND product_tag.tag = ?)")
+		args = append(args, filter.Tag)
+	}
+
+	if filter.Organizer != "" {
+		conditions = append(conditions, "organizer_name LIKE ?")
+		args = append(args, "%"+filter.Organizer+"%")
+	}
+
+	if filter.Location != "" {
+		conditions = append(conditions, "(location LIKE ? OR address LIKE ?)")
+		args = append(args, "%"+filter.Location+"%", "%"+filter.Location+"%")
+	}
+
+	if filter.RadiusKM > 0 {
+		condition, radiusArgs := dialect.withinRadius(filter.Latitude, filter.Longitude, filter.RadiusKM*1000)
+		conditions = append(conditions, condition)
+		args = append(args, radiusArgs...)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return query, args
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertReconciliation = `INSERT INTO reconciliation (transaction_id, provider_status, previous_status, status, currency, expected_amount,
+		provider_amount, result, message) VALUES (?,?,?,?,?,?,?,?,?)`
+	readReconciliationBetween = `SELECT id, transaction_id, provider_status, previous_status, status, currency, expected_amount, provider_amount,
+		result, message, created_at FROM reconciliation WHERE created_at >= ? AND created_at < ? ORDER BY id`
+)
+
+type ReconciliationRepository interface {
+	Create(ctx context.Context, reconciliation model.Reconciliation) (*model.Reconciliation, error)
+	// ReadBetween returns the reconciliations made from start until end
+	ReadBetween(ctx context.Context, start time.Time, end time.Time) ([]model.Reconciliation, error)
+}
+
+type mysqlReconciliationRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	replicas *Replicas
+}
+
+func NewReconciliationRepository(db *sql.DB, dialect Dialect, replicas *Replicas) ReconciliationRepository {
+	return &mysqlReconciliationRepository{
+		db:       db,
+		dialect:  dialect,
+		replicas: replicas,
+	}
+}
+
+func (repo *mysqlReconciliationRepository) Create(ctx context.Context, request model.Reconciliation) (*model.Reconciliation, error) {
+	id, err := repo.dialect.insert(
+		ctx,
+		conn(ctx, repo.db),
+		insertReconciliation,
+		request.TransactionID,
+		request.ProviderStatus,
+		request.PreviousStatus,
+		request.Status,
+		request.Currency,
+		request.ExpectedAmount,
+		request.ProviderAmount,
+		request.Result,
+		request.Message,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ID = id
+	request.CreatedAt = time.Now()
+
+	return &request, nil
+}
+
+func (repo *mysqlReconciliationRepository) ReadBetween(ctx context.Context, start time.Time, end time.Time) (response []model.Reconciliation, err error) {
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readReconciliationBetween), start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r model.Reconciliation
+		err = rows.Scan(
+			&r.ID,
+			&r.TransactionID,
+			&r.ProviderStatus,
+			&r.PreviousStatus,
+			&r.Status,
+			&r.Currency,
+			&r.ExpectedAmount,
+			&r.ProviderAmount,
+			&r.Result,
+			&r.Message,
+			&r.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, r)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+type replicaKey struct{}
+
+// AllowReplica marks ctx as tolerating replication lag, the read only queries
+// made with it may be served by a read replica. Queries of a unit of work and
+// of an unmarked ctx always run on the primary, so a flow reads its own writes.
+func AllowReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaKey{}, true)
+}
+
+// Replicas routes the read only queries of a repository to the healthy read
+// replicas in turn, and to the primary when none of them is healthy. A replica
+// that can not be reached between two checks is marked unhealthy by the query
+// failing on it, which runs again on the primary.
+type Replicas struct {
+	nodes []*replica
+	next  uint32
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy int32
+}
+
+// ReplicaStatus is the health of a read replica as last checked, with the
+// statistics of its connection pool
+type ReplicaStatus struct {
+	Healthy bool
+	Stats   sql.DBStats
+}
+
+// NewReplicas routes between the primary of each repository and the given
+// replicas, a replica only serves queries once Check found it healthy
+func NewReplicas(dbs []*sql.DB) *Replicas {
+	r := &Replicas{}
+	for _, db := range dbs {
+		r.nodes = append(r.nodes, &replica{db: db})
+	}
+
+	return r
+}
+
+// Check pings every replica, a replica failing the ping serves no query until
+// it answers again
+func (r *Replicas) Check(ctx context.Context, timeout time.Duration) {
+	for _, node := range r.nodes {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := node.db.PingContext(pingCtx)
+		cancel()
+
+		if err != nil {
+			atomic.StoreInt32(&node.healthy, 0)
+			continue
+		}
+
+		atomic.StoreInt32(&node.healthy, 1)
+	}
+}
+
+// Watch checks the replicas every interval until ctx is cancelled
+func (r *Replicas) Watch(ctx context.Context, interval time.Duration) {
+	if len(r.nodes) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.Check(ctx, interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the health and pool statistics of every replica
+func (r *Replicas) Status() []ReplicaStatus {
+	status := make([]ReplicaStatus, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		status = append(status, ReplicaStatus{
+			Healthy: atomic.LoadInt32(&node.healthy) == 1,
+			Stats:   node.db.Stats(),
+		})
+	}
+
+	return status
+}
+
+// reader returns where a read only query made with ctx runs: the transaction of
+// a running unit of work, a healthy replica when ctx allows one, or the primary
+func (r *Replicas) reader(ctx context.Context, db *sql.DB) executor {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok || r == nil {
+		return conn(ctx, db)
+	}
+
+	if allowed, _ := ctx.Value(replicaKey{}).(bool); !allowed {
+		return db
+	}
+
+	for i := 0; i < len(r.nodes); i++ {
+		node := r.nodes[atomic.AddUint32(&r.next, 1)%uint32(len(r.nodes))]
+		if atomic.LoadInt32(&node.healthy) == 1 {
+			return replicaReader{node: node, primary: db}
+		}
+	}
+
+	return db
+}
+
+// replicaReader runs the queries of a repository on a replica and falls back to
+// the primary when the replica can not be reached
+type replicaReader struct {
+	node    *replica
+	primary *sql.DB
+}
+
+// ExecContext writes, which is only done on the primary
+func (r replicaReader) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+func (r replicaReader) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := r.node.db.PrepareContext(ctx, query)
+	if r.unreachable(ctx, err) {
+		return r.primary.PrepareContext(ctx, query)
+	}
+
+	return stmt, err
+}
+
+func (r replicaReader) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := r.node.db.QueryContext(ctx, query, args...)
+	if r.unreachable(ctx, err) {
+		return r.primary.QueryContext(ctx, query, args...)
+	}
+
+	return rows, err
+}
+
+func (r replicaReader) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := r.node.db.QueryRowContext(ctx, query, args...)
+	if r.unreachable(ctx, row.Err()) {
+		return r.primary.QueryRowContext(ctx, query, args...)
+	}
+
+	return row
+}
+
+// unreachable tells whether err is a connection error of the replica, the
+// replica then serves no query until the next check finds it healthy again
+func (r replicaReader) unreachable(ctx context.Context, err error) bool {
+	var netErr net.Error
+	if err == nil || ctx.Err() != nil || !(errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr)) {
+		return false
+	}
+
+	atomic.StoreInt32(&r.node.healthy, 0)
+
+	return true
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// unreachableDriver is a replica that went away, dialing it is refused
+type unreachableDriver struct{}
+
+func (unreachableDriver) Open(name string) (driver.Conn, error) {
+	return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+}
+
+func init() {
+	sql.Register("unreachable", unreachableDriver{})
+}
+
+func TestReplicaFallsBackToPrimary(t *testing.T) {
+	primary, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+
+	down, err := sql.Open("unreachable", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer down.Close()
+
+	// the replica went away after the last check found it healthy
+	replicas := NewReplicas([]*sql.DB{down})
+	atomic.StoreInt32(&replicas.nodes[0].healthy, 1)
+
+	ctx := AllowReplica(context.Background())
+
+	var one int
+	if err := replicas.reader(ctx, primary).QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil || one != 1 {
+		t.Fatalf("got %d, %v, want the row of the primary", one, err)
+	}
+
+	if replicas.Status()[0].Healthy {
+		t.Fatal("got a healthy replica, want it marked unhealthy by the failed read")
+	}
+
+	atomic.StoreInt32(&replicas.nodes[0].healthy, 1)
+
+	rows, err := replicas.reader(ctx, primary).QueryContext(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatalf("got %v, want the rows of the primary", err)
+	}
+	rows.Close()
+
+	if reader := replicas.reader(ctx, primary); reader != primary {
+		t.Fatalf("got %T, want the primary until the replica answers a check", reader)
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	// the casts type the selected placeholders, PostgreSQL cannot tell their type otherwise
+	insertSeatMap = `INSERT INTO product_seat (product_id, seat_id, status) SELECT CAST(? AS DECIMAL(20,0)), id, CAST(? AS CHAR(10)) FROM seat WHERE venue_id=?`
+	readSeatMap   = `SELECT ps.product_id, ps.seat_id, s.section, s.row_label, s.number, ps.status, COALESCE(ps.transaction_id, 0)
+		FROM product_seat ps JOIN seat s ON s.id = ps.seat_id WHERE ps.product_id=? ORDER BY s.section, s.row_label, s.number`
+	countSeatMap = `SELECT count(1) FROM product_seat WHERE product_id=?`
+	holdSeat     = `UPDATE product_seat SET status=?, transaction_id=? WHERE product_id=? AND seat_id=? AND status=?`
+	sellSeats    = `UPDATE product_seat SET status=? WHERE transaction_id=? AND status=?`
+	releaseSeats = `UPDATE product_seat SET status=?, transaction_id=NULL WHERE transaction_id=? AND status IN (?,?)`
+)
+
+type SeatRepository interface {
+	CreateSeatMap(ctx context.Context, productID int64, venueID int64) (int64, error)
+	ReadSeatMap(ctx context.Context, productID int64) ([]model.ProductSeat, error)
+	CountSeatMap(ctx context.Context, productID int64) (int64, error)
+	HoldSeats(ctx context.Context, productID int64, transactionID int64, seatIDs []int64) error
+	SellSeats(ctx context.Context, transactionID int64) (int64, error)
+	ReleaseSeats(ctx context.Context, transactionID int64) (int64, error)
+}
+
+type mysqlSeatRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	replicas *Replicas
+}
+
+func NewSeatRepository(db *sql.DB, dialect Dialect, replicas *Replicas) SeatRepository {
+	return &mysqlSeatRepository{
+		db:       db,
+		dialect:  dialect,
+		replicas: replicas,
+	}
+}
+
+func (repo *mysqlSeatRepository) CreateSeatMap(ctx context.Context, productID int64, venueID int64) (int64, error) {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(insertSeatMap))
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := stmt.ExecContext(ctx, productID, constans.AVAILABLE, venueID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (repo *mysqlSeatRepository) ReadSeatMap(ctx context.Context, productID int64) (response []model.ProductSeat, err error) {
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readSeatMap), productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s model.ProductSeat
+		err = rows.Scan(
+			&s.ProductID,
+			&s.SeatID,
+			&s.Section,
+			&s.Row,
+			&s.Number,
+			&s.Status,
+			&s.TransactionID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, s)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlSeatRepository) CountSeatMap(ctx context.Context, productID int64) (total int64, err error) {
+	err = repo.replicas.reader(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(countSeatMap), productID).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// HoldSeats locks every requested seat for the given transaction. Each seat is
+// only taken when it is still available, and the whole hold is rolled back if
+// any of the seats was already taken by another buyer.
+func (repo *mysqlSeatRepository) HoldSeats(ctx context.Context, productID int64, transactionID int64, seatIDs []int64) error {
+	tx, err := beginTx(ctx, repo.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, repo.dialect.Query(holdSeat))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, seatID := range seatIDs {
+		result, err := stmt.ExecContext(ctx, constans.HELD, transactionID, productID, seatID, constans.AVAILABLE)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected != 1 {
+			return constans.ErrSeatNotAvailable
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *mysqlSeatRepository) SellSeats(ctx context.Context, transactionID int64) (int64, error) {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(sellSeats))
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := stmt.ExecContext(ctx, constans.SOLD, transactionID, constans.HELD)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (repo *mysqlSeatRepository) ReleaseSeats(ctx context.Context, transactionID int64) (int64, error) {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(releaseSeats))
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := stmt.ExecContext(ctx, constans.AVAILABLE, transactionID, constans.HELD, constans.SOLD)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
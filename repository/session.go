@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertSession        = `INSERT INTO session (product_id, start_date, end_date, capacity, stock) VALUES (?,?,?,?,?)`
+	readSessionByProduct = `SELECT id, product_id, start_date, end_date, capacity, stock, created_at, updated_at FROM session WHERE product_id=? ORDER BY start_date`
+	readSessionByID      = `SELECT id, product_id, start_date, end_date, capacity, stock, created_at, updated_at FROM session WHERE id=?`
+	countSession         = `SELECT count(1) FROM session WHERE product_id=?`
+	updateSession        = `UPDATE session SET start_date=?, end_date=?, stock=GREATEST(stock+(?-capacity), 0), capacity=? WHERE id=?`
+	updateFutureSession  = `UPDATE session SET stock=GREATEST(stock+(?-capacity), 0), capacity=? WHERE product_id=? AND start_date > ?`
+	deleteSession        = `DELETE FROM session WHERE id=?`
+	updateSessionStock   = `UPDATE session SET stock=(stock+?) WHERE id=?`
+	takeSessionStock     = `UPDATE session SET stock=(stock-?) WHERE id=? AND stock>=?`
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, sessions []model.Session) error
+	ReadByProduct(ctx context.Context, productID int64) ([]model.Session, error)
+	ReadByID(ctx context.Context, sessionID int64) (*model.Session, error)
+	// ReadByIDForUpdate reads a session and locks it until the unit of work ends
+	ReadByIDForUpdate(ctx context.Context, sessionID int64) (*model.Session, error)
+	Count(ctx context.Context, productID int64) (int64, error)
+	Update(ctx context.Context, session model.Session) error
+	UpdateFutureCapacity(ctx context.Context, productID int64, capacity int64, after time.Time) error
+	Delete(ctx context.Context, sessionID int64) error
+	UpdateStock(ctx context.Context, sessionID int64, newStock int64) error
+	// TakeStock takes quantity tickets off the stock, failing with ErrTicketRunOut
+	// rather than letting the stock go below zero
+	TakeStock(ctx context.Context, sessionID int64, quantity int64) error
+}
+
+type mysqlSessionRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	replicas *Replicas
+}
+
+func NewSessionRepository(db *sql.DB, dialect Dialect, replicas *Replicas) SessionRepository {
+	return &mysqlSessionRepository{
+		db:       db,
+		dialect:  dialect,
+		replicas: replicas,
+	}
+}
+
+func (repo *mysqlSessionRepository) Create(ctx context.Context, sessions []model.Session) error {
+	tx, err := beginTx(ctx, repo.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, repo.dialect.Query(insertSession))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, session := range sessions {
+		_, err = stmt.ExecContext(ctx, session.ProductID, session.StartDate, session.EndDate, session.Capacity, session.Capacity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *mysqlSessionRepository) ReadByProduct(ctx context.Context, productID int64) (response []model.Session, err error) {
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readSessionByProduct), productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s model.Session
+		err = rows.Scan(
+			&s.ID,
+			&s.ProductID,
+			&s.StartDate,
+			&s.EndDate,
+			&s.Capacity,
+			&s.Stock,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, s)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlSessionRepository) ReadByID(ctx context.Context, sessionID int64) (*model.Session, error) {
+	return repo.readByID(ctx, readSessionByID, sessionID)
+}
+
+func (repo *mysqlSessionRepository) ReadByIDForUpdate(ctx context.Context, sessionID int64) (*model.Session, error) {
+	return repo.readByID(ctx, readSessionByID+` FOR UPDATE`, sessionID)
+}
+
+func (repo *mysqlSessionRepository) readByID(ctx context.Context, query string, sessionID int64) (*model.Session, error) {
+	var s model.Session
+	err := repo.replicas.reader(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(query), sessionID).Scan(
+		&s.ID,
+		&s.ProductID,
+		&s.StartDate,
+		&s.EndDate,
+		&s.Capacity,
+		&s.Stock,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (repo *mysqlSessionRepository) Count(ctx context.Context, productID int64) (total int64, err error) {
+	err = repo.replicas.reader(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(countSession), productID).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Update changes the schedule and capacity of a session, the stock follows the
+// capacity change so tickets already sold stay accounted for
+func (repo *mysqlSessionRepository) Update(ctx context.Context, session model.Session) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateSession))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, session.StartDate, session.EndDate, session.Capacity, session.Capacity, session.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlSessionRepository) UpdateFutureCapacity(ctx context.Context, productID int64, capacity int64, after time.Time) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateFutureSession))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, capacity, capacity, productID, after)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlSessionRepository) Delete(ctx context.Context, sessionID int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(deleteSession))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlSessionRepository) UpdateStock(ctx context.Context, sessionID int64, newStock int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateSessionStock))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, newStock, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlSessionRepository) TakeStock(ctx context.Context, sessionID int64, quantity int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(takeSessionStock))
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, quantity, sessionID, quantity)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return constans.ErrTicketRunOut
+	}
+
+	return nil
+}
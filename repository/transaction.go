@@ -3,15 +3,25 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/cecepsprd/ticketing-api/constans"
 	"github.com/cecepsprd/ticketing-api/model"
 )
 
 var (
-	insertTransaction       = `INSERT INTO transaction (product_id, user_id, amount, status) VALUES (?,?,?,?)`
-	updateTransaction       = `UPDATE transaction set payment_url=? WHERE id=?`
-	updateTransactionStatus = `UPDATE transaction set status=? WHERE id=?`
-	readTransactionByID     = `SELECT product_id, user_id, amount, status, payment_url, created_at, updated_at FROM product WHERE id=?`
+	insertTransaction = `INSERT INTO transaction (product_id, session_id, user_id, amount, currency, payment_method, unit_price, quantity, subtotal, discount, fee, surcharge, tax, total,
+		price_rate, display_currency, display_rate, display_total, status)
+		VALUES (?,NULLIF(?, 0),?,?,?,?,?,?,?,?,?,?,?,?,CAST(NULLIF(?, '') AS DECIMAL(24,12)),NULLIF(?, ''),CAST(NULLIF(?, '') AS DECIMAL(24,12)),?,?)`
+	updateTransaction         = `UPDATE transaction set payment_url=? WHERE id=?`
+	updateTransactionStatus   = `UPDATE transaction set status=? WHERE id=?`
+	countTransactionByProduct = `SELECT count(1) FROM transaction WHERE product_id=? AND status=?`
+	countUpcomingByProduct    = `SELECT count(1) FROM transaction t LEFT JOIN session s ON s.id = t.session_id WHERE t.product_id=? AND t.status=? AND (t.session_id IS NULL OR s.end_date > ?)`
+	selectTransaction         = `SELECT id, product_id, COALESCE(session_id, 0), user_id, amount, currency, payment_method, unit_price, quantity, subtotal, discount, fee,
+		surcharge, tax, total, price_rate, display_currency, display_rate, display_total, status, COALESCE(payment_url, ''), created_at, updated_at FROM transaction`
+	readTransactionByID          = selectTransaction + ` WHERE id=?`
+	readUnreconciledTransactions = selectTransaction + ` t WHERE status=? AND created_at < ?
+		AND NOT EXISTS (SELECT 1 FROM reconciliation r WHERE r.transaction_id = t.id AND r.result IN (?,?)) ORDER BY id LIMIT ?`
 )
 
 type TransactionRepository interface {
@@ -19,42 +29,81 @@ type TransactionRepository interface {
 	Update(context.Context, model.Transaction) error
 	UpdateStatus(ctx context.Context, transactionID int64, status string) error
 	ReadByID(ctx context.Context, transactionID int64) (*model.Transaction, error)
+	// ReadByIDForUpdate reads a transaction and locks it until the unit of work ends
+	ReadByIDForUpdate(ctx context.Context, transactionID int64) (*model.Transaction, error)
+	CountByProduct(ctx context.Context, productID int64, status string) (int64, error)
+	// CountUpcomingByProduct counts the transactions of a product leaving out
+	// the ones for sessions that ended before now
+	CountUpcomingByProduct(ctx context.Context, productID int64, status string, now time.Time) (int64, error)
+	// ReadUnreconciled returns the pending transactions created before the given time,
+	// oldest first. A transaction with a mismatch or a failed check is left out
+	// until it is settled, it waits for a review instead of being checked again.
+	ReadUnreconciled(ctx context.Context, before time.Time, limit int) ([]model.Transaction, error)
 }
 
 type mysqlTrxRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
 }
 
-func NewTransactionRepository(db *sql.DB) TransactionRepository {
+func NewTransactionRepository(db *sql.DB, dialect Dialect) TransactionRepository {
 	return &mysqlTrxRepository{
-		db: db,
+		db:      db,
+		dialect: dialect,
 	}
 }
 
 func (m *mysqlTrxRepository) Create(ctx context.Context, request model.Transaction) (*model.Transaction, error) {
-	stmt, err := m.db.PrepareContext(ctx, insertTransaction)
-	if err != nil {
-		return nil, err
+	var (
+		exchange      model.ExchangeSnapshot
+		displayTotal  model.Money
+		displayAmount sql.NullInt64
+	)
+
+	if request.Exchange != nil {
+		exchange = *request.Exchange
+	}
+
+	if exchange.DisplayTotal != nil {
+		displayTotal = *exchange.DisplayTotal
+		displayAmount = sql.NullInt64{Int64: displayTotal.Amount, Valid: true}
 	}
 
-	result, err := stmt.ExecContext(
+	id, err := m.dialect.insert(
 		ctx,
+		conn(ctx, m.db),
+		insertTransaction,
 		request.ProductID,
+		request.SessionID,
 		request.UserID,
-		request.Amount,
+		request.Amount.Amount,
+		request.Amount.Currency,
+		request.PaymentMethod,
+		request.Breakdown.UnitPrice,
+		request.Breakdown.Quantity,
+		request.Breakdown.Subtotal,
+		request.Breakdown.Discount,
+		request.Breakdown.Fee,
+		request.Breakdown.Surcharge,
+		request.Breakdown.Tax,
+		request.Breakdown.Total,
+		exchange.PriceRate,
+		displayTotal.Currency,
+		exchange.DisplayRate,
+		displayAmount,
 		request.Status,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	request.ID, _ = result.LastInsertId()
+	request.ID = id
 
 	return &request, nil
 }
 
 func (repo *mysqlTrxRepository) Update(ctx context.Context, request model.Transaction) error {
-	stmt, err := repo.db.PrepareContext(ctx, updateTransaction)
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateTransaction))
 	if err != nil {
 		return err
 	}
@@ -69,7 +118,7 @@ func (repo *mysqlTrxRepository) Update(ctx context.Context, request model.Transa
 }
 
 func (repo *mysqlTrxRepository) UpdateStatus(ctx context.Context, transactionID int64, status string) error {
-	stmt, err := repo.db.PrepareContext(ctx, updateTransactionStatus)
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateTransactionStatus))
 	if err != nil {
 		return err
 	}
@@ -84,20 +133,111 @@ func (repo *mysqlTrxRepository) UpdateStatus(ctx context.Context, transactionID
 }
 
 func (m *mysqlTrxRepository) ReadByID(ctx context.Context, transactionID int64) (*model.Transaction, error) {
-	var transaction model.Transaction
-	err := m.db.QueryRowContext(ctx, readProductByID, transactionID).Scan(
+	return m.readByID(ctx, readTransactionByID, transactionID)
+}
+
+func (m *mysqlTrxRepository) ReadByIDForUpdate(ctx context.Context, transactionID int64) (*model.Transaction, error) {
+	return m.readByID(ctx, readTransactionByID+` FOR UPDATE`, transactionID)
+}
+
+func (m *mysqlTrxRepository) readByID(ctx context.Context, query string, transactionID int64) (*model.Transaction, error) {
+	transaction, err := scanTransaction(conn(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(query), transactionID))
+	if err == sql.ErrNoRows {
+		return nil, constans.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+func (m *mysqlTrxRepository) ReadUnreconciled(ctx context.Context, before time.Time, limit int) (response []model.Transaction, err error) {
+	rows, err := conn(ctx, m.db).QueryContext(ctx, m.dialect.Query(readUnreconciledTransactions), constans.PENDING, before, constans.MISMATCH, constans.FAILED, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *transaction)
+	}
+
+	return response, nil
+}
+
+func scanTransaction(row scanner) (*model.Transaction, error) {
+	var (
+		transaction     model.Transaction
+		priceRate       sql.NullString
+		displayCurrency sql.NullString
+		displayRate     sql.NullString
+		displayTotal    sql.NullInt64
+	)
+	err := row.Scan(
 		&transaction.ID,
 		&transaction.ProductID,
+		&transaction.SessionID,
 		&transaction.UserID,
-		&transaction.Amount,
+		&transaction.Amount.Amount,
+		&transaction.Amount.Currency,
+		&transaction.PaymentMethod,
+		&transaction.Breakdown.UnitPrice,
+		&transaction.Breakdown.Quantity,
+		&transaction.Breakdown.Subtotal,
+		&transaction.Breakdown.Discount,
+		&transaction.Breakdown.Fee,
+		&transaction.Breakdown.Surcharge,
+		&transaction.Breakdown.Tax,
+		&transaction.Breakdown.Total,
+		&priceRate,
+		&displayCurrency,
+		&displayRate,
+		&displayTotal,
 		&transaction.Status,
 		&transaction.PaymentURL,
 		&transaction.CreatedAt,
 		&transaction.UpdatedAt,
 	)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, nil
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.Breakdown.Currency = transaction.Amount.Currency
+
+	if priceRate.Valid || displayRate.Valid {
+		transaction.Exchange = &model.ExchangeSnapshot{
+			PriceRate:   priceRate.String,
+			DisplayRate: displayRate.String,
+		}
+
+		if displayTotal.Valid {
+			total := model.NewMoney(displayTotal.Int64, displayCurrency.String)
+			transaction.Exchange.DisplayTotal = &total
+		}
 	}
 
 	return &transaction, nil
 }
+
+func (m *mysqlTrxRepository) CountByProduct(ctx context.Context, productID int64, status string) (total int64, err error) {
+	err = conn(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(countTransactionByProduct), productID, status).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (m *mysqlTrxRepository) CountUpcomingByProduct(ctx context.Context, productID int64, status string, now time.Time) (total int64, err error) {
+	err = conn(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(countUpcomingByProduct), productID, status, now).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
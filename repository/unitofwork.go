@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type (
+	txKey          struct{}
+	commitHooksKey struct{}
+)
+
+// UnitOfWork runs the operations of several repositories inside one database transaction
+type UnitOfWork interface {
+	// Do runs fn in a transaction, every repository called with the context given
+	// to fn takes part in it. The transaction is committed when fn returns nil and
+	// rolled back otherwise. Nested calls join the transaction already running.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type mysqlUnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) UnitOfWork {
+	return &mysqlUnitOfWork{
+		db: db,
+	}
+}
+
+func (u *mysqlUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ctx, committed := WithCommitHooks(ctx)
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	committed()
+	return nil
+}
+
+// AfterCommit runs fn once the unit of work running in ctx committed, or right
+// away outside of one. fn is dropped when the unit of work rolls back.
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(commitHooksKey{}).(*[]func()); ok {
+		*hooks = append(*hooks, fn)
+		return
+	}
+
+	fn()
+}
+
+// WithCommitHooks collects the functions given to AfterCommit with the returned
+// context, a unit of work calls committed to run them once it committed
+func WithCommitHooks(ctx context.Context) (_ context.Context, committed func()) {
+	hooks := &[]func(){}
+
+	return context.WithValue(ctx, commitHooksKey{}, hooks), func() {
+		for _, fn := range *hooks {
+			fn()
+		}
+	}
+}
+
+// executor runs statements on either the database or a transaction
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction of the unit of work running in ctx, or db outside of one
+func conn(ctx context.Context, db *sql.DB) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// repoTx is the transaction of a single repository method. Inside a unit of work
+// it joins the running transaction and leaves committing to the unit of work.
+type repoTx struct {
+	*sql.Tx
+	joined bool
+}
+
+func beginTx(ctx context.Context, db *sql.DB) (*repoTx, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return &repoTx{Tx: tx, joined: true}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repoTx{Tx: tx}, nil
+}
+
+func (t *repoTx) Commit() error {
+	if t.joined {
+		return nil
+	}
+
+	return t.Tx.Commit()
+}
+
+func (t *repoTx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+
+	return t.Tx.Rollback()
+}
@@ -9,7 +9,7 @@ import (
 )
 
 var (
-	readUserByID       = `SELECT id, username, email, password, phone, address, created_at, updated_at FROM user WHERE id = ?`
+	readUserByID       = `SELECT id, username, email, password, phone, address, roles, created_at, updated_at FROM user WHERE id = ?`
 	insertUser         = `INSERT INTO user (username, password, email, phone, address, roles) VALUES (?,?,?,?,?,?)`
 	readAllUser        = `SELECT id, username, email, password, phone, address, roles FROM user`
 	updateUser         = `UPDATE user set username=?, email=?, password=?, phone=?, address=?, roles=? WHERE id = ?`
@@ -19,7 +19,7 @@ var (
 )
 
 type UserRepository interface {
-	Create(context.Context, model.User) error
+	Create(context.Context, model.User) (*model.User, error)
 	Read(context.Context) ([]model.User, error)
 	Update(ctx context.Context, request model.User) error
 	Delete(ctx context.Context, userid int64) error
@@ -29,18 +29,20 @@ type UserRepository interface {
 }
 
 type mysqlUserRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
 }
 
-func NewUserRepository(db *sql.DB) UserRepository {
+func NewUserRepository(db *sql.DB, dialect Dialect) UserRepository {
 	return &mysqlUserRepository{
-		db: db,
+		db:      db,
+		dialect: dialect,
 	}
 }
 
 func (m *mysqlUserRepository) ReadByID(ctx context.Context, userid int64) (model.User, error) {
 	var user model.User
-	err := m.db.QueryRowContext(ctx, readUserByID, userid).Scan(
+	err := conn(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(readUserByID), userid).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
@@ -58,22 +60,19 @@ func (m *mysqlUserRepository) ReadByID(ctx context.Context, userid int64) (model
 	return user, nil
 }
 
-func (m *mysqlUserRepository) Create(ctx context.Context, request model.User) error {
-	stmt, err := m.db.PrepareContext(ctx, insertUser)
+func (m *mysqlUserRepository) Create(ctx context.Context, request model.User) (*model.User, error) {
+	id, err := m.dialect.insert(ctx, conn(ctx, m.db), insertUser, request.Username, request.Password, request.Email, request.Phone, request.Address, request.Roles)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = stmt.ExecContext(ctx, request.Username, request.Password, request.Email, request.Phone, request.Address, request.Roles)
-	if err != nil {
-		return err
-	}
+	request.ID = id
 
-	return nil
+	return &request, nil
 }
 
 func (repo *mysqlUserRepository) Read(ctx context.Context) (response []model.User, err error) {
-	rows, err := repo.db.QueryContext(ctx, readAllUser)
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readAllUser))
 	if err != nil {
 		return nil, err
 	}
@@ -102,12 +101,12 @@ func (repo *mysqlUserRepository) Read(ctx context.Context) (response []model.Use
 }
 
 func (repo *mysqlUserRepository) Update(ctx context.Context, request model.User) error {
-	stmt, err := repo.db.PrepareContext(ctx, updateUser)
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateUser))
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.ExecContext(ctx, request.Username, request.Password, request.Email, request.Phone, request.Address, request.Roles, request.ID)
+	_, err = stmt.ExecContext(ctx, request.Username, request.Email, request.Password, request.Phone, request.Address, request.Roles, request.ID)
 	if err != nil {
 		return err
 	}
@@ -116,7 +115,7 @@ func (repo *mysqlUserRepository) Update(ctx context.Context, request model.User)
 }
 
 func (repo *mysqlUserRepository) Delete(ctx context.Context, userid int64) error {
-	stmt, err := repo.db.PrepareContext(ctx, deleteUser)
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(deleteUser))
 	if err != nil {
 		return err
 	}
@@ -131,7 +130,7 @@ func (repo *mysqlUserRepository) Delete(ctx context.Context, userid int64) error
 
 func (m *mysqlUserRepository) ReadByUsername(ctx context.Context, username string) (model.User, error) {
 	var user model.User
-	err := m.db.QueryRowContext(ctx, readUserByUsername, username).Scan(
+	err := conn(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(readUserByUsername), username).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
@@ -150,7 +149,7 @@ func (m *mysqlUserRepository) ReadByUsername(ctx context.Context, username strin
 }
 
 func (m *mysqlUserRepository) CountUser(ctx context.Context, request model.User) (total int32, err error) {
-	err = m.db.QueryRowContext(ctx, checkDuplicateUser, request.Username, request.Email, request.Phone).Scan(&total)
+	err = conn(ctx, m.db).QueryRowContext(ctx, m.dialect.Query(checkDuplicateUser), request.Username, request.Email, request.Phone).Scan(&total)
 	if err != nil {
 		return 0, err
 	}
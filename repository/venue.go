@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertVenue     = `INSERT INTO venue (name, address) VALUES (?,?)`
+	readAllVenue    = `SELECT id, name, address, created_at, updated_at FROM venue`
+	readVenueByID   = `SELECT id, name, address, created_at, updated_at FROM venue WHERE id=?`
+	insertSeat      = `INSERT INTO seat (venue_id, section, row_label, number) VALUES (?,?,?,?)`
+	readSeatByVenue = `SELECT id, venue_id, section, row_label, number FROM seat WHERE venue_id=? ORDER BY section, row_label, number`
+)
+
+type VenueRepository interface {
+	Create(ctx context.Context, venue model.Venue) (*model.Venue, error)
+	Read(context.Context) ([]model.Venue, error)
+	ReadByID(ctx context.Context, venueID int64) (*model.Venue, error)
+	CreateSeats(ctx context.Context, seats []model.Seat) error
+	ReadSeats(ctx context.Context, venueID int64) ([]model.Seat, error)
+}
+
+type mysqlVenueRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	replicas *Replicas
+}
+
+func NewVenueRepository(db *sql.DB, dialect Dialect, replicas *Replicas) VenueRepository {
+	return &mysqlVenueRepository{
+		db:       db,
+		dialect:  dialect,
+		replicas: replicas,
+	}
+}
+
+func (repo *mysqlVenueRepository) Create(ctx context.Context, request model.Venue) (*model.Venue, error) {
+	id, err := repo.dialect.insert(ctx, conn(ctx, repo.db), insertVenue, request.Name, request.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ID = id
+
+	return &request, nil
+}
+
+func (repo *mysqlVenueRepository) Read(ctx context.Context) (response []model.Venue, err error) {
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readAllVenue))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v model.Venue
+		err = rows.Scan(
+			&v.ID,
+			&v.Name,
+			&v.Address,
+			&v.CreatedAt,
+			&v.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, v)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlVenueRepository) ReadByID(ctx context.Context, venueID int64) (*model.Venue, error) {
+	var v model.Venue
+	err := repo.replicas.reader(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readVenueByID), venueID).Scan(
+		&v.ID,
+		&v.Name,
+		&v.Address,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// CreateSeats inserts all given seats in a single database transaction,
+// so a duplicated seat rejects the whole batch
+func (repo *mysqlVenueRepository) CreateSeats(ctx context.Context, seats []model.Seat) error {
+	tx, err := beginTx(ctx, repo.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, repo.dialect.Query(insertSeat))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, seat := range seats {
+		_, err = stmt.ExecContext(ctx, seat.VenueID, seat.Section, seat.Row, seat.Number)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *mysqlVenueRepository) ReadSeats(ctx context.Context, venueID int64) (response []model.Seat, err error) {
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readSeatByVenue), venueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s model.Seat
+		err = rows.Scan(
+			&s.ID,
+			&s.VenueID,
+			&s.Section,
+			&s.Row,
+			&s.Number,
+		)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, s)
+	}
+
+	return response, nil
+}
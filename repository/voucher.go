@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertVoucher = `INSERT INTO voucher (code, type, value, product_id, section, valid_from, valid_until, max_redemptions, max_per_user)
+		VALUES (?,?,?,NULLIF(?, 0),?,?,?,?,?)`
+	updateVoucher = `UPDATE voucher SET code=?, type=?, value=?, product_id=NULLIF(?, 0), section=?, valid_from=?, valid_until=?,
+		max_redemptions=?, max_per_user=? WHERE id=?`
+	deleteVoucher = `DELETE FROM voucher WHERE id=? AND redeemed=0`
+	selectVoucher = `SELECT id, code, type, value, COALESCE(product_id, 0), section, valid_from, valid_until,
+		max_redemptions, max_per_user, redeemed, created_at, updated_at FROM voucher`
+	readAllVoucher          = selectVoucher + ` ORDER BY id DESC`
+	readVoucherByID         = selectVoucher + ` WHERE id=?`
+	readVoucherByCode       = selectVoucher + ` WHERE code=?`
+	lockVoucher             = `SELECT max_redemptions, max_per_user, redeemed FROM voucher WHERE id=? FOR UPDATE`
+	countUserRedemption     = `SELECT count(1) FROM voucher_redemption WHERE voucher_id=? AND user_id=? AND status<>?`
+	insertRedemption        = `INSERT INTO voucher_redemption (voucher_id, user_id, transaction_id, discount, status) VALUES (?,?,?,?,?)`
+	incrementRedeemed       = `UPDATE voucher SET redeemed=redeemed+1 WHERE id=?`
+	readRedemptionByTrx     = `SELECT id, voucher_id, user_id, transaction_id, discount, status, created_at FROM voucher_redemption WHERE transaction_id=? FOR UPDATE`
+	updateRedemptionStatus  = `UPDATE voucher_redemption SET status=? WHERE id=?`
+	decrementRedeemed       = `UPDATE voucher SET redeemed=redeemed-1 WHERE id=? AND redeemed>0`
+	readRedemptionByVoucher = `SELECT id, voucher_id, user_id, transaction_id, discount, status, created_at FROM voucher_redemption WHERE voucher_id=? ORDER BY id`
+)
+
+type VoucherRepository interface {
+	Create(ctx context.Context, voucher model.Voucher) (*model.Voucher, error)
+	Read(context.Context) ([]model.Voucher, error)
+	ReadByID(ctx context.Context, voucherID int64) (*model.Voucher, error)
+	ReadByCode(ctx context.Context, code string) (*model.Voucher, error)
+	Update(ctx context.Context, voucher model.Voucher) error
+	Delete(ctx context.Context, voucherID int64) error
+	Redeem(ctx context.Context, redemption model.VoucherRedemption) error
+	UpdateRedemption(ctx context.Context, transactionID int64, status string) error
+	ReadRedemptions(ctx context.Context, voucherID int64) ([]model.VoucherRedemption, error)
+}
+
+type mysqlVoucherRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	replicas *Replicas
+}
+
+func NewVoucherRepository(db *sql.DB, dialect Dialect, replicas *Replicas) VoucherRepository {
+	return &mysqlVoucherRepository{
+		db:       db,
+		dialect:  dialect,
+		replicas: replicas,
+	}
+}
+
+func (repo *mysqlVoucherRepository) Create(ctx context.Context, request model.Voucher) (*model.Voucher, error) {
+	id, err := repo.dialect.insert(
+		ctx,
+		conn(ctx, repo.db),
+		insertVoucher,
+		request.Code,
+		request.Type,
+		request.Value,
+		request.ProductID,
+		request.Section,
+		request.ValidFrom,
+		request.ValidUntil,
+		request.MaxRedemptions,
+		request.MaxPerUser,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ID = id
+
+	return &request, nil
+}
+
+func (repo *mysqlVoucherRepository) Read(ctx context.Context) (response []model.Voucher, err error) {
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readAllVoucher))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := scanVoucher(rows)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *v)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlVoucherRepository) ReadByID(ctx context.Context, voucherID int64) (*model.Voucher, error) {
+	v, err := scanVoucher(repo.replicas.reader(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readVoucherByID), voucherID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (repo *mysqlVoucherRepository) ReadByCode(ctx context.Context, code string) (*model.Voucher, error) {
+	v, err := scanVoucher(repo.replicas.reader(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readVoucherByCode), code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (repo *mysqlVoucherRepository) Update(ctx context.Context, request model.Voucher) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateVoucher))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(
+		ctx,
+		request.Code,
+		request.Type,
+		request.Value,
+		request.ProductID,
+		request.Section,
+		request.ValidFrom,
+		request.ValidUntil,
+		request.MaxRedemptions,
+		request.MaxPerUser,
+		request.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes a voucher which has never been redeemed
+func (repo *mysqlVoucherRepository) Delete(ctx context.Context, voucherID int64) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(deleteVoucher))
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, voucherID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return constans.ErrConflict
+	}
+
+	return nil
+}
+
+// Redeem reserves one redemption of a voucher. The voucher row is locked while
+// the global and per user limits are checked, so concurrent checkouts can never
+// redeem a voucher more often than allowed.
+func (repo *mysqlVoucherRepository) Redeem(ctx context.Context, redemption model.VoucherRedemption) error {
+	tx, err := beginTx(ctx, repo.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var maxRedemptions, maxPerUser, redeemed int64
+	err = tx.QueryRowContext(ctx, repo.dialect.Query(lockVoucher), redemption.VoucherID).Scan(&maxRedemptions, &maxPerUser, &redeemed)
+	if err == sql.ErrNoRows {
+		return constans.ErrVoucherInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	if maxRedemptions > 0 && redeemed >= maxRedemptions {
+		return constans.ErrVoucherExhausted
+	}
+
+	if maxPerUser > 0 {
+		var used int64
+		err = tx.QueryRowContext(ctx, repo.dialect.Query(countUserRedemption), redemption.VoucherID, redemption.UserID, constans.RELEASED).Scan(&used)
+		if err != nil {
+			return err
+		}
+
+		if used >= maxPerUser {
+			return constans.ErrVoucherExhausted
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, repo.dialect.Query(insertRedemption),
+		redemption.VoucherID,
+		redemption.UserID,
+		redemption.TransactionID,
+		redemption.Discount,
+		constans.RESERVED,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, repo.dialect.Query(incrementRedeemed), redemption.VoucherID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateRedemption moves the redemption of a transaction to the given status,
+// releasing a redemption gives it back to the voucher limits
+func (repo *mysqlVoucherRepository) UpdateRedemption(ctx context.Context, transactionID int64, status string) error {
+	tx, err := beginTx(ctx, repo.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var r model.VoucherRedemption
+	err = tx.QueryRowContext(ctx, repo.dialect.Query(readRedemptionByTrx), transactionID).Scan(
+		&r.ID,
+		&r.VoucherID,
+		&r.UserID,
+		&r.TransactionID,
+		&r.Discount,
+		&r.Status,
+		&r.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.Status == status || r.Status == constans.RELEASED {
+		return nil
+	}
+
+	if _, err = tx.ExecContext(ctx, repo.dialect.Query(updateRedemptionStatus), status, r.ID); err != nil {
+		return err
+	}
+
+	if status == constans.RELEASED {
+		if _, err = tx.ExecContext(ctx, repo.dialect.Query(decrementRedeemed), r.VoucherID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *mysqlVoucherRepository) ReadRedemptions(ctx context.Context, voucherID int64) (response []model.VoucherRedemption, err error) {
+	rows, err := repo.replicas.reader(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(readRedemptionByVoucher), voucherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r model.VoucherRedemption
+		err = rows.Scan(
+			&r.ID,
+			&r.VoucherID,
+			&r.UserID,
+			&r.TransactionID,
+			&r.Discount,
+			&r.Status,
+			&r.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, r)
+	}
+
+	return response, nil
+}
+
+func scanVoucher(row scanner) (*model.Voucher, error) {
+	var (
+		v          model.Voucher
+		validFrom  sql.NullTime
+		validUntil sql.NullTime
+	)
+
+	err := row.Scan(
+		&v.ID,
+		&v.Code,
+		&v.Type,
+		&v.Value,
+		&v.ProductID,
+		&v.Section,
+		&validFrom,
+		&validUntil,
+		&v.MaxRedemptions,
+		&v.MaxPerUser,
+		&v.Redeemed,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if validFrom.Valid {
+		v.ValidFrom = &validFrom.Time
+	}
+
+	if validUntil.Valid {
+		v.ValidUntil = &validUntil.Time
+	}
+
+	return &v, nil
+}
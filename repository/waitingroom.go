@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertWaitingRoom        = `INSERT INTO waiting_room (product_id, rate, checkout_window, paused, admitted, admitted_at, last_position) VALUES (?,?,?,?,?,?,?)`
+	readWaitingRoom          = `SELECT product_id, rate, checkout_window, paused, admitted, admitted_at, last_position, created_at, updated_at FROM waiting_room WHERE product_id=?`
+	updateWaitingRoom        = `UPDATE waiting_room SET rate=?, checkout_window=?, paused=?, admitted=?, admitted_at=?, last_position=? WHERE product_id=?`
+	deleteWaitingRoom        = `DELETE FROM waiting_room WHERE product_id=?`
+	deleteWaitingRoomEntries = `DELETE FROM waiting_room_entry WHERE product_id=?`
+	insertQueueEntry         = `INSERT INTO waiting_room_entry (product_id, user_id, position) VALUES (?,?,?)`
+	readQueueEntry           = `SELECT product_id, user_id, position, admitted_at, created_at FROM waiting_room_entry WHERE product_id=? AND user_id=?`
+	requeueEntry             = `UPDATE waiting_room_entry SET position=?, admitted_at=NULL WHERE product_id=? AND user_id=?`
+	admitQueueEntry          = `UPDATE waiting_room_entry SET admitted_at=? WHERE product_id=? AND user_id=? AND admitted_at IS NULL`
+)
+
+type WaitingRoomRepository interface {
+	Create(ctx context.Context, room model.WaitingRoom) error
+	ReadByProduct(ctx context.Context, productID int64) (*model.WaitingRoom, error)
+	ReadByProductForUpdate(ctx context.Context, productID int64) (*model.WaitingRoom, error)
+	Update(ctx context.Context, room model.WaitingRoom) error
+	// Delete removes a waiting room together with its queue
+	Delete(ctx context.Context, productID int64) error
+	CreateEntry(ctx context.Context, entry model.QueueEntry) error
+	ReadEntry(ctx context.Context, productID int64, userID int64) (*model.QueueEntry, error)
+	// Requeue moves an entry to a new position, as if it had just joined
+	Requeue(ctx context.Context, entry model.QueueEntry) error
+	// Admit records when an entry was let in, it reports false when that was already recorded
+	Admit(ctx context.Context, productID int64, userID int64, at time.Time) (bool, error)
+}
+
+type mysqlWaitingRoomRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewWaitingRoomRepository(db *sql.DB, dialect Dialect) WaitingRoomRepository {
+	return &mysqlWaitingRoomRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func (repo *mysqlWaitingRoomRepository) Create(ctx context.Context, room model.WaitingRoom) error {
+	_, err := repo.exec(ctx, insertWaitingRoom,
+		room.ProductID,
+		room.Rate,
+		room.CheckoutWindow,
+		room.Paused,
+		room.Admitted,
+		room.AdmittedAt,
+		room.LastPosition,
+	)
+
+	return err
+}
+
+// ReadByProduct reads from the primary, pausing a room has to stop the flow at once
+func (repo *mysqlWaitingRoomRepository) ReadByProduct(ctx context.Context, productID int64) (*model.WaitingRoom, error) {
+	return repo.readByProduct(ctx, readWaitingRoom, productID)
+}
+
+func (repo *mysqlWaitingRoomRepository) ReadByProductForUpdate(ctx context.Context, productID int64) (*model.WaitingRoom, error) {
+	return repo.readByProduct(ctx, readWaitingRoom+` FOR UPDATE`, productID)
+}
+
+func (repo *mysqlWaitingRoomRepository) readByProduct(ctx context.Context, query string, productID int64) (*model.WaitingRoom, error) {
+	var w model.WaitingRoom
+	err := conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(query), productID).Scan(
+		&w.ProductID,
+		&w.Rate,
+		&w.CheckoutWindow,
+		&w.Paused,
+		&w.Admitted,
+		&w.AdmittedAt,
+		&w.LastPosition,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+func (repo *mysqlWaitingRoomRepository) Update(ctx context.Context, room model.WaitingRoom) error {
+	_, err := repo.exec(ctx, updateWaitingRoom,
+		room.Rate,
+		room.CheckoutWindow,
+		room.Paused,
+		room.Admitted,
+		room.AdmittedAt,
+		room.LastPosition,
+		room.ProductID,
+	)
+
+	return err
+}
+
+func (repo *mysqlWaitingRoomRepository) Delete(ctx context.Context, productID int64) error {
+	tx, err := beginTx(ctx, repo.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, repo.dialect.Query(deleteWaitingRoomEntries), productID); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, repo.dialect.Query(deleteWaitingRoom), productID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *mysqlWaitingRoomRepository) CreateEntry(ctx context.Context, entry model.QueueEntry) error {
+	_, err := repo.exec(ctx, insertQueueEntry, entry.ProductID, entry.UserID, entry.Position)
+	return err
+}
+
+func (repo *mysqlWaitingRoomRepository) ReadEntry(ctx context.Context, productID int64, userID int64) (*model.QueueEntry, error) {
+	var (
+		e          model.QueueEntry
+		admittedAt sql.NullTime
+	)
+
+	err := conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readQueueEntry), productID, userID).Scan(
+		&e.ProductID,
+		&e.UserID,
+		&e.Position,
+		&admittedAt,
+		&e.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if admittedAt.Valid {
+		e.AdmittedAt = &admittedAt.Time
+	}
+
+	return &e, nil
+}
+
+func (repo *mysqlWaitingRoomRepository) Requeue(ctx context.Context, entry model.QueueEntry) error {
+	_, err := repo.exec(ctx, requeueEntry, entry.Position, entry.ProductID, entry.UserID)
+	return err
+}
+
+func (repo *mysqlWaitingRoomRepository) Admit(ctx context.Context, productID int64, userID int64, at time.Time) (bool, error) {
+	return repo.exec(ctx, admitQueueEntry, at, productID, userID)
+}
+
+func (repo *mysqlWaitingRoomRepository) exec(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(query))
+	if err != nil {
+		return false, err
+	}
+
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
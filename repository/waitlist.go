@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertWaitlist       = `INSERT INTO waitlist (product_id, session_id, user_id, status) VALUES (?,?,?,?)`
+	readActiveWaitlist   = `SELECT id, product_id, session_id, user_id, status, offer_expires_at, created_at, updated_at FROM waitlist WHERE product_id=? AND session_id=? AND user_id=? AND status IN (?,?)`
+	readWaitlistPosition = `SELECT count(1) FROM waitlist WHERE product_id=? AND session_id=? AND status=? AND id<=?`
+	readNextWaitlist     = `SELECT id, product_id, session_id, user_id, status, offer_expires_at, created_at, updated_at FROM waitlist WHERE product_id=? AND session_id=? AND status=? ORDER BY id LIMIT ?`
+	readExpiredOffers    = `SELECT id, product_id, session_id, user_id, status, offer_expires_at, created_at, updated_at FROM waitlist WHERE status=? AND offer_expires_at < ?`
+	countActiveOffers    = `SELECT count(1) FROM waitlist WHERE product_id=? AND session_id=? AND status=? AND offer_expires_at >= ? AND user_id<>?`
+	offerWaitlist        = `UPDATE waitlist SET status=?, offer_expires_at=? WHERE id=? AND status=?`
+	updateWaitlistStatus = `UPDATE waitlist SET status=? WHERE id=? AND status=?`
+)
+
+// WaitlistRepository keeps a waitlist per session, products without sessions
+// use session 0
+type WaitlistRepository interface {
+	Create(ctx context.Context, waitlist model.Waitlist) (*model.Waitlist, error)
+	ReadActive(ctx context.Context, productID int64, sessionID int64, userID int64) (*model.Waitlist, error)
+	Position(ctx context.Context, waitlist model.Waitlist) (int64, error)
+	ReadNext(ctx context.Context, productID int64, sessionID int64, limit int64) ([]model.Waitlist, error)
+	ReadExpiredOffers(ctx context.Context, now time.Time) ([]model.Waitlist, error)
+	CountActiveOffers(ctx context.Context, productID int64, sessionID int64, exceptUserID int64, now time.Time) (int64, error)
+	Offer(ctx context.Context, waitlistID int64, expiresAt time.Time) (bool, error)
+	UpdateStatus(ctx context.Context, waitlistID int64, from string, to string) (bool, error)
+}
+
+type mysqlWaitlistRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewWaitlistRepository(db *sql.DB, dialect Dialect) WaitlistRepository {
+	return &mysqlWaitlistRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func (repo *mysqlWaitlistRepository) Create(ctx context.Context, request model.Waitlist) (*model.Waitlist, error) {
+	id, err := repo.dialect.insert(ctx, conn(ctx, repo.db), insertWaitlist, request.ProductID, request.SessionID, request.UserID, request.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ID = id
+
+	return &request, nil
+}
+
+// ReadActive returns the waiting or offered entry of a user for a product session
+func (repo *mysqlWaitlistRepository) ReadActive(ctx context.Context, productID int64, sessionID int64, userID int64) (*model.Waitlist, error) {
+	w, err := scanWaitlist(conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readActiveWaitlist), productID, sessionID, userID, constans.WAITING, constans.OFFERED))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (repo *mysqlWaitlistRepository) Position(ctx context.Context, waitlist model.Waitlist) (position int64, err error) {
+	err = conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readWaitlistPosition), waitlist.ProductID, waitlist.SessionID, constans.WAITING, waitlist.ID).Scan(&position)
+	if err != nil {
+		return 0, err
+	}
+
+	return position, nil
+}
+
+// ReadNext returns the oldest waiting entries of a product session, first in first out
+func (repo *mysqlWaitlistRepository) ReadNext(ctx context.Context, productID int64, sessionID int64, limit int64) ([]model.Waitlist, error) {
+	return repo.query(ctx, readNextWaitlist, productID, sessionID, constans.WAITING, limit)
+}
+
+func (repo *mysqlWaitlistRepository) ReadExpiredOffers(ctx context.Context, now time.Time) ([]model.Waitlist, error) {
+	return repo.query(ctx, readExpiredOffers, constans.OFFERED, now)
+}
+
+func (repo *mysqlWaitlistRepository) CountActiveOffers(ctx context.Context, productID int64, sessionID int64, exceptUserID int64, now time.Time) (total int64, err error) {
+	err = conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(countActiveOffers), productID, sessionID, constans.OFFERED, now, exceptUserID).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Offer gives a waiting entry its purchase window, it reports false when the
+// entry was not waiting anymore
+func (repo *mysqlWaitlistRepository) Offer(ctx context.Context, waitlistID int64, expiresAt time.Time) (bool, error) {
+	return repo.exec(ctx, offerWaitlist, constans.OFFERED, expiresAt, waitlistID, constans.WAITING)
+}
+
+func (repo *mysqlWaitlistRepository) UpdateStatus(ctx context.Context, waitlistID int64, from string, to string) (bool, error) {
+	return repo.exec(ctx, updateWaitlistStatus, to, waitlistID, from)
+}
+
+func (repo *mysqlWaitlistRepository) exec(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(query))
+	if err != nil {
+		return false, err
+	}
+
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *mysqlWaitlistRepository) query(ctx context.Context, query string, args ...interface{}) (response []model.Waitlist, err error) {
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		w, err := scanWaitlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *w)
+	}
+
+	return response, nil
+}
+
+func scanWaitlist(row scanner) (*model.Waitlist, error) {
+	var (
+		w         model.Waitlist
+		expiresAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&w.ID,
+		&w.ProductID,
+		&w.SessionID,
+		&w.UserID,
+		&w.Status,
+		&expiresAt,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		w.OfferExpiresAt = &expiresAt.Time
+	}
+
+	return &w, nil
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+var (
+	insertWebhook           = `INSERT INTO webhook (url, event_types, secret, active) VALUES (?,?,?,1)`
+	updateWebhook           = `UPDATE webhook SET url=?, event_types=?, secret=? WHERE id=?`
+	enableWebhook           = `UPDATE webhook SET active=1, failure_count=0, disabled_at=NULL WHERE id=?`
+	disableWebhook          = `UPDATE webhook SET active=0, disabled_at=COALESCE(disabled_at, ?) WHERE id=?`
+	deleteWebhook           = `DELETE FROM webhook WHERE id=?`
+	deleteWebhookDeliveries = `DELETE FROM webhook_delivery WHERE webhook_id=?`
+	selectWebhook           = `SELECT id, url, event_types, secret, active, failure_count, disabled_at, created_at, updated_at FROM webhook`
+	readAllWebhook          = selectWebhook + ` ORDER BY id`
+	readWebhookByID         = selectWebhook + ` WHERE id=?`
+	readSubscribedWebhook   = selectWebhook + ` WHERE active=1 AND FIND_IN_SET(?, event_types) > 0`
+	resetWebhookFailures    = `UPDATE webhook SET failure_count=0 WHERE id=?`
+	incrementWebhookFailure = `UPDATE webhook SET failure_count=failure_count+1 WHERE id=?`
+	readWebhookFailures     = `SELECT failure_count FROM webhook WHERE id=?`
+	insertWebhookDelivery   = `INSERT IGNORE INTO webhook_delivery (webhook_id, event_id, event_type, payload, status, next_attempt_at) VALUES (?,?,?,?,?,?)`
+	selectWebhookDelivery   = `SELECT d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.response_code, d.last_error,
+		d.next_attempt_at, COALESCE(d.lease_token, ''), d.locked_until, d.delivered_at, d.created_at, d.updated_at FROM webhook_delivery d`
+	readDeliveryByID      = selectWebhookDelivery + ` WHERE d.id=?`
+	readDeliveryByWebhook = selectWebhookDelivery + ` WHERE d.webhook_id=? ORDER BY d.id DESC LIMIT ?`
+	claimDeliveries       = `UPDATE webhook_delivery SET status=?, lease_token=?, locked_until=?
+		WHERE ((status=? AND next_attempt_at <= ?) OR (status=? AND locked_until < ?)) AND webhook_id IN (SELECT id FROM webhook WHERE active=1)
+		ORDER BY id LIMIT ?`
+	readDeliveryByLease   = selectWebhookDelivery + ` WHERE d.lease_token=? ORDER BY d.id`
+	updateWebhookDelivery = `UPDATE webhook_delivery SET status=?, attempts=?, response_code=?, last_error=?, next_attempt_at=?, delivered_at=?,
+		lease_token=NULL, locked_until=NULL WHERE id=? AND COALESCE(lease_token, '')=?`
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook model.Webhook) (*model.Webhook, error)
+	Read(context.Context) ([]model.Webhook, error)
+	ReadByID(ctx context.Context, webhookID int64) (*model.Webhook, error)
+	// ReadSubscribed returns the active webhooks subscribed to an event type
+	ReadSubscribed(ctx context.Context, eventType string) ([]model.Webhook, error)
+	Update(ctx context.Context, webhook model.Webhook) error
+	Delete(ctx context.Context, webhookID int64) error
+	Enable(ctx context.Context, webhookID int64) error
+	Disable(ctx context.Context, webhookID int64, disabledAt time.Time) error
+	// RecordFailure counts a failed delivery of a webhook and returns its consecutive failures
+	RecordFailure(ctx context.Context, webhookID int64) (int64, error)
+	ResetFailures(ctx context.Context, webhookID int64) error
+	// CreateDelivery schedules the delivery of an event, an event is only delivered once to a webhook
+	CreateDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+	ReadDelivery(ctx context.Context, deliveryID int64) (*model.WebhookDelivery, error)
+	ReadDeliveries(ctx context.Context, webhookID int64, limit int) ([]model.WebhookDelivery, error)
+	// ClaimDeliveries takes up to limit deliveries of the active webhooks due at
+	// now, or whose lease expired, and hides them from other instances until lockedUntil
+	ClaimDeliveries(ctx context.Context, token string, now time.Time, lockedUntil time.Time, limit int) ([]model.WebhookDelivery, error)
+	// UpdateDelivery records the outcome of a delivery, it is ignored when the
+	// delivery was claimed by someone else since it was read
+	UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+}
+
+type mysqlWebhookRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewWebhookRepository(db *sql.DB, dialect Dialect) WebhookRepository {
+	return &mysqlWebhookRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func (repo *mysqlWebhookRepository) Create(ctx context.Context, request model.Webhook) (*model.Webhook, error) {
+	id, err := repo.dialect.insert(ctx, conn(ctx, repo.db), insertWebhook, request.URL, strings.Join(request.EventTypes, ","), request.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ID = id
+	request.Active = true
+
+	return &request, nil
+}
+
+func (repo *mysqlWebhookRepository) Read(ctx context.Context) ([]model.Webhook, error) {
+	return repo.query(ctx, readAllWebhook)
+}
+
+func (repo *mysqlWebhookRepository) ReadByID(ctx context.Context, webhookID int64) (*model.Webhook, error) {
+	w, err := scanWebhook(conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readWebhookByID), webhookID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (repo *mysqlWebhookRepository) ReadSubscribed(ctx context.Context, eventType string) ([]model.Webhook, error) {
+	return repo.query(ctx, readSubscribedWebhook, eventType)
+}
+
+func (repo *mysqlWebhookRepository) query(ctx context.Context, query string, args ...interface{}) (response []model.Webhook, err error) {
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *w)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlWebhookRepository) Update(ctx context.Context, request model.Webhook) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(updateWebhook))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, request.URL, strings.Join(request.EventTypes, ","), request.Secret, request.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes a webhook with its delivery log
+func (repo *mysqlWebhookRepository) Delete(ctx context.Context, webhookID int64) error {
+	tx, err := beginTx(ctx, repo.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, repo.dialect.Query(deleteWebhookDeliveries), webhookID); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, repo.dialect.Query(deleteWebhook), webhookID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *mysqlWebhookRepository) Enable(ctx context.Context, webhookID int64) error {
+	return repo.exec(ctx, enableWebhook, webhookID)
+}
+
+func (repo *mysqlWebhookRepository) Disable(ctx context.Context, webhookID int64, disabledAt time.Time) error {
+	return repo.exec(ctx, disableWebhook, disabledAt, webhookID)
+}
+
+func (repo *mysqlWebhookRepository) ResetFailures(ctx context.Context, webhookID int64) error {
+	return repo.exec(ctx, resetWebhookFailures, webhookID)
+}
+
+func (repo *mysqlWebhookRepository) RecordFailure(ctx context.Context, webhookID int64) (int64, error) {
+	tx, err := beginTx(ctx, repo.db)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, repo.dialect.Query(incrementWebhookFailure), webhookID); err != nil {
+		return 0, err
+	}
+
+	var failures int64
+	if err = tx.QueryRowContext(ctx, repo.dialect.Query(readWebhookFailures), webhookID).Scan(&failures); err != nil {
+		return 0, err
+	}
+
+	return failures, tx.Commit()
+}
+
+func (repo *mysqlWebhookRepository) exec(ctx context.Context, query string, args ...interface{}) error {
+	stmt, err := conn(ctx, repo.db).PrepareContext(ctx, repo.dialect.Query(query))
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mysqlWebhookRepository) CreateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	return repo.exec(ctx, insertWebhookDelivery,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		constans.PENDING,
+		delivery.NextAttemptAt,
+	)
+}
+
+func (repo *mysqlWebhookRepository) ReadDelivery(ctx context.Context, deliveryID int64) (*model.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(conn(ctx, repo.db).QueryRowContext(ctx, repo.dialect.Query(readDeliveryByID), deliveryID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (repo *mysqlWebhookRepository) ReadDeliveries(ctx context.Context, webhookID int64, limit int) ([]model.WebhookDelivery, error) {
+	return repo.queryDeliveries(ctx, readDeliveryByWebhook, webhookID, limit)
+}
+
+// ClaimDeliveries leases a batch with a single update, so concurrent instances
+// never send the same delivery, and reads it back by its lease token
+func (repo *mysqlWebhookRepository) ClaimDeliveries(ctx context.Context, token string, now time.Time, lockedUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	result, err := conn(ctx, repo.db).ExecContext(ctx, repo.dialect.Query(claimDeliveries),
+		constans.SENDING, token, lockedUntil,
+		constans.PENDING, now,
+		constans.SENDING, now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, nil
+	}
+
+	return repo.queryDeliveries(ctx, readDeliveryByLease, token)
+}
+
+func (repo *mysqlWebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) (response []model.WebhookDelivery, err error) {
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, repo.dialect.Query(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *d)
+	}
+
+	return response, nil
+}
+
+func (repo *mysqlWebhookRepository) UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	return repo.exec(ctx, updateWebhookDelivery,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseCode,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+		delivery.ID,
+		delivery.LeaseToken,
+	)
+}
+
+func scanWebhook(row scanner) (*model.Webhook, error) {
+	var (
+		w          model.Webhook
+		eventTypes string
+		disabledAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&w.ID,
+		&w.URL,
+		&eventTypes,
+		&w.Secret,
+		&w.Active,
+		&w.FailureCount,
+		&disabledAt,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if eventTypes != "" {
+		w.EventTypes = strings.Split(eventTypes, ",")
+	}
+
+	if disabledAt.Valid {
+		w.DisabledAt = &disabledAt.Time
+	}
+
+	return &w, nil
+}
+
+func scanWebhookDelivery(row scanner) (*model.WebhookDelivery, error) {
+	var (
+		d           model.WebhookDelivery
+		lockedUntil sql.NullTime
+		deliveredAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseCode,
+		&d.LastError,
+		&d.NextAttemptAt,
+		&d.LeaseToken,
+		&lockedUntil,
+		&deliveredAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		d.LockedUntil = &lockedUntil.Time
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+
+	return &d, nil
+}
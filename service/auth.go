@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/cecepsprd/ticketing-api/constans"
 	"github.com/cecepsprd/ticketing-api/model"
 	"github.com/cecepsprd/ticketing-api/utils/logger"
 	"github.com/dgrijalva/jwt-go"
@@ -18,6 +19,8 @@ type authService struct {
 
 type AuthService interface {
 	Login(context.Context, model.LoginRequest) (*model.LoginResponse, error)
+	// IssueToken signs a token for a user without its password, valid for ttl
+	IssueToken(ctx context.Context, username string, ttl time.Duration) (*model.LoginResponse, error)
 }
 
 func NewAuthService(us UserService, JWTSecret string) AuthService {
@@ -43,6 +46,28 @@ func (s *authService) Login(ctx context.Context, request model.LoginRequest) (*m
 		return nil, errors.New("password incorrect")
 	}
 
+	return s.sign(*user, constans.DefaultTokenTTL)
+}
+
+func (s *authService) IssueToken(ctx context.Context, username string, ttl time.Duration) (*model.LoginResponse, error) {
+	if ttl <= 0 {
+		return nil, constans.ErrBadParamInput
+	}
+
+	user, err := s.userService.ReadByUsername(ctx, username)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if user == nil || user.ID == 0 {
+		return nil, constans.ErrNotFound
+	}
+
+	return s.sign(*user, ttl)
+}
+
+func (s *authService) sign(user model.User, ttl time.Duration) (*model.LoginResponse, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 	claims := token.Claims.(jwt.MapClaims)
 	claims["id"] = user.ID
@@ -50,7 +75,7 @@ func (s *authService) Login(ctx context.Context, request model.LoginRequest) (*m
 	claims["email"] = user.Email
 	claims["phone"] = user.Phone
 	claims["roles"] = user.Roles
-	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
 
 	t, err := token.SignedString([]byte(s.JWTSecret))
 	if err != nil {
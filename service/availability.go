@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/hub"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/dgrijalva/jwt-go"
+	"go.uber.org/zap"
+)
+
+// streamToken is the type of the tokens opening an availability stream
+const streamToken = "availability_stream"
+
+// availabilityEvents are the events changing the stock or the seats held of a
+// product, they all carry the product_id
+var availabilityEvents = []string{
+	constans.TransactionCreated,
+	constans.TransactionPaid,
+	constans.TransactionCancelled,
+	constans.TransactionRefunded,
+	constans.StockUpdated,
+}
+
+type AvailabilityService interface {
+	// Read returns what is left of a product right now
+	Read(ctx context.Context, productID int64) (*model.Availability, error)
+	// Subscribe follows the availability of a product, every change is received
+	// as a message with the encoded availability and the ID of the event behind it
+	Subscribe(productID int64) *hub.Subscription
+	// Latest returns the ID of the last change of a product, ok is false when
+	// none was seen since the server started
+	Latest(productID int64) (id int64, ok bool)
+	// Publish reads the availability of a product changed by the event eventID
+	// and hands it to its followers, it is read once however many follow it
+	Publish(ctx context.Context, productID int64, eventID int64) error
+	// Feed publishes the availability changed by the events recorded since the
+	// last feed. Every instance reads the outbox itself, so a change reaches the
+	// followers of every instance whichever instance dispatches its event.
+	Feed(ctx context.Context) error
+	// StreamToken issues a token opening the stream of a product for a minute,
+	// so a client that cannot set headers never puts its login token in a URL
+	StreamToken(ctx context.Context, productID int64, userID int64) (*model.StreamToken, error)
+	// VerifyStreamToken reports whether token opens the stream of a product
+	VerifyStreamToken(token string, productID int64) bool
+}
+
+type availability struct {
+	productRepo    repository.ProductRepository
+	sessionRepo    repository.SessionRepository
+	seatRepo       repository.SeatRepository
+	outboxRepo     repository.OutboxRepository
+	hub            *hub.Hub
+	secret         []byte
+	contextTimeout time.Duration
+
+	mu sync.Mutex
+	// since is when the last feed started, fed holds the events it published
+	// with their creation, until they are older than the feed reads again
+	since time.Time
+	fed   map[int64]time.Time
+}
+
+func NewAvailabilityService(productRepo repository.ProductRepository, sessionRepo repository.SessionRepository, seatRepo repository.SeatRepository, outboxRepo repository.OutboxRepository, JWTSecret string, timeout time.Duration) AvailabilityService {
+	return &availability{
+		productRepo:    productRepo,
+		sessionRepo:    sessionRepo,
+		seatRepo:       seatRepo,
+		outboxRepo:     outboxRepo,
+		hub:            hub.New(),
+		secret:         streamKey(JWTSecret),
+		contextTimeout: timeout,
+		since:          time.Now(),
+		fed:            make(map[int64]time.Time),
+	}
+}
+
+// Read does not allow replicas, a change has to be seen as soon as its event is dispatched
+func (s *availability) Read(ctx context.Context, productID int64) (*model.Availability, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	product, err := s.productRepo.ReadByID(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return nil, constans.ErrNotFound
+	}
+
+	result := &model.Availability{
+		ProductID: productID,
+		Stock:     product.Stock,
+		UpdatedAt: time.Now(),
+	}
+
+	sessions, err := s.sessionRepo.ReadByProduct(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if len(sessions) > 0 {
+		// a product with sessions sells the tickets of its upcoming sessions
+		result.Stock = 0
+		for _, session := range sessions {
+			if !session.StartDate.After(result.UpdatedAt) {
+				continue
+			}
+
+			result.Stock += session.Stock
+			result.Sessions = append(result.Sessions, model.SessionAvailability{
+				SessionID: session.ID,
+				StartDate: session.StartDate,
+				Stock:     session.Stock,
+			})
+		}
+	}
+
+	seats, err := s.seatRepo.ReadSeatMap(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if len(seats) > 0 {
+		var available int64
+		for _, seat := range seats {
+			if seat.Status == constans.AVAILABLE {
+				available++
+			}
+		}
+		result.SeatsAvailable = &available
+	}
+
+	return result, nil
+}
+
+func (s *availability) Subscribe(productID int64) *hub.Subscription {
+	return s.hub.Subscribe(productID)
+}
+
+func (s *availability) Latest(productID int64) (int64, bool) {
+	return s.hub.Latest(productID)
+}
+
+func (s *availability) Publish(ctx context.Context, productID int64, eventID int64) error {
+	// an event committed after a newer one still changed the availability, it
+	// is sent again under the ID of the newer one
+	if latest, ok := s.hub.Latest(productID); ok && latest > eventID {
+		eventID = latest
+	}
+
+	// nobody follows the product, only remember a change happened
+	if s.hub.Subscribers(productID) == 0 {
+		s.hub.Seen(productID, eventID)
+		return nil
+	}
+
+	current, err := s.Read(ctx, productID)
+	if err == constans.ErrNotFound {
+		s.hub.Seen(productID, eventID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	s.hub.Publish(productID, hub.Message{ID: eventID, Data: data})
+
+	return nil
+}
+
+func (s *availability) Feed(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	started := time.Now()
+	events, err := s.outboxRepo.ReadRecent(ctx, s.since.Add(-constans.AvailabilityFeedLag), availabilityEvents, constans.AvailabilityFeedBatchSize)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	// the older events were replaced, a product only streams its latest availability
+	if len(events) == constans.AvailabilityFeedBatchSize {
+		logger.Log.Warn("availability feed is behind, older changes are skipped", zap.Int64("event_id", events[0].ID))
+	}
+
+	// a product changed by several events is read once, under the newest of them
+	changed := make(map[int64]int64)
+	for _, e := range events {
+		if _, ok := s.fed[e.ID]; ok {
+			continue
+		}
+
+		var payload struct {
+			ProductID int64 `json:"product_id"`
+		}
+		if err := json.Unmarshal(e.Payload, &payload); err != nil {
+			logger.Log.Error(err.Error())
+			continue
+		}
+
+		if e.ID > changed[payload.ProductID] {
+			changed[payload.ProductID] = e.ID
+		}
+	}
+
+	for productID, eventID := range changed {
+		if err := s.Publish(ctx, productID, eventID); err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+	}
+
+	for _, e := range events {
+		s.fed[e.ID] = e.CreatedAt
+	}
+
+	s.since = started
+	for id, createdAt := range s.fed {
+		if createdAt.Before(s.since.Add(-constans.AvailabilityFeedLag)) {
+			delete(s.fed, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *availability) StreamToken(ctx context.Context, productID int64, userID int64) (*model.StreamToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	product, err := s.productRepo.ReadByID(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return nil, constans.ErrNotFound
+	}
+
+	expiresAt := time.Now().Add(constans.AvailabilityTokenTTL)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":        streamToken,
+		"product_id": productID,
+		"user_id":    userID,
+		"exp":        expiresAt.Unix(),
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.StreamToken{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *availability) VerifyStreamToken(token string, productID int64) bool {
+	if token == "" {
+		return false
+	}
+
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return s.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return false
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	return ok && claims["typ"] == streamToken && claimInt(claims, "product_id") == productID
+}
+
+// streamKey derives the key of the stream tokens from the JWT secret, so a
+// stream token never passes for a login token nor the other way around
+func streamKey(JWTSecret string) []byte {
+	mac := hmac.New(sha256.New, []byte(JWTSecret))
+	mac.Write([]byte("availability stream"))
+	return mac.Sum(nil)
+}
+
+// recordStockUpdated records that an admin changed the stock of a product or of its sessions
+func recordStockUpdated(ctx context.Context, events EventService, productID int64) error {
+	return events.Record(ctx, constans.StockUpdated, productID, model.StockUpdatedEvent{ProductID: productID})
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+)
+
+type CategoryService interface {
+	Create(ctx context.Context, request model.CategoryRequest) (*model.Category, error)
+	Read(context.Context) ([]model.Category, error)
+	ReadByID(ctx context.Context, categoryID int64) (*model.Category, error)
+	Update(ctx context.Context, request model.CategoryRequest) error
+	Delete(ctx context.Context, categoryID int64) error
+}
+
+type category struct {
+	repo           repository.CategoryRepository
+	contextTimeout time.Duration
+}
+
+func NewCategoryService(repo repository.CategoryRepository, timeout time.Duration) CategoryService {
+	return &category{
+		repo:           repo,
+		contextTimeout: timeout,
+	}
+}
+
+func (s *category) Create(ctx context.Context, request model.CategoryRequest) (*model.Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if request.ParentID != 0 {
+		parent, err := s.repo.ReadByID(ctx, request.ParentID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+
+		if parent == nil {
+			return nil, constans.ErrBadParamInput
+		}
+	}
+
+	category, err := s.repo.Create(ctx, model.Category{
+		ParentID: request.ParentID,
+		Name:     request.Name,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *category) Read(ctx context.Context) ([]model.Category, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	categories, err := s.repo.Read(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (s *category) ReadByID(ctx context.Context, categoryID int64) (*model.Category, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	category, err := s.repo.ReadByID(ctx, categoryID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if category == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	return category, nil
+}
+
+func (s *category) Update(ctx context.Context, request model.CategoryRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	categories, err := s.repo.Read(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if !containsCategory(categories, request.ID) {
+		return constans.ErrNotFound
+	}
+
+	if request.ParentID != 0 {
+		if !containsCategory(categories, request.ParentID) {
+			return constans.ErrBadParamInput
+		}
+
+		// a category can not be moved below itself or one of its children
+		for _, id := range descendantCategories(categories, request.ID) {
+			if id == request.ParentID {
+				return constans.ErrBadParamInput
+			}
+		}
+	}
+
+	err = s.repo.Update(ctx, model.Category{
+		ID:       request.ID,
+		ParentID: request.ParentID,
+		Name:     request.Name,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *category) Delete(ctx context.Context, categoryID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	usages, err := s.repo.CountUsages(ctx, categoryID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if usages > 0 {
+		return constans.ErrConflict
+	}
+
+	err = s.repo.Delete(ctx, categoryID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func containsCategory(categories []model.Category, categoryID int64) bool {
+	for _, c := range categories {
+		if c.ID == categoryID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// descendantCategories returns the given category id followed by the ids of all its children, recursively
+func descendantCategories(categories []model.Category, categoryID int64) []int64 {
+	children := make(map[int64][]int64)
+	for _, c := range categories {
+		children[c.ParentID] = append(children[c.ParentID], c.ID)
+	}
+
+	var (
+		result  []int64
+		queue   = []int64{categoryID}
+		visited = make(map[int64]bool)
+	)
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		result = append(result, id)
+		queue = append(queue, children[id]...)
+	}
+
+	return result
+}
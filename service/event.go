@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/eventbus"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"go.uber.org/zap"
+)
+
+// maxEventError is the size of the last_error column of the outbox
+const maxEventError = 1000
+
+type EventService interface {
+	// Record stores a domain event in the outbox. Called inside a unit of work the
+	// event is only kept when the state change it describes is committed.
+	Record(ctx context.Context, eventType string, aggregateID int64, payload interface{}) error
+	// Dispatch delivers the pending events of the outbox to the subscribers of the bus,
+	// failed deliveries are retried with an exponential backoff. Every instance
+	// claims its own batch, so an event is published by one instance at a time.
+	Dispatch(context.Context) error
+}
+
+type event struct {
+	repo           repository.OutboxRepository
+	bus            eventbus.Bus
+	contextTimeout time.Duration
+}
+
+func NewEventService(repo repository.OutboxRepository, bus eventbus.Bus, timeout time.Duration) EventService {
+	return &event{
+		repo:           repo,
+		bus:            bus,
+		contextTimeout: timeout,
+	}
+}
+
+func (s *event) Record(ctx context.Context, eventType string, aggregateID int64, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Create(ctx, model.Event{
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		Payload:       data,
+		NextAttemptAt: time.Now(),
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *event) Dispatch(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	token, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	events, err := s.repo.Claim(ctx, token, now, now.Add(constans.OutboxLeaseTimeout), constans.OutboxBatchSize)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	for _, e := range events {
+		if err := s.bus.Publish(ctx, e); err != nil {
+			s.retry(ctx, e, err)
+			continue
+		}
+
+		if err := s.repo.MarkDispatched(ctx, e, time.Now()); err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retry schedules the next delivery of an event, doubling the delay after every
+// attempt, and gives up after OutboxMaxAttempts
+func (s *event) retry(ctx context.Context, e model.Event, cause error) {
+	e.Attempts++
+	e.LastError = cause.Error()
+	if len(e.LastError) > maxEventError {
+		e.LastError = e.LastError[:maxEventError]
+	}
+
+	backoff := constans.OutboxBaseBackoff << uint(e.Attempts-1)
+	if backoff <= 0 || backoff > constans.OutboxMaxBackoff {
+		backoff = constans.OutboxMaxBackoff
+	}
+	e.NextAttemptAt = time.Now().Add(backoff)
+
+	e.Status = constans.PENDING
+	if e.Attempts >= constans.OutboxMaxAttempts {
+		e.Status = constans.FAILED
+	}
+
+	logger.Log.Warn("event delivery failed",
+		zap.Int64("event_id", e.ID),
+		zap.String("type", e.Type),
+		zap.Int("attempts", e.Attempts),
+		zap.String("error", e.LastError),
+	)
+
+	if err := s.repo.MarkFailed(ctx, e); err != nil {
+		logger.Log.Error(err.Error())
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/exchange"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+)
+
+type ExchangeService interface {
+	Read(context.Context) ([]model.ExchangeRate, error)
+	Update(ctx context.Context, request model.ExchangeRateRequest) error
+	Delete(ctx context.Context, currency string) error
+	// Refresh replaces the rates with the ones of the rate provider
+	Refresh(context.Context) error
+	// Convert converts money to currency and returns the rate it was converted with
+	Convert(ctx context.Context, money model.Money, currency string) (model.Money, string, error)
+	// Base returns the currency the exchange rates are relative to
+	Base() string
+}
+
+type exchangeRate struct {
+	repo           repository.ExchangeRateRepository
+	provider       exchange.Provider
+	base           string
+	contextTimeout time.Duration
+}
+
+// NewExchangeService returns an exchange service with rates relative to base,
+// provider may be nil when the rates are only managed by admins
+func NewExchangeService(repo repository.ExchangeRateRepository, provider exchange.Provider, base string, timeout time.Duration) ExchangeService {
+	return &exchangeRate{
+		repo:           repo,
+		provider:       provider,
+		base:           strings.ToUpper(base),
+		contextTimeout: timeout,
+	}
+}
+
+func (s *exchangeRate) Read(ctx context.Context) ([]model.ExchangeRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	rates, err := s.repo.Read(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (s *exchangeRate) Update(ctx context.Context, request model.ExchangeRateRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.save(ctx, request.Currency, request.Rate, constans.ManualRateSource)
+}
+
+func (s *exchangeRate) Delete(ctx context.Context, currency string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	err := s.repo.Delete(ctx, strings.ToUpper(currency))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *exchangeRate) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if s.provider == nil {
+		return constans.ErrRateProviderUnavailable
+	}
+
+	base, rates, err := s.provider.Rates(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if base != s.base {
+		return constans.ErrRateProviderUnavailable
+	}
+
+	for currency, rate := range rates {
+		if err := s.save(ctx, currency, rate, constans.ProviderRateSource); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *exchangeRate) Convert(ctx context.Context, money model.Money, currency string) (model.Money, string, error) {
+	currency = strings.ToUpper(currency)
+	if money.Currency == currency {
+		return money, "", nil
+	}
+
+	from, err := s.rate(ctx, money.Currency)
+	if err != nil {
+		return model.Money{}, "", err
+	}
+
+	to, err := s.rate(ctx, currency)
+	if err != nil {
+		return model.Money{}, "", err
+	}
+
+	rate := new(big.Rat).Quo(to, from)
+
+	return exchange.Convert(money, rate, currency), exchange.FormatRate(rate), nil
+}
+
+func (s *exchangeRate) Base() string {
+	return s.base
+}
+
+// rate returns the rate of a currency, the base currency always has a rate of 1
+func (s *exchangeRate) rate(ctx context.Context, currency string) (*big.Rat, error) {
+	if currency == s.base {
+		return big.NewRat(1, 1), nil
+	}
+
+	rate, err := s.repo.ReadByCurrency(ctx, currency)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if rate == nil {
+		return nil, constans.ErrCurrencyNotSupported
+	}
+
+	return exchange.ParseRate(rate.Rate)
+}
+
+func (s *exchangeRate) save(ctx context.Context, currency string, rate string, source string) error {
+	currency = strings.ToUpper(currency)
+	if currency == s.base || !model.ValidCurrency(currency) {
+		return constans.ErrCurrencyNotSupported
+	}
+
+	parsed, err := exchange.ParseRate(rate)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Upsert(ctx, model.ExchangeRate{
+		Currency: currency,
+		Rate:     exchange.FormatRate(parsed),
+		Source:   source,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
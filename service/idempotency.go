@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+)
+
+type IdempotencyService interface {
+	// Begin reserves a key for a request. It returns nil when the request has to
+	// be handled, or the completed key of an earlier request to replay. A key
+	// stays reserved for IdempotencyLockTimeout, a retry of a request that did
+	// not complete by then, like one whose instance crashed, takes it over.
+	Begin(ctx context.Context, key model.IdempotencyKey) (*model.IdempotencyKey, error)
+	// Complete stores the response of a request
+	Complete(ctx context.Context, key model.IdempotencyKey) error
+	// Abandon frees the key of a request which failed, so it can be retried
+	Abandon(ctx context.Context, scope string, key string) error
+	DeleteExpired(context.Context) error
+}
+
+type idempotency struct {
+	repo           repository.IdempotencyRepository
+	retention      time.Duration
+	contextTimeout time.Duration
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository, retention time.Duration, timeout time.Duration) IdempotencyService {
+	if retention <= 0 {
+		retention = constans.DefaultIdempotencyRetention
+	}
+
+	return &idempotency{
+		repo:           repo,
+		retention:      retention,
+		contextTimeout: timeout,
+	}
+}
+
+func (s *idempotency) Begin(ctx context.Context, key model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	lockedUntil := time.Now().Add(constans.IdempotencyLockTimeout)
+	key.LockedUntil = &lockedUntil
+
+	created, err := s.repo.Create(ctx, key, time.Now().Add(-s.retention))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if created {
+		return nil, nil
+	}
+
+	existing, err := s.repo.Read(ctx, key.Scope, key.Key)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	// the key was abandoned in between, the client has to retry
+	if existing == nil {
+		return nil, constans.ErrIdempotencyInProgress
+	}
+
+	if existing.RequestHash != key.RequestHash {
+		return nil, constans.ErrIdempotencyKeyReused
+	}
+
+	if !existing.Completed {
+		taken, err := s.repo.TakeOver(ctx, key, time.Now())
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+
+		if taken {
+			return nil, nil
+		}
+
+		return nil, constans.ErrIdempotencyInProgress
+	}
+
+	return existing, nil
+}
+
+func (s *idempotency) Complete(ctx context.Context, key model.IdempotencyKey) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	err := s.repo.Complete(ctx, key)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *idempotency) Abandon(ctx context.Context, scope string, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	err := s.repo.Delete(ctx, scope, key)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the keys which are past the retention period
+func (s *idempotency) DeleteExpired(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	_, err := s.repo.DeleteExpired(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
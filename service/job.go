@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"go.uber.org/zap"
+)
+
+// JobHandler runs a job, a returned error runs the job again after a backoff
+type JobHandler func(ctx context.Context, job model.Job) error
+
+type JobService interface {
+	// Enqueue adds a job to run as soon as possible
+	Enqueue(ctx context.Context, jobType string, payload interface{}) error
+	// Schedule adds a job to run at runAt, a job with the unique key of a queued job is not added again
+	Schedule(ctx context.Context, jobType string, payload interface{}, runAt time.Time, uniqueKey string) error
+	// Handle registers the handler of a job type, handlers are registered before the workers start
+	Handle(jobType string, handler JobHandler)
+	Read(ctx context.Context, status string) ([]model.Job, error)
+	// Retry queues a dead job again with its attempts reset
+	Retry(ctx context.Context, jobID int64) error
+	DeleteFinished(context.Context) error
+	// Work runs queued jobs with the given number of workers until ctx is cancelled,
+	// it returns once the jobs already running are finished
+	Work(ctx context.Context, workers int)
+}
+
+type job struct {
+	repo           repository.JobRepository
+	handlers       map[string]JobHandler
+	mu             sync.RWMutex
+	contextTimeout time.Duration
+}
+
+func NewJobService(repo repository.JobRepository, timeout time.Duration) JobService {
+	return &job{
+		repo:           repo,
+		handlers:       make(map[string]JobHandler),
+		contextTimeout: timeout,
+	}
+}
+
+func (s *job) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	return s.Schedule(ctx, jobType, payload, time.Now(), "")
+}
+
+func (s *job) Schedule(ctx context.Context, jobType string, payload interface{}, runAt time.Time, uniqueKey string) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Create(ctx, model.Job{
+		Type:        jobType,
+		Payload:     data,
+		UniqueKey:   uniqueKey,
+		MaxAttempts: constans.JobMaxAttempts,
+		RunAt:       runAt,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *job) Handle(jobType string, handler JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[jobType] = handler
+}
+
+func (s *job) Read(ctx context.Context, status string) ([]model.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	jobs, err := s.repo.ReadByStatus(ctx, status, constans.JobLogLimit)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (s *job) Retry(ctx context.Context, jobID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	current, err := s.repo.ReadByID(ctx, jobID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if current == nil {
+		return constans.ErrNotFound
+	}
+
+	retried, err := s.repo.Retry(ctx, jobID, time.Now())
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	// only dead jobs can be retried, the others are still queued or completed
+	if !retried {
+		return constans.ErrConflict
+	}
+
+	return nil
+}
+
+func (s *job) DeleteFinished(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	err := s.repo.DeleteFinished(ctx, time.Now().Add(-constans.JobRetention))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *job) Work(ctx context.Context, workers int) {
+	if workers <= 0 {
+		workers = constans.DefaultWorkerConcurrency
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// work leases and runs jobs one at a time, polling the queue while it is empty
+func (s *job) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		leased, err := s.lease()
+		if err != nil {
+			logger.Log.Error(err.Error())
+		}
+
+		if leased != nil {
+			s.run(*leased)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(constans.JobPollInterval):
+		}
+	}
+}
+
+func (s *job) lease() (*model.Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.contextTimeout)
+	defer cancel()
+
+	token, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return s.repo.Lease(ctx, token, now, now.Add(constans.JobVisibilityTimeout))
+}
+
+// run executes a leased job. The job gets its own context so a shutdown lets
+// it finish instead of cancelling it halfway.
+func (s *job) run(leased model.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), constans.JobTimeout)
+	defer cancel()
+
+	s.mu.RLock()
+	handler, ok := s.handlers[leased.Type]
+	s.mu.RUnlock()
+
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("no handler for job type %s", leased.Type)
+	case leased.Attempts > leased.MaxAttempts:
+		// the job lost its lease too often, e.g. its worker crashed while running it
+		err = fmt.Errorf("job lease expired after %d attempts", leased.MaxAttempts)
+	default:
+		err = s.handle(ctx, handler, leased)
+	}
+
+	// the outcome is stored even when the job used up its own timeout
+	ctx, cancelUpdate := context.WithTimeout(context.Background(), s.contextTimeout)
+	defer cancelUpdate()
+
+	if err == nil {
+		if err := s.repo.Complete(ctx, leased, time.Now()); err != nil {
+			logger.Log.Error(err.Error())
+		}
+		return
+	}
+
+	s.retry(ctx, leased, err)
+}
+
+// handle runs a job handler, a panicking job fails instead of stopping its worker
+func (s *job) handle(ctx context.Context, handler JobHandler, leased model.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, leased)
+}
+
+// retry schedules the next run of a failed job, doubling the delay after every
+// attempt, and moves it to the dead letters after its last attempt
+func (s *job) retry(ctx context.Context, failed model.Job, cause error) {
+	failed.LastError = cause.Error()
+	if len(failed.LastError) > maxEventError {
+		failed.LastError = failed.LastError[:maxEventError]
+	}
+
+	backoff := constans.JobBaseBackoff << uint(failed.Attempts-1)
+	if backoff <= 0 || backoff > constans.JobMaxBackoff {
+		backoff = constans.JobMaxBackoff
+	}
+	failed.RunAt = time.Now().Add(backoff)
+
+	failed.Status = constans.PENDING
+	if failed.Attempts >= failed.MaxAttempts {
+		failed.Status = constans.DEAD
+	}
+
+	logger.Log.Warn("job failed",
+		zap.Int64("job_id", failed.ID),
+		zap.String("type", failed.Type),
+		zap.Int("attempts", failed.Attempts),
+		zap.String("status", failed.Status),
+		zap.String("error", failed.LastError),
+	)
+
+	if err := s.repo.Fail(ctx, failed, time.Now()); err != nil {
+		logger.Log.Error(err.Error())
+	}
+}
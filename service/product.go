@@ -2,45 +2,84 @@ package service
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/cecepsprd/ticketing-api/constans"
 	"github.com/cecepsprd/ticketing-api/model"
 	"github.com/cecepsprd/ticketing-api/repository"
 	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
 	"github.com/cecepsprd/ticketing-api/utils/logger"
 )
 
 type ProductService interface {
-	Read(context.Context) ([]model.Product, error)
+	Read(ctx context.Context, filter model.ProductFilter) ([]model.Product, error)
 	Create(ctx context.Context, product model.ProductRequest) error
+	// Update changes the details of a product but not its stock, the stock is
+	// changed by UpdateStock so the tickets taken by checkouts are kept
 	Update(ctx context.Context, product model.ProductRequest) error
-	Delete(ctx context.Context, id int64) error
-	ReadByID(ctx context.Context, productID int64) (*model.Product, error)
+	Delete(ctx context.Context, id int64, force bool) error
+	Restore(ctx context.Context, id int64) error
+	// ReadByID returns a product with its price converted to currency, an empty currency skips the conversion
+	ReadByID(ctx context.Context, productID int64, currency string) (*model.Product, error)
+	UpdateStock(ctx context.Context, request model.StockRequest) error
 }
 
 type product struct {
-	repo           repository.ProductRepository
-	contextTimeout time.Duration
+	repo            repository.ProductRepository
+	categoryRepo    repository.CategoryRepository
+	sessionRepo     repository.SessionRepository
+	transactionRepo repository.TransactionRepository
+	waitlist        WaitlistService
+	exchange        ExchangeService
+	eventService    EventService
+	contextTimeout  time.Duration
 }
 
-func NewProductService(repo repository.ProductRepository, timeout time.Duration) ProductService {
+func NewProductService(repo repository.ProductRepository, categoryRepo repository.CategoryRepository, sessionRepo repository.SessionRepository, transactionRepo repository.TransactionRepository, ws WaitlistService, es ExchangeService, events EventService, timeout time.Duration) ProductService {
 	return &product{
-		repo:           repo,
-		contextTimeout: timeout,
+		repo:            repo,
+		categoryRepo:    categoryRepo,
+		sessionRepo:     sessionRepo,
+		transactionRepo: transactionRepo,
+		waitlist:        ws,
+		exchange:        es,
+		eventService:    events,
+		contextTimeout:  timeout,
 	}
 }
 
-func (s *product) Read(ctx context.Context) ([]model.Product, error) {
-	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+func (s *product) Read(ctx context.Context, filter model.ProductFilter) ([]model.Product, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
 	defer cancel()
 
-	products, err := s.repo.Read(ctx)
+	if filter.CategoryID != 0 {
+		categories, err := s.categoryRepo.Read(ctx)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+
+		// listing a category includes the products of all its sub categories
+		filter.CategoryIDs = descendantCategories(categories, filter.CategoryID)
+	}
+
+	// the tags are stored normalized
+	filter.Tag = normalizeTag(filter.Tag)
+
+	products, err := s.repo.Read(ctx, filter)
 	if err != nil {
 		logger.Log.Error(err.Error())
 		return nil, err
 	}
 
+	for i := range products {
+		if err := s.displayPrice(ctx, &products[i], filter.Currency); err != nil {
+			return nil, err
+		}
+	}
+
 	return products, nil
 }
 
@@ -62,6 +101,20 @@ func (s *product) Create(ctx context.Context, request model.ProductRequest) erro
 		product.ImageURL = constans.DefaultImage
 	}
 
+	product.Tags, err = normalizeTags(request.Tags)
+	if err != nil {
+		return err
+	}
+
+	product.Price, err = normalizePrice(request.Price)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkCategory(ctx, request.CategoryID); err != nil {
+		return err
+	}
+
 	err = s.repo.Create(ctx, product)
 	if err != nil {
 		logger.Log.Error(err.Error())
@@ -85,19 +138,91 @@ func (s *product) Update(ctx context.Context, request model.ProductRequest) erro
 		return err
 	}
 
+	product.ID = request.ID
+
+	product.Tags, err = normalizeTags(request.Tags)
+	if err != nil {
+		return err
+	}
+
+	product.Price, err = normalizePrice(request.Price)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkCategory(ctx, request.CategoryID); err != nil {
+		return err
+	}
+
+	current, err := s.repo.ReadByID(ctx, convert.Atoi(request.ID))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if current == nil || current.DeletedAt != nil {
+		return constans.ErrNotFound
+	}
+
 	err = s.repo.Update(ctx, product)
 	if err != nil {
 		logger.Log.Error(err.Error())
 		return err
 	}
 
+	if request.CascadeSessions {
+		err = s.sessionRepo.UpdateFutureCapacity(ctx, convert.Atoi(request.ID), request.Stock, time.Now())
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if err = s.offerSessions(ctx, convert.Atoi(request.ID)); err != nil {
+			return err
+		}
+
+		return recordStockUpdated(ctx, s.eventService, convert.Atoi(request.ID))
+	}
+
 	return nil
 }
 
-func (s *product) Delete(ctx context.Context, id int64) error {
+// Delete soft deletes a product. Products with paid tickets for an event or
+// session still to come are kept in the catalogue unless the deletion is forced.
+func (s *product) Delete(ctx context.Context, id int64, force bool) error {
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
+	if !force {
+		product, err := s.repo.ReadByID(ctx, id)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if product == nil || product.DeletedAt != nil {
+			return constans.ErrNotFound
+		}
+
+		// the tickets of sessions that ended are used up
+		paid, err := s.transactionRepo.CountUpcomingByProduct(ctx, id, constans.PAID, time.Now())
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		sessions, err := s.sessionRepo.Count(ctx, id)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		// sessions can run past the end date of the event, their own end counts
+		if paid > 0 && (sessions > 0 || !ended(*product)) {
+			return constans.ErrProductHasTickets
+		}
+	}
+
 	err := s.repo.Delete(ctx, id)
 	if err != nil {
 		logger.Log.Error(err.Error())
@@ -107,15 +232,177 @@ func (s *product) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (s *product) ReadByID(ctx context.Context, productID int64) (*model.Product, error) {
+func (s *product) Restore(ctx context.Context, id int64) error {
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
+	err := s.repo.Restore(ctx, id)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *product) ReadByID(ctx context.Context, productID int64, currency string) (*model.Product, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
 	product, err := s.repo.ReadByID(ctx, productID)
 	if err != nil {
 		logger.Log.Error(err.Error())
 		return nil, err
 	}
 
+	if product == nil || product.DeletedAt != nil {
+		return nil, constans.ErrNotFound
+	}
+
+	if err := s.displayPrice(ctx, product, currency); err != nil {
+		return nil, err
+	}
+
 	return product, nil
 }
+
+// displayPrice sets the price of a product converted to currency
+func (s *product) displayPrice(ctx context.Context, product *model.Product, currency string) error {
+	if currency == "" {
+		return nil
+	}
+
+	price, _, err := s.exchange.Convert(ctx, product.Price, currency)
+	if err != nil {
+		return err
+	}
+
+	product.DisplayPrice = &price
+
+	return nil
+}
+
+// UpdateStock adds the given quantity to the product stock, added tickets are
+// offered to the waitlist first
+func (s *product) UpdateStock(ctx context.Context, request model.StockRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	product, err := s.repo.ReadByID(ctx, request.ProductID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return constans.ErrNotFound
+	}
+
+	if product.Stock+request.Quantity < 0 {
+		return constans.ErrBadParamInput
+	}
+
+	err = s.repo.UpdateStock(ctx, request.ProductID, request.Quantity)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if request.Quantity > 0 {
+		if err = s.waitlist.OfferReleasedStock(ctx, request.ProductID, 0); err != nil {
+			return err
+		}
+	}
+
+	return recordStockUpdated(ctx, s.eventService, request.ProductID)
+}
+
+// ended reports whether the event of a product is over, the tickets sold
+// without a session are used up then. An end date that can not be read keeps
+// the event going.
+func ended(product model.Product) bool {
+	end, err := time.ParseInLocation(constans.DateTimeFormat, product.EndDate, time.Local)
+	return err == nil && end.Before(time.Now())
+}
+
+// offerSessions offers the tickets the upcoming sessions of a product gained to their waitlists
+func (s *product) offerSessions(ctx context.Context, productID int64) error {
+	sessions, err := s.sessionRepo.ReadByProduct(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	for _, session := range sessions {
+		if !session.StartDate.After(time.Now()) {
+			continue
+		}
+
+		if err = s.waitlist.OfferReleasedStock(ctx, productID, session.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalizeTags lower cases and trims the given tags and removes duplicates.
+// The tags of a product are read back joined by commas, so a tag can not hold one.
+func normalizeTags(tags []string) ([]string, error) {
+	var (
+		result []string
+		seen   = make(map[string]bool)
+	)
+
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if strings.Contains(tag, ",") {
+			return nil, constans.ErrTagInvalid
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	return result, nil
+}
+
+// normalizeTag lower cases and trims a tag
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// checkCategory makes sure the category of a product exists, no category is fine
+func (s *product) checkCategory(ctx context.Context, categoryID int64) error {
+	if categoryID == 0 {
+		return nil
+	}
+
+	category, err := s.categoryRepo.ReadByID(ctx, categoryID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if category == nil {
+		return constans.ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// normalizePrice defaults the currency of a price and checks it is a supported ISO 4217 code
+func normalizePrice(price model.Money) (model.Money, error) {
+	if price.Currency == "" {
+		price.Currency = constans.DefaultCurrency
+	}
+
+	price = model.NewMoney(price.Amount, price.Currency)
+	if price.Amount < 0 || !model.ValidCurrency(price.Currency) {
+		return model.Money{}, constans.ErrBadParamInput
+	}
+
+	return price, nil
+}
@@ -0,0 +1,42 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository/memory"
+	"github.com/cecepsprd/ticketing-api/service"
+)
+
+func TestProductTagFilter(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	products := service.NewProductService(memory.NewProductRepository(store), memory.NewCategoryRepository(store), memory.NewSessionRepository(store),
+		memory.NewTransactionRepository(store), nil, nil, nil, time.Second)
+
+	for name, tags := range map[string][]string{"concert": {" Rock ", "Live"}, "recital": {"jazz"}} {
+		err := products.Create(ctx, model.ProductRequest{
+			Name:        name,
+			Description: name,
+			Price:       model.Money{Amount: 100000, Currency: constans.DefaultCurrency},
+			Tags:        tags,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the filter matches the tags however they were typed
+	for _, tag := range []string{"rock", " ROCK ", "Rock"} {
+		found, err := products.Read(ctx, model.ProductFilter{Tag: tag})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 1 || found[0].Name != "concert" {
+			t.Fatalf("got %+v for tag %q, want the concert", found, tag)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/cecepsprd/ticketing-api/utils/notification"
+	"github.com/veritrans/go-midtrans"
+	"go.uber.org/zap"
+)
+
+type ReconciliationService interface {
+	// Reconcile checks the transactions pending for longer than the reconcile delay
+	// against the payment gateway and applies the status changes it missed
+	Reconcile(context.Context) ([]model.Reconciliation, error)
+	// Report sums up the reconciliations of the day of date
+	Report(ctx context.Context, date time.Time) (*model.ReconciliationReport, error)
+	// SendReport sends the report of the day of date to the admins
+	SendReport(ctx context.Context, date time.Time) error
+}
+
+type reconciliation struct {
+	repo               repository.ReconciliationRepository
+	transactionRepo    repository.TransactionRepository
+	userRepo           repository.UserRepository
+	transactionService TransactionService
+	notifier           notification.Notifier
+	status             func(orderID string) (midtrans.Response, error)
+	after              time.Duration
+	expireAfter        time.Duration
+	contextTimeout     time.Duration
+}
+
+func NewReconciliationService(repo repository.ReconciliationRepository, transactionRepo repository.TransactionRepository, userRepo repository.UserRepository, ts TransactionService, notifier notification.Notifier, after time.Duration, expireAfter time.Duration, timeout time.Duration) ReconciliationService {
+	if after <= 0 {
+		after = constans.DefaultReconcileAfter
+	}
+
+	if expireAfter <= 0 {
+		expireAfter = constans.DefaultReconcileExpireAfter
+	}
+
+	return &reconciliation{
+		repo:               repo,
+		transactionRepo:    transactionRepo,
+		userRepo:           userRepo,
+		transactionService: ts,
+		notifier:           notifier,
+		status:             midtransStatus,
+		after:              after,
+		expireAfter:        expireAfter,
+		contextTimeout:     timeout,
+	}
+}
+
+// midtransStatus reads the status of an order from the midtrans status API
+func midtransStatus(orderID string) (midtrans.Response, error) {
+	coreGateway := midtrans.CoreGateway{
+		Client: midtransClient(),
+	}
+
+	return coreGateway.Status(orderID)
+}
+
+func (s *reconciliation) Reconcile(ctx context.Context) ([]model.Reconciliation, error) {
+	readCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	// a mismatch or a failed check is reported once and left for a review, so
+	// the batch is not filled up with the same unresolved transactions
+	pending, err := s.transactionRepo.ReadUnreconciled(readCtx, time.Now().Add(-s.after), constans.ReconcileBatchSize)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	var result []model.Reconciliation
+	for _, transaction := range pending {
+		// the transactions left are checked on the next run
+		if ctx.Err() != nil {
+			break
+		}
+
+		r := s.check(ctx, transaction)
+		if r == nil {
+			continue
+		}
+
+		if len(r.Message) > maxEventError {
+			r.Message = r.Message[:maxEventError]
+		}
+
+		saved, err := s.repo.Create(ctx, *r)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return result, err
+		}
+
+		if saved.Result != constans.APPLIED {
+			logger.Log.Warn("payment reconciliation needs attention",
+				zap.Int64("transaction_id", saved.TransactionID),
+				zap.String("result", saved.Result),
+				zap.String("message", saved.Message),
+			)
+		}
+
+		result = append(result, *saved)
+	}
+
+	return result, nil
+}
+
+// check compares a pending transaction with its status at the payment gateway and
+// settles it when the status changed. It returns nil when there is nothing to
+// record, i.e. the payment is still open.
+func (s *reconciliation) check(ctx context.Context, transaction model.Transaction) *model.Reconciliation {
+	r := &model.Reconciliation{
+		TransactionID:  transaction.ID,
+		PreviousStatus: transaction.Status,
+		Status:         transaction.Status,
+		Currency:       transaction.Amount.Currency,
+		// midtrans is charged the total in whole units
+		ExpectedAmount: transaction.Amount.Whole() * model.MinorUnits(transaction.Amount.Currency),
+	}
+
+	resp, err := s.status(strconv.FormatInt(transaction.ID, 10))
+	if err != nil {
+		r.Result = constans.FAILED
+		r.Message = err.Error()
+		return r
+	}
+
+	request := model.UpdateTransactionRequest{
+		TransactionStatus: resp.TransactionStatus,
+		OrderID:           strconv.FormatInt(transaction.ID, 10),
+		PaymentType:       resp.PaymentType,
+		FraudStatus:       resp.FraudStatus,
+		StatusCode:        resp.StatusCode,
+		GrossAmount:       resp.GrossAmount,
+	}
+
+	switch {
+	case resp.StatusCode == "404":
+		// the customer never opened the payment page, the order is given up once
+		// the payment page expired
+		if time.Since(transaction.CreatedAt) < s.expireAfter {
+			return nil
+		}
+
+		r.ProviderStatus = "not_found"
+		request.TransactionStatus = "expire"
+	case resp.TransactionStatus == "" || resp.TransactionStatus == "pending":
+		return nil
+	default:
+		r.ProviderStatus = resp.TransactionStatus
+
+		amount, ok := providerAmount(resp.GrossAmount, transaction.Amount.Currency)
+		r.ProviderAmount = amount
+		if !ok || amount != r.ExpectedAmount {
+			r.Result = constans.MISMATCH
+			r.Message = fmt.Sprintf("payment gateway reports an amount of %s", resp.GrossAmount)
+			return r
+		}
+	}
+
+	if err = s.transactionService.Settle(ctx, request); err != nil {
+		r.Result = constans.FAILED
+		r.Message = err.Error()
+		return r
+	}
+
+	settled, err := s.transactionRepo.ReadByID(ctx, transaction.ID)
+	if err != nil {
+		r.Result = constans.FAILED
+		r.Message = err.Error()
+		return r
+	}
+
+	// e.g. a card payment challenged by the fraud detection is still under review
+	if settled.Status == transaction.Status {
+		return nil
+	}
+
+	r.Status = settled.Status
+	r.Result = constans.APPLIED
+	r.Message = fmt.Sprintf("missed %s notification applied", r.ProviderStatus)
+
+	return r
+}
+
+func (s *reconciliation) Report(ctx context.Context, date time.Time) (*model.ReconciliationReport, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	reconciliations, err := s.repo.ReadBetween(ctx, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	report := &model.ReconciliationReport{
+		Date:            start.Format(constans.DateFormat),
+		Reconciliations: reconciliations,
+	}
+
+	for _, r := range reconciliations {
+		switch r.Result {
+		case constans.APPLIED:
+			report.Applied++
+			if r.Status == constans.PAID {
+				report.Paid++
+			} else if r.Status == constans.CANCELLED {
+				report.Cancelled++
+			}
+		case constans.MISMATCH:
+			report.Mismatches++
+		case constans.FAILED:
+			report.Failures++
+		}
+	}
+
+	return report, nil
+}
+
+func (s *reconciliation) SendReport(ctx context.Context, date time.Time) error {
+	report, err := s.Report(ctx, date)
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Info("payment reconciliation report",
+		zap.String("date", report.Date),
+		zap.Int("applied", report.Applied),
+		zap.Int("mismatches", report.Mismatches),
+		zap.Int("failures", report.Failures),
+	)
+
+	if len(report.Reconciliations) == 0 {
+		return nil
+	}
+
+	users, err := s.userRepo.Read(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	subject := fmt.Sprintf("Payment reconciliation report %s", report.Date)
+	message := fmt.Sprintf("%d missed payment notifications were applied (%d paid, %d cancelled), %d amount mismatches and %d failed checks need a review.",
+		report.Applied, report.Paid, report.Cancelled, report.Mismatches, report.Failures)
+
+	for _, user := range users {
+		if user.Roles != "admin" {
+			continue
+		}
+
+		if err := s.notifier.Notify(ctx, user, subject, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// providerAmount converts a gross amount reported by the payment gateway to minor units
+func providerAmount(gross string, currency string) (int64, bool) {
+	amount, ok := new(big.Rat).SetString(gross)
+	if !ok {
+		return 0, false
+	}
+
+	amount.Mul(amount, new(big.Rat).SetInt64(model.MinorUnits(currency)))
+	if !amount.IsInt() {
+		return 0, false
+	}
+
+	return amount.Num().Int64(), true
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository/memory"
+	"github.com/veritrans/go-midtrans"
+)
+
+func TestReconcileMismatchOnce(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	repo := memory.NewReconciliationRepository(store)
+	transactionRepo := memory.NewTransactionRepository(store)
+
+	transaction, err := transactionRepo.Create(ctx, model.Transaction{
+		ProductID: 1,
+		UserID:    1,
+		Amount:    model.Money{Amount: 100000, Currency: constans.DefaultCurrency},
+		Breakdown: model.PriceBreakdown{Currency: constans.DefaultCurrency, UnitPrice: 100000, Quantity: 1, Subtotal: 100000, Total: 100000},
+		Status:    constans.PENDING,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewReconciliationService(repo, transactionRepo, nil, nil, nil, time.Nanosecond, 0, time.Second).(*reconciliation)
+	s.status = func(orderID string) (midtrans.Response, error) {
+		return midtrans.Response{StatusCode: "200", TransactionStatus: "settlement", GrossAmount: "90000.00"}, nil
+	}
+
+	time.Sleep(time.Millisecond)
+
+	first, err := s.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 || first[0].TransactionID != transaction.ID || first[0].Result != constans.MISMATCH {
+		t.Fatalf("got %+v, want the mismatch of transaction %d", first, transaction.ID)
+	}
+
+	// the mismatch waits for a review, the next run does not record it again
+	second, err := s.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 0 {
+		t.Fatalf("got %+v, want nothing reconciled again", second)
+	}
+
+	recorded, err := repo.ReadBetween(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 1 {
+		t.Fatalf("got %d reconciliations, want the mismatch once", len(recorded))
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils"
+	"github.com/cecepsprd/ticketing-api/utils/convert"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+)
+
+// SeedService fills a database with demo data. Seeding is idempotent, users and
+// products are matched by name and transactions are topped up to the volume.
+type SeedService interface {
+	Seed(ctx context.Context, fixture model.Fixture) (*model.SeedResult, error)
+}
+
+type seed struct {
+	uow             repository.UnitOfWork
+	userRepo        repository.UserRepository
+	productRepo     repository.ProductRepository
+	transactionRepo repository.TransactionRepository
+	tickets         TicketService
+	contextTimeout  time.Duration
+}
+
+func NewSeedService(uow repository.UnitOfWork, userRepo repository.UserRepository, productRepo repository.ProductRepository, transactionRepo repository.TransactionRepository, tickets TicketService, timeout time.Duration) SeedService {
+	return &seed{
+		uow:             uow,
+		userRepo:        userRepo,
+		productRepo:     productRepo,
+		transactionRepo: transactionRepo,
+		tickets:         tickets,
+		contextTimeout:  timeout,
+	}
+}
+
+// Seed creates the records of the fixture that do not exist yet. Every record
+// gets its own timeout, a large volume of transactions can take a while.
+func (s *seed) Seed(ctx context.Context, fixture model.Fixture) (*model.SeedResult, error) {
+	result := &model.SeedResult{}
+
+	if fixture.Admin.Username != "" {
+		if _, err := s.user(ctx, fixture.Admin, "admin", result); err != nil {
+			return result, err
+		}
+	}
+
+	var buyers []model.User
+	for _, request := range fixture.Users {
+		buyer, err := s.user(ctx, request, "user", result)
+		if err != nil {
+			return result, err
+		}
+		buyers = append(buyers, buyer)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	for _, request := range fixture.Products {
+		product, err := s.product(ctx, request, today, result)
+		if err != nil {
+			return result, err
+		}
+
+		if fixture.Transactions.PerProduct <= 0 || len(buyers) == 0 {
+			continue
+		}
+
+		if err = s.transactions(ctx, product, buyers, fixture.Transactions, result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func (s *seed) user(ctx context.Context, request model.SeedUser, roles string, result *model.SeedResult) (model.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	user, err := s.userRepo.ReadByUsername(ctx, request.Username)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return model.User{}, err
+	}
+
+	// existing users are left untouched, a re-run must not reset their password
+	if user.ID != 0 {
+		return user, nil
+	}
+
+	password, err := utils.HashPassword(request.Password)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	created, err := s.userRepo.Create(ctx, model.User{
+		Username: request.Username,
+		Password: password,
+		Email:    request.Email,
+		Phone:    request.Phone,
+		Address:  request.Address,
+		Roles:    roles,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return model.User{}, err
+	}
+
+	result.Users++
+
+	return *created, nil
+}
+
+func (s *seed) product(ctx context.Context, request model.SeedProduct, today time.Time, result *model.SeedResult) (*model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	product, err := s.productRepo.ReadByName(ctx, request.Name)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if product != nil {
+		return product, nil
+	}
+
+	price, err := normalizePrice(model.NewMoney(request.Price, request.Currency))
+	if err != nil {
+		return nil, fmt.Errorf("product %s: %w", request.Name, err)
+	}
+
+	startTime, err := time.Parse("15:04", request.StartTime)
+	if err != nil && request.StartTime != "" {
+		return nil, fmt.Errorf("product %s: invalid start_time %s", request.Name, request.StartTime)
+	}
+
+	start := today.AddDate(0, 0, request.StartsIn).Add(time.Duration(startTime.Hour())*time.Hour + time.Duration(startTime.Minute())*time.Minute)
+	end := start.Add(time.Duration(request.Duration) * time.Hour)
+
+	tags, err := normalizeTags(request.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("product %s: %w", request.Name, err)
+	}
+
+	imageURL := request.ImageURL
+	if imageURL == "" {
+		imageURL = constans.DefaultImage
+	}
+
+	err = s.productRepo.Create(ctx, model.Product{
+		Name:             request.Name,
+		Description:      request.Description,
+		Price:            price,
+		Stock:            request.Stock,
+		ImageURL:         imageURL,
+		StartDate:        start.Format(constans.DateTimeFormat),
+		EndDate:          end.Format(constans.DateTimeFormat),
+		Tags:             tags,
+		OrganizerName:    request.OrganizerName,
+		OrganizerContact: request.OrganizerContact,
+		Location:         request.Location,
+		Address:          request.Address,
+		Latitude:         request.Latitude,
+		Longitude:        request.Longitude,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	result.Products++
+
+	return s.productRepo.ReadByName(ctx, request.Name)
+}
+
+// transactions tops the paid transactions of a product up to the configured
+// volume, the buyers take turns and every ticket is taken from the stock
+func (s *seed) transactions(ctx context.Context, product *model.Product, buyers []model.User, volume model.SeedTransactions, result *model.SeedResult) error {
+	productID := convert.Atoi(product.ID)
+
+	paid, err := s.transactionRepo.CountByProduct(ctx, productID, constans.PAID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	for i := int(paid); i < volume.PerProduct; i++ {
+		created, err := s.transaction(ctx, productID, buyers[i%len(buyers)])
+		if err != nil {
+			return err
+		}
+
+		// the product sold out
+		if created == nil {
+			return nil
+		}
+
+		result.Transactions++
+
+		if !volume.Tickets {
+			continue
+		}
+
+		if err = s.tickets.Generate(ctx, created.ID); err != nil {
+			return err
+		}
+
+		result.Tickets++
+	}
+
+	return nil
+}
+
+func (s *seed) transaction(ctx context.Context, productID int64, buyer model.User) (created *model.Transaction, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		product, err := s.productRepo.ReadByIDForUpdate(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if product.Stock <= 0 {
+			return nil
+		}
+
+		created, err = s.transactionRepo.Create(ctx, model.Transaction{
+			ProductID:     productID,
+			UserID:        buyer.ID,
+			Amount:        product.Price,
+			PaymentMethod: constans.SeedPaymentMethod,
+			Breakdown: model.PriceBreakdown{
+				Currency:  product.Price.Currency,
+				UnitPrice: product.Price.Amount,
+				Quantity:  1,
+				Subtotal:  product.Price.Amount,
+				Total:     product.Price.Amount,
+			},
+			Status: constans.PAID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.productRepo.UpdateStock(ctx, productID, -1)
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return created, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/cecepsprd/ticketing-api/utils/recurrence"
+)
+
+type SessionService interface {
+	Create(ctx context.Context, request model.SessionRequest) error
+	ReadByProduct(ctx context.Context, productID int64) ([]model.Session, error)
+	Update(ctx context.Context, request model.UpdateSessionRequest) error
+	Delete(ctx context.Context, productID int64, sessionID int64) error
+}
+
+type session struct {
+	uow            repository.UnitOfWork
+	repo           repository.SessionRepository
+	productRepo    repository.ProductRepository
+	seatRepo       repository.SeatRepository
+	waitlist       WaitlistService
+	eventService   EventService
+	contextTimeout time.Duration
+}
+
+func NewSessionService(uow repository.UnitOfWork, repo repository.SessionRepository, productRepo repository.ProductRepository, seatRepo repository.SeatRepository, ws WaitlistService, events EventService, timeout time.Duration) SessionService {
+	return &session{
+		uow:            uow,
+		repo:           repo,
+		productRepo:    productRepo,
+		seatRepo:       seatRepo,
+		waitlist:       ws,
+		eventService:   events,
+		contextTimeout: timeout,
+	}
+}
+
+// Create adds sessions to a product. A seat is held for the whole event, so a
+// product with a seat map can not have sessions.
+func (s *session) Create(ctx context.Context, request model.SessionRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	items, err := expandSessions(request)
+	if err != nil {
+		return err
+	}
+
+	sessions := make([]model.Session, 0, len(items))
+	for _, item := range items {
+		if !item.EndDate.After(item.StartDate) {
+			return constans.ErrBadParamInput
+		}
+
+		sessions = append(sessions, model.Session{
+			ProductID: request.ProductID,
+			StartDate: item.StartDate,
+			EndDate:   item.EndDate,
+			Capacity:  item.Capacity,
+		})
+	}
+
+	// the product stays locked so a seat map can not be attached meanwhile
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		product, err := s.productRepo.ReadByIDForUpdate(ctx, request.ProductID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if product == nil || product.DeletedAt != nil {
+			return constans.ErrNotFound
+		}
+
+		seats, err := s.seatRepo.CountSeatMap(ctx, request.ProductID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if seats > 0 {
+			return constans.ErrSessionsHaveSeats
+		}
+
+		err = s.repo.Create(ctx, sessions)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		return recordStockUpdated(ctx, s.eventService, request.ProductID)
+	})
+}
+
+func (s *session) ReadByProduct(ctx context.Context, productID int64) ([]model.Session, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	sessions, err := s.repo.ReadByProduct(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (s *session) Update(ctx context.Context, request model.UpdateSessionRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	session, err := s.repo.ReadByID(ctx, request.ID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if session == nil || session.ProductID != request.ProductID {
+		return constans.ErrNotFound
+	}
+
+	if !request.EndDate.After(request.StartDate) {
+		return constans.ErrBadParamInput
+	}
+
+	err = s.repo.Update(ctx, model.Session{
+		ID:        request.ID,
+		StartDate: request.StartDate,
+		EndDate:   request.EndDate,
+		Capacity:  request.Capacity,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	// a larger session offers the tickets it gained to its waitlist first
+	if request.Capacity > session.Capacity {
+		if err = s.waitlist.OfferReleasedStock(ctx, session.ProductID, session.ID); err != nil {
+			return err
+		}
+	}
+
+	return recordStockUpdated(ctx, s.eventService, session.ProductID)
+}
+
+func (s *session) Delete(ctx context.Context, productID int64, sessionID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	session, err := s.repo.ReadByID(ctx, sessionID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if session == nil || session.ProductID != productID {
+		return constans.ErrNotFound
+	}
+
+	// sessions with sold tickets have to stay for the ticket holders
+	if session.Stock < session.Capacity {
+		return constans.ErrConflict
+	}
+
+	err = s.repo.Delete(ctx, sessionID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return recordStockUpdated(ctx, s.eventService, productID)
+}
+
+// expandSessions returns the explicit sessions of the request followed by the
+// occurrences of its recurrence rule
+func expandSessions(request model.SessionRequest) ([]model.SessionItem, error) {
+	items := append([]model.SessionItem{}, request.Sessions...)
+
+	if request.Recurrence != "" {
+		if request.First == nil {
+			return nil, constans.ErrBadParamInput
+		}
+
+		rule, err := recurrence.Parse(request.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+
+		duration := request.First.EndDate.Sub(request.First.StartDate)
+		for _, start := range rule.Expand(request.First.StartDate) {
+			items = append(items, model.SessionItem{
+				StartDate: start,
+				EndDate:   start.Add(duration),
+				Capacity:  request.First.Capacity,
+			})
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, constans.ErrBadParamInput
+	}
+
+	return items, nil
+}
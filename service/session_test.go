@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository/memory"
+	"github.com/cecepsprd/ticketing-api/service"
+	"github.com/cecepsprd/ticketing-api/utils/eventbus"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.Log = zap.NewNop()
+}
+
+// seatedEvent is an event with a venue of two seats, ready to get either a
+// seat map or sessions
+type seatedEvent struct {
+	venues    service.VenueService
+	sessions  service.SessionService
+	productID int64
+	venueID   int64
+}
+
+func newSeatedEvent(t *testing.T) seatedEvent {
+	ctx := context.Background()
+	store := memory.NewStore()
+	uow := memory.NewUnitOfWork(store)
+	productRepo := memory.NewProductRepository(store)
+	seatRepo := memory.NewSeatRepository(store)
+	sessionRepo := memory.NewSessionRepository(store)
+	events := service.NewEventService(memory.NewOutboxRepository(store), eventbus.New(), time.Second)
+
+	venues := service.NewVenueService(uow, memory.NewVenueRepository(store), seatRepo, productRepo, sessionRepo, events, time.Second)
+	sessions := service.NewSessionService(uow, sessionRepo, productRepo, seatRepo, nil, events, time.Second)
+
+	err := productRepo.Create(ctx, model.Product{Name: "concert", Price: model.Money{Amount: 100000, Currency: constans.DefaultCurrency}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product, err := productRepo.ReadByName(ctx, "concert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	productID, err := strconv.ParseInt(product.ID, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	venue, err := venues.Create(ctx, model.VenueRequest{Name: "arena", Address: "jakarta"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = venues.CreateSeats(ctx, model.SeatRequest{VenueID: venue.ID, Section: "A", Row: "1", Numbers: []int64{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return seatedEvent{venues: venues, sessions: sessions, productID: productID, venueID: venue.ID}
+}
+
+func sessionRequest(productID int64) model.SessionRequest {
+	start := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	return model.SessionRequest{
+		ProductID: productID,
+		Sessions:  []model.SessionItem{{StartDate: start, EndDate: start.Add(2 * time.Hour), Capacity: 10}},
+	}
+}
+
+func TestSeatMapOnEventWithSessions(t *testing.T) {
+	ctx := context.Background()
+	event := newSeatedEvent(t)
+
+	if err := event.sessions.Create(ctx, sessionRequest(event.productID)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := event.venues.CreateSeatMap(ctx, model.SeatMapRequest{ProductID: event.productID, VenueID: event.venueID})
+	if !errors.Is(err, constans.ErrSeatMapHasSessions) {
+		t.Fatalf("got %v, want %v", err, constans.ErrSeatMapHasSessions)
+	}
+
+	seats, err := event.venues.ReadSeatAvailability(ctx, event.productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seats) != 0 {
+		t.Fatalf("got %d seats, want none", len(seats))
+	}
+}
+
+func TestSessionsOnSeatedEvent(t *testing.T) {
+	ctx := context.Background()
+	event := newSeatedEvent(t)
+
+	if err := event.venues.CreateSeatMap(ctx, model.SeatMapRequest{ProductID: event.productID, VenueID: event.venueID}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := event.sessions.Create(ctx, sessionRequest(event.productID))
+	if !errors.Is(err, constans.ErrSessionsHaveSeats) {
+		t.Fatalf("got %v, want %v", err, constans.ErrSessionsHaveSeats)
+	}
+
+	sessions, err := event.sessions.ReadByProduct(ctx, event.productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("got %d sessions, want none", len(sessions))
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/eventbus"
+	"github.com/cecepsprd/ticketing-api/utils/notification"
+)
+
+// SubscribeNotifications sends the customer notifications triggered by domain events
+func SubscribeNotifications(bus eventbus.Bus, userRepo repository.UserRepository, productRepo repository.ProductRepository, notifier notification.Notifier) {
+	bus.Subscribe(constans.TransactionPaid, func(ctx context.Context, event model.Event) error {
+		var transaction model.Transaction
+		if err := json.Unmarshal(event.Payload, &transaction); err != nil {
+			return err
+		}
+
+		user, err := userRepo.ReadByID(ctx, transaction.UserID)
+		if err != nil {
+			return err
+		}
+
+		product, err := productRepo.ReadByID(ctx, transaction.ProductID)
+		if err != nil || product == nil {
+			return err
+		}
+
+		message := fmt.Sprintf("Your payment of %s for %s is confirmed, order number %d.",
+			transaction.Amount, product.Name, transaction.ID)
+
+		return notifier.Notify(ctx, user, "Your tickets are confirmed", message)
+	})
+}
+
+// SubscribeWebhooks schedules the webhook deliveries of the events partners can subscribe to
+func SubscribeWebhooks(bus eventbus.Bus, webhooks WebhookService) {
+	for _, eventType := range WebhookEvents {
+		bus.Subscribe(eventType, webhooks.Enqueue)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/cecepsprd/ticketing-api/utils/ticket"
+)
+
+type TicketService interface {
+	// Generate writes the PDF ticket of a paid transaction
+	Generate(ctx context.Context, transactionID int64) error
+	// Read returns the path of the PDF ticket of a paid transaction, generating it when it
+	// was not generated yet. Users only get their own tickets.
+	Read(ctx context.Context, transactionID int64, user model.User) (string, error)
+}
+
+type tickets struct {
+	transactionRepo repository.TransactionRepository
+	productRepo     repository.ProductRepository
+	sessionRepo     repository.SessionRepository
+	seatRepo        repository.SeatRepository
+	userRepo        repository.UserRepository
+	dir             string
+	contextTimeout  time.Duration
+}
+
+func NewTicketService(transactionRepo repository.TransactionRepository, productRepo repository.ProductRepository, sessionRepo repository.SessionRepository, seatRepo repository.SeatRepository, userRepo repository.UserRepository, dir string, timeout time.Duration) TicketService {
+	if dir == "" {
+		dir = constans.DefaultTicketDir
+	}
+
+	return &tickets{
+		transactionRepo: transactionRepo,
+		productRepo:     productRepo,
+		sessionRepo:     sessionRepo,
+		seatRepo:        seatRepo,
+		userRepo:        userRepo,
+		dir:             dir,
+		contextTimeout:  timeout,
+	}
+}
+
+func (s *tickets) Generate(ctx context.Context, transactionID int64) error {
+	transaction, err := s.transactionRepo.ReadByID(ctx, transactionID)
+	if err != nil {
+		return err
+	}
+
+	// a ticket is only issued once the transaction is paid
+	if transaction.Status != constans.PAID {
+		return nil
+	}
+
+	fields, err := s.fields(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(s.dir, 0755); err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	// write to a temporary file first so a download never sees half a ticket
+	tmp, err := ioutil.TempFile(s.dir, "ticket-*.tmp")
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(ticket.Render("E-Ticket", fields)); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path(transactionID))
+}
+
+func (s *tickets) Read(ctx context.Context, transactionID int64, user model.User) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	transaction, err := s.transactionRepo.ReadByID(ctx, transactionID)
+	if err != nil {
+		return "", err
+	}
+
+	if transaction.UserID != user.ID && user.Roles != "admin" {
+		return "", constans.ErrNotFound
+	}
+
+	if transaction.Status != constans.PAID {
+		return "", constans.ErrTicketNotIssued
+	}
+
+	path := s.path(transactionID)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if err := s.Generate(ctx, transactionID); err != nil {
+		logger.Log.Error(err.Error())
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (s *tickets) path(transactionID int64) string {
+	return filepath.Join(s.dir, fmt.Sprintf(constans.TicketFileName, transactionID))
+}
+
+// fields are the details printed on the ticket of a transaction
+func (s *tickets) fields(ctx context.Context, transaction *model.Transaction) ([]ticket.Field, error) {
+	product, err := s.productRepo.ReadByID(ctx, transaction.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	user, err := s.userRepo.ReadByID(ctx, transaction.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	date := product.StartDate
+	if transaction.SessionID != 0 {
+		session, err := s.sessionRepo.ReadByID(ctx, transaction.SessionID)
+		if err != nil {
+			return nil, err
+		}
+
+		if session != nil {
+			date = session.StartDate.Format("Monday, 02 January 2006 15:04")
+		}
+	}
+
+	seats, err := s.seatRepo.ReadSeatMap(ctx, transaction.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sold []string
+	for _, seat := range seats {
+		if seat.TransactionID == transaction.ID && seat.Status == constans.SOLD {
+			sold = append(sold, fmt.Sprintf("%s %s-%d", seat.Section, seat.Row, seat.Number))
+		}
+	}
+
+	admission := "General admission"
+	if len(sold) > 0 {
+		admission = strings.Join(sold, ", ")
+	}
+
+	return []ticket.Field{
+		{Label: "Event", Value: product.Name},
+		{Label: "Date", Value: date},
+		{Label: "Location", Value: strings.TrimSpace(product.Location + " " + product.Address)},
+		{Label: "Seats", Value: admission},
+		{Label: "Quantity", Value: strconv.FormatInt(transaction.Breakdown.Quantity, 10)},
+		{Label: "Order number", Value: strconv.FormatInt(transaction.ID, 10)},
+		{Label: "Name", Value: user.Username},
+		{Label: "Total paid", Value: transaction.Amount.String()},
+		{Label: "Issued at", Value: time.Now().Format(time.RFC1123)},
+	}, nil
+}
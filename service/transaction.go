@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"reflect"
 	"strconv"
@@ -12,25 +15,54 @@ import (
 	"github.com/cecepsprd/ticketing-api/repository"
 	"github.com/cecepsprd/ticketing-api/utils/convert"
 	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/cecepsprd/ticketing-api/utils/pricing"
 	"github.com/spf13/viper"
 	"github.com/veritrans/go-midtrans"
+	"go.uber.org/zap"
 )
 
 type TransactionService interface {
 	Checkout(ctx context.Context, request model.CreateTransactionRequest) (*model.Transaction, error)
+	Quote(ctx context.Context, request model.CreateTransactionRequest) (*model.Quote, error)
+	Update(ctx context.Context, request model.UpdateTransactionRequest) error
+	// Settle applies a payment status to a transaction like a payment notification
+	// does, the caller is responsible for the status coming from the payment gateway
+	Settle(ctx context.Context, request model.UpdateTransactionRequest) error
+	ReadByID(ctx context.Context, transactionID int64) (*model.Transaction, error)
+	// Cancel cancels a pending transaction like an expired payment, paid
+	// transactions have to be refunded through the payment gateway instead
+	Cancel(ctx context.Context, transactionID int64) error
 }
 
 type transaction struct {
-	transactionRepo repository.TransactionRepository
-	productRepo     repository.ProductRepository
-	contextTimeout  time.Duration
+	uow                repository.UnitOfWork
+	transactionRepo    repository.TransactionRepository
+	productRepo        repository.ProductRepository
+	seatRepo           repository.SeatRepository
+	sessionRepo        repository.SessionRepository
+	waitlistService    WaitlistService
+	waitingRoomService WaitingRoomService
+	voucherService     VoucherService
+	exchangeService    ExchangeService
+	eventService       EventService
+	pricing            pricing.Rules
+	contextTimeout     time.Duration
 }
 
-func NewTransactionService(transactionRepo repository.TransactionRepository, productRepo repository.ProductRepository, timeout time.Duration) TransactionService {
+func NewTransactionService(uow repository.UnitOfWork, transactionRepo repository.TransactionRepository, productRepo repository.ProductRepository, seatRepo repository.SeatRepository, sessionRepo repository.SessionRepository, ws WaitlistService, wr WaitingRoomService, vs VoucherService, es ExchangeService, events EventService, rules pricing.Rules, timeout time.Duration) TransactionService {
 	return &transaction{
-		transactionRepo: transactionRepo,
-		productRepo:     productRepo,
-		contextTimeout:  timeout,
+		uow:                uow,
+		transactionRepo:    transactionRepo,
+		productRepo:        productRepo,
+		seatRepo:           seatRepo,
+		sessionRepo:        sessionRepo,
+		waitlistService:    ws,
+		waitingRoomService: wr,
+		voucherService:     vs,
+		exchangeService:    es,
+		eventService:       events,
+		pricing:            rules,
+		contextTimeout:     timeout,
 	}
 }
 
@@ -38,57 +70,320 @@ func (s *transaction) Checkout(ctx context.Context, req model.CreateTransactionR
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
-	product, err := s.productRepo.ReadByID(ctx, req.ProductID)
+	// a product behind a waiting room only sells to the buyers it let in
+	if err := s.waitingRoomService.CheckAdmission(ctx, req.ProductID, req.User.ID, req.AdmissionToken); err != nil {
+		return nil, err
+	}
+
+	var (
+		trx   *model.Transaction
+		order *order
+	)
+
+	// the tickets are reserved all at once, a failing step leaves nothing behind
+	err := s.uow.Do(ctx, func(ctx context.Context) (err error) {
+		order, err = s.prepare(ctx, req, true)
+		if err != nil {
+			return err
+		}
+
+		// the tickets leave the stock as soon as they are held, a checkout that is
+		// never paid gives them back when it is cancelled or expires
+		err = s.takeStock(ctx, req.ProductID, req.SessionID, order.breakdown.Quantity)
+		if err != nil {
+			return err
+		}
+
+		trx, err = s.transactionRepo.Create(ctx, model.Transaction{
+			ProductID:     req.ProductID,
+			SessionID:     req.SessionID,
+			UserID:        req.User.ID,
+			Status:        constans.PENDING,
+			Amount:        order.breakdown.TotalMoney(),
+			PaymentMethod: req.PaymentMethod,
+			Breakdown:     order.breakdown,
+			Exchange:      order.exchange,
+		})
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if order.voucher != nil {
+			err = s.voucherService.Redeem(ctx, model.VoucherRedemption{
+				VoucherID:     order.voucher.ID,
+				UserID:        req.User.ID,
+				TransactionID: trx.ID,
+				Discount:      order.breakdown.Discount,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(req.SeatIDs) > 0 {
+			err = s.seatRepo.HoldSeats(ctx, req.ProductID, trx.ID, req.SeatIDs)
+			if err != nil {
+				logger.Log.Warn(err.Error())
+				return err
+			}
+		}
+
+		return s.eventService.Record(ctx, constans.TransactionCreated, trx.ID, trx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the payment gateway is called once the reservation is committed, so no row
+	// stays locked while waiting for it
+	trx.PaymentURL, err = s.GetPaymentURL(trx, order.product, req.User)
 	if err != nil {
 		logger.Log.Error(err.Error())
+		s.cancelCheckout(ctx, trx.ID)
 		return nil, err
 	}
 
-	if reflect.ValueOf(product).IsNil() {
-		return nil, errors.New(constans.ErrNotFound.Error())
+	err = s.transactionRepo.Update(ctx, *trx)
+	if err != nil {
+		logger.Log.Warn(err.Error())
+		s.cancelCheckout(ctx, trx.ID)
+		return nil, err
 	}
 
-	if product.Stock == 0 {
-		return nil, errors.New("ticket has run out")
+	return trx, nil
+}
+
+// Quote returns the price breakdown a checkout request would be charged, without reserving anything
+func (s *transaction) Quote(ctx context.Context, req model.CreateTransactionRequest) (*model.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	order, err := s.prepare(ctx, req, false)
+	if err != nil {
+		return nil, err
 	}
 
-	trx, err := s.transactionRepo.Create(ctx, model.Transaction{
-		ProductID: req.ProductID,
-		UserID:    req.User.ID,
-		Status:    constans.PENDING,
-		Amount:    product.Price,
-	})
+	return &model.Quote{
+		Breakdown: order.breakdown,
+		Exchange:  order.exchange,
+	}, nil
+}
+
+// order is a validated checkout request with its price
+type order struct {
+	product   *model.Product
+	voucher   *model.Voucher
+	breakdown model.PriceBreakdown
+	exchange  *model.ExchangeSnapshot
+}
 
+// prepare validates a checkout request against the stock, sessions and seat map
+// of the product and prices it. With lock the product and session stay locked
+// until the unit of work ends.
+func (s *transaction) prepare(ctx context.Context, req model.CreateTransactionRequest, lock bool) (*order, error) {
+	readProduct, readSession := s.productRepo.ReadByID, s.sessionRepo.ReadByID
+	if lock {
+		readProduct, readSession = s.productRepo.ReadByIDForUpdate, s.sessionRepo.ReadByIDForUpdate
+	}
+
+	product, err := readProduct(ctx, req.ProductID)
 	if err != nil {
 		logger.Log.Error(err.Error())
 		return nil, err
 	}
 
-	trx.PaymentURL, err = s.GetPaymentURL(trx, req.User)
+	if reflect.ValueOf(product).IsNil() || product.DeletedAt != nil {
+		return nil, errors.New(constans.ErrNotFound.Error())
+	}
+
+	// every payment method is charged its own surcharge, so it has to be known upfront
+	if req.PaymentMethod == "" && len(s.pricing.Surcharges) > 0 {
+		return nil, constans.ErrPaymentMethodRequired
+	}
+
+	sessions, err := s.sessionRepo.Count(ctx, req.ProductID)
 	if err != nil {
 		logger.Log.Error(err.Error())
 		return nil, err
 	}
 
-	err = s.transactionRepo.Update(ctx, *trx)
+	if sessions > 0 {
+		if req.SessionID == 0 {
+			return nil, constans.ErrSessionRequired
+		}
+
+		session, err := readSession(ctx, req.SessionID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+
+		if session == nil || session.ProductID != req.ProductID {
+			return nil, constans.ErrNotFound
+		}
+
+		if !session.StartDate.After(time.Now()) {
+			return nil, constans.ErrSessionClosed
+		}
+
+		// every session keeps its own stock, the stock of the event itself is not used
+		product.Stock = session.Stock
+	} else if req.SessionID != 0 {
+		return nil, constans.ErrBadParamInput
+	}
+
+	seated, err := s.seatRepo.CountSeatMap(ctx, req.ProductID)
 	if err != nil {
-		logger.Log.Warn(err.Error())
+		logger.Log.Error(err.Error())
 		return nil, err
 	}
 
-	return trx, nil
+	if seated > 0 && len(req.SeatIDs) == 0 {
+		return nil, constans.ErrSeatRequired
+	}
+
+	if seated == 0 && len(req.SeatIDs) > 0 {
+		return nil, constans.ErrBadParamInput
+	}
+
+	quantity := int64(1)
+	if len(req.SeatIDs) > 0 {
+		quantity = int64(len(req.SeatIDs))
+	}
+
+	reserved, err := s.waitlistService.Reserved(ctx, req.ProductID, req.SessionID, req.User.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// tickets offered to waitlisted users can only be bought by them until the offer expires
+	if product.Stock-reserved < quantity {
+		return nil, constans.ErrTicketRunOut
+	}
+
+	var (
+		result   = &order{product: product}
+		snapshot model.ExchangeSnapshot
+		discount int64
+	)
+
+	// transactions are charged in the base currency, products priced in another
+	// currency are converted at the current rate
+	unitPrice, priceRate, err := s.exchangeService.Convert(ctx, product.Price, s.exchangeService.Base())
+	if err != nil {
+		return nil, err
+	}
+	snapshot.PriceRate = priceRate
+
+	if req.PromoCode != "" {
+		sections, err := s.seatSections(ctx, req.ProductID, req.SeatIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		result.voucher, discount, err = s.voucherService.Apply(ctx, req.PromoCode, req.ProductID, unitPrice.Amount, sections)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.breakdown = s.pricing.Calculate(unitPrice, quantity, discount, req.PaymentMethod)
+
+	if req.Currency != "" && req.Currency != unitPrice.Currency {
+		total, rate, err := s.exchangeService.Convert(ctx, result.breakdown.TotalMoney(), req.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot.DisplayRate = rate
+		snapshot.DisplayTotal = &total
+	}
+
+	if snapshot != (model.ExchangeSnapshot{}) {
+		result.exchange = &snapshot
+	}
+
+	return result, nil
+}
+
+// cancelCheckout cancels a transaction whose payment could not be started and
+// gives back its tickets, seats and promo code
+func (s *transaction) cancelCheckout(ctx context.Context, transactionID int64) {
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.transactionRepo.UpdateStatus(ctx, transactionID, constans.CANCELLED); err != nil {
+			return err
+		}
+
+		if err := s.voucherService.Release(ctx, transactionID); err != nil {
+			return err
+		}
+
+		if _, err := s.seatRepo.ReleaseSeats(ctx, transactionID); err != nil {
+			return err
+		}
+
+		transaction, err := s.transactionRepo.ReadByID(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		if err = s.updateStock(ctx, *transaction, quantity(*transaction)); err != nil {
+			return err
+		}
+
+		return s.eventService.Record(ctx, constans.TransactionCancelled, transactionID, transaction)
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+	}
 }
 
-func (s *transaction) GetPaymentURL(trx *model.Transaction, user model.User) (paymentURL string, err error) {
+// seatSections returns the section of every requested seat, in request order
+func (s *transaction) seatSections(ctx context.Context, productID int64, seatIDs []int64) ([]string, error) {
+	if len(seatIDs) == 0 {
+		return nil, nil
+	}
+
+	seatMap, err := s.seatRepo.ReadSeatMap(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	sections := make(map[int64]string, len(seatMap))
+	for _, seat := range seatMap {
+		sections[seat.SeatID] = seat.Section
+	}
+
+	result := make([]string, 0, len(seatIDs))
+	for _, id := range seatIDs {
+		section, ok := sections[id]
+		if !ok {
+			return nil, constans.ErrSeatNotAvailable
+		}
+		result = append(result, section)
+	}
+
+	return result, nil
+}
+
+func midtransClient() midtrans.Client {
 	midclient := midtrans.NewClient()
 	midclient.ServerKey = viper.GetString("MIDTRANS_SERVER_KEY")
 	midclient.ClientKey = viper.GetString("MIDTRANS_CLIENT_KEY")
 	midclient.APIEnvType = midtrans.Sandbox
 
+	return midclient
+}
+
+func (s *transaction) GetPaymentURL(trx *model.Transaction, product *model.Product, user model.User) (paymentURL string, err error) {
 	snapGateway := midtrans.SnapGateway{
-		Client: midclient,
+		Client: midtransClient(),
 	}
 
+	items, grossAmount := paymentItems(trx.Breakdown, product)
+
 	snapReq := &midtrans.SnapReq{
 		CustomerDetail: &midtrans.CustDetail{
 			Email: user.Email,
@@ -97,8 +392,13 @@ func (s *transaction) GetPaymentURL(trx *model.Transaction, user model.User) (pa
 		},
 		TransactionDetails: midtrans.TransactionDetails{
 			OrderID:  strconv.Itoa(int(trx.ID)),
-			GrossAmt: int64(trx.Amount),
+			GrossAmt: grossAmount,
 		},
+		Items: &items,
+	}
+
+	if trx.PaymentMethod != "" {
+		snapReq.EnabledPayments = []midtrans.PaymentType{midtrans.PaymentType(trx.PaymentMethod)}
 	}
 
 	snapTokenResp, err := snapGateway.GetToken(snapReq)
@@ -109,37 +409,329 @@ func (s *transaction) GetPaymentURL(trx *model.Transaction, user model.User) (pa
 	return snapTokenResp.RedirectURL, nil
 }
 
+// paymentItems lists the price breakdown as midtrans item details. Midtrans only
+// accepts whole amounts and requires the items to add up to the gross amount, so
+// any rounding difference is charged as its own item.
+func paymentItems(breakdown model.PriceBreakdown, product *model.Product) ([]midtrans.ItemDetail, int64) {
+	whole := func(amount int64) int64 {
+		return model.NewMoney(amount, breakdown.Currency).Whole()
+	}
+
+	items := []midtrans.ItemDetail{
+		{
+			ID:    product.ID,
+			Name:  product.Name,
+			Price: whole(breakdown.UnitPrice),
+			Qty:   int32(breakdown.Quantity),
+		},
+	}
+
+	lines := []struct {
+		id, name string
+		amount   int64
+	}{
+		{"DISCOUNT", "Promo code discount", -breakdown.Discount},
+		{"FEE", "Service fee", breakdown.Fee},
+		{"SURCHARGE", "Payment surcharge", breakdown.Surcharge},
+		{"TAX", "VAT", breakdown.Tax},
+	}
+
+	for _, line := range lines {
+		if line.amount == 0 {
+			continue
+		}
+
+		items = append(items, midtrans.ItemDetail{
+			ID:    line.id,
+			Name:  line.name,
+			Price: whole(line.amount),
+			Qty:   1,
+		})
+	}
+
+	var sum int64
+	for _, item := range items {
+		sum += item.Price * int64(item.Qty)
+	}
+
+	grossAmount := whole(breakdown.Total)
+	if sum != grossAmount {
+		items = append(items, midtrans.ItemDetail{
+			ID:    "ROUNDING",
+			Name:  "Rounding",
+			Price: grossAmount - sum,
+			Qty:   1,
+		})
+	}
+
+	return items, grossAmount
+}
+
 func (s *transaction) Update(ctx context.Context, request model.UpdateTransactionRequest) error {
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
-	transaction, err := s.transactionRepo.ReadByID(ctx, convert.Atoi(request.OrderID))
+	if !validSignature(request) {
+		return constans.ErrInvalidSignature
+	}
+
+	return s.Settle(ctx, request)
+}
+
+func (s *transaction) Settle(ctx context.Context, request model.UpdateTransactionRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	var (
+		transaction *model.Transaction
+		released    bool
+	)
+
+	// the transaction row stays locked while it is settled, so notifications the
+	// payment gateway delivers concurrently are applied one after the other
+	err := s.uow.Do(ctx, func(ctx context.Context) (err error) {
+		transaction, err = s.transactionRepo.ReadByIDForUpdate(ctx, convert.Atoi(request.OrderID))
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		released, err = s.settle(ctx, transaction, request)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// tickets given back are offered to the waitlist once the settlement is committed
+	if released {
+		return s.waitlistService.OfferReleasedStock(ctx, transaction.ProductID, transaction.SessionID)
+	}
+
+	return nil
+}
+
+func (s *transaction) ReadByID(ctx context.Context, transactionID int64) (*model.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	transaction, err := s.transactionRepo.ReadByID(ctx, transactionID)
 	if err != nil {
 		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+func (s *transaction) Cancel(ctx context.Context, transactionID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	var (
+		transaction *model.Transaction
+		released    bool
+	)
+
+	err := s.uow.Do(ctx, func(ctx context.Context) (err error) {
+		transaction, err = s.transactionRepo.ReadByIDForUpdate(ctx, transactionID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if transaction.Status != constans.PENDING {
+			return constans.ErrConflict
+		}
+
+		released, err = s.settle(ctx, transaction, model.UpdateTransactionRequest{TransactionStatus: "cancel"})
+		return err
+	})
+	if err != nil {
 		return err
 	}
 
+	if released {
+		return s.waitlistService.OfferReleasedStock(ctx, transaction.ProductID, transaction.SessionID)
+	}
+
+	return nil
+}
+
+// settle applies the payment status of a notification to a transaction and
+// reports whether tickets were given back
+func (s *transaction) settle(ctx context.Context, transaction *model.Transaction, request model.UpdateTransactionRequest) (bool, error) {
+	previousStatus := transaction.Status
+
 	if request.PaymentType == "credit_card" && request.TransactionStatus == "capture" && request.FraudStatus == "accept" {
-		transaction.Status = "paid"
+		transaction.Status = constans.PAID
 	} else if request.TransactionStatus == "settlement" {
-		transaction.Status = "paid"
+		transaction.Status = constans.PAID
 	} else if request.TransactionStatus == "deny" || request.TransactionStatus == "expire" || request.TransactionStatus == "cancel" {
-		transaction.Status = "cancelled"
+		transaction.Status = constans.CANCELLED
+	} else if (request.TransactionStatus == "refund" || request.TransactionStatus == "partial_refund") && previousStatus == constans.PAID {
+		transaction.Status = constans.REFUNDED
 	}
 
-	err = s.transactionRepo.UpdateStatus(ctx, convert.Atoi(request.OrderID), transaction.Status)
+	// notifications are retried by the payment gateway, only act on a status change
+	if transaction.Status == previousStatus {
+		return false, nil
+	}
+
+	// a cancelled or refunded transaction gave its tickets back already, a late
+	// notification does not reopen it. A payment captured after the cancellation
+	// is flagged so it gets refunded.
+	if previousStatus == constans.CANCELLED || previousStatus == constans.REFUNDED {
+		paidAfterCancel := previousStatus == constans.CANCELLED && transaction.Status == constans.PAID
+		transaction.Status = previousStatus
+
+		if paidAfterCancel {
+			logger.Log.Warn("transaction paid after it was cancelled", zap.Int64("transaction_id", transaction.ID))
+			return false, s.eventService.Record(ctx, constans.TransactionPaidAfterCancel, transaction.ID, transaction)
+		}
+
+		return false, nil
+	}
+
+	err := s.transactionRepo.UpdateStatus(ctx, transaction.ID, transaction.Status)
 	if err != nil {
 		logger.Log.Error(err.Error())
-		return err
+		return false, err
 	}
 
-	if transaction.Status == "paid" {
-		err = s.productRepo.UpdateStock(ctx, transaction.ProductID, -1)
-		if err != nil {
+	switch transaction.Status {
+	case constans.PAID:
+		// the tickets were taken off the stock at checkout
+		if _, err = s.seatRepo.SellSeats(ctx, transaction.ID); err != nil {
+			logger.Log.Error(err.Error())
+			return false, err
+		}
+
+		if err = s.voucherService.Confirm(ctx, transaction.ID); err != nil {
+			return false, err
+		}
+
+		if err = s.waitlistService.MarkPurchased(ctx, transaction.ProductID, transaction.SessionID, transaction.UserID); err != nil {
+			return false, err
+		}
+
+		if err = s.eventService.Record(ctx, constans.TransactionPaid, transaction.ID, transaction); err != nil {
+			return false, err
+		}
+
+		return false, s.recordSoldOut(ctx, *transaction)
+	case constans.CANCELLED:
+		if err = s.voucherService.Release(ctx, transaction.ID); err != nil {
+			return false, err
+		}
+
+		if _, err = s.seatRepo.ReleaseSeats(ctx, transaction.ID); err != nil {
 			logger.Log.Error(err.Error())
+			return false, err
+		}
+
+		if err = s.updateStock(ctx, *transaction, quantity(*transaction)); err != nil {
+			return false, err
+		}
+
+		if err = s.eventService.Record(ctx, constans.TransactionCancelled, transaction.ID, transaction); err != nil {
+			return false, err
+		}
+
+		// an expired hold gives its tickets back to the waitlist
+		return true, nil
+	case constans.REFUNDED:
+		if err = s.voucherService.Release(ctx, transaction.ID); err != nil {
+			return false, err
+		}
+
+		if _, err = s.seatRepo.ReleaseSeats(ctx, transaction.ID); err != nil {
+			logger.Log.Error(err.Error())
+			return false, err
+		}
+
+		if err = s.updateStock(ctx, *transaction, quantity(*transaction)); err != nil {
+			return false, err
+		}
+
+		return true, s.eventService.Record(ctx, constans.TransactionRefunded, transaction.ID, transaction)
+	}
+
+	return false, nil
+}
+
+// recordSoldOut records a ProductSoldOut event when the last ticket of the
+// product, or of the session of the transaction, was sold
+func (s *transaction) recordSoldOut(ctx context.Context, transaction model.Transaction) error {
+	var stock int64
+
+	if transaction.SessionID != 0 {
+		session, err := s.sessionRepo.ReadByID(ctx, transaction.SessionID)
+		if err != nil || session == nil {
 			return err
 		}
+		stock = session.Stock
+	} else {
+		product, err := s.productRepo.ReadByID(ctx, transaction.ProductID)
+		if err != nil || product == nil {
+			return err
+		}
+		stock = product.Stock
+	}
+
+	if stock > 0 {
+		return nil
+	}
+
+	return s.eventService.Record(ctx, constans.ProductSoldOut, transaction.ProductID, model.ProductSoldOutEvent{
+		ProductID: transaction.ProductID,
+		SessionID: transaction.SessionID,
+	})
+}
+
+// takeStock takes the tickets of a checkout off the stock of its session, or
+// of the product when it has no sessions
+func (s *transaction) takeStock(ctx context.Context, productID int64, sessionID int64, quantity int64) (err error) {
+	if sessionID != 0 {
+		err = s.sessionRepo.TakeStock(ctx, sessionID, quantity)
+	} else {
+		err = s.productRepo.TakeStock(ctx, productID, quantity)
+	}
+	if err != nil && err != constans.ErrTicketRunOut {
+		logger.Log.Error(err.Error())
+	}
+
+	return err
+}
+
+func (s *transaction) updateStock(ctx context.Context, transaction model.Transaction, quantity int64) (err error) {
+	if transaction.SessionID != 0 {
+		err = s.sessionRepo.UpdateStock(ctx, transaction.SessionID, quantity)
+	} else {
+		err = s.productRepo.UpdateStock(ctx, transaction.ProductID, quantity)
+	}
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
 	}
 
 	return nil
 }
+
+// quantity returns how many tickets a transaction took off the stock
+func quantity(transaction model.Transaction) int64 {
+	if transaction.Breakdown.Quantity > 0 {
+		return transaction.Breakdown.Quantity
+	}
+
+	return 1
+}
+
+// validSignature checks the signature_key of a payment notification,
+// which is SHA512(order_id+status_code+gross_amount+server_key)
+func validSignature(request model.UpdateTransactionRequest) bool {
+	payload := request.OrderID + request.StatusCode + request.GrossAmount + viper.GetString("MIDTRANS_SERVER_KEY")
+	hash := sha512.Sum512([]byte(payload))
+
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(hash[:])), []byte(request.SignatureKey)) == 1
+}
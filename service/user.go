@@ -16,16 +16,22 @@ type UserService interface {
 	Create(context.Context, model.User) error
 	Read(ctx context.Context) (users []model.User, err error)
 	ReadByUsername(ctx context.Context, username string) (*model.User, error)
+	SetRole(ctx context.Context, username string, role string) error
+	ResetPassword(ctx context.Context, username string, password string) error
 }
 
 type user struct {
+	uow            repository.UnitOfWork
 	repo           repository.UserRepository
+	eventService   EventService
 	contextTimeout time.Duration
 }
 
-func NewUserService(urepo repository.UserRepository, timeout time.Duration) UserService {
+func NewUserService(uow repository.UnitOfWork, urepo repository.UserRepository, events EventService, timeout time.Duration) UserService {
 	return &user{
+		uow:            uow,
 		repo:           urepo,
+		eventService:   events,
 		contextTimeout: timeout,
 	}
 }
@@ -62,23 +68,88 @@ func (s *user) Create(ctx context.Context, user model.User) error {
 		return err
 	}
 
-	err = s.repo.Create(ctx, user)
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		created, err := s.repo.Create(ctx, user)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		return s.eventService.Record(ctx, constans.UserRegistered, created.ID, model.UserRegisteredEvent{
+			UserID:   created.ID,
+			Username: created.Username,
+			Email:    created.Email,
+		})
+	})
+}
+
+func (s *user) ReadByUsername(ctx context.Context, username string) (*model.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	user, err := s.repo.ReadByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (s *user) SetRole(ctx context.Context, username string, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if role != constans.RoleAdmin && role != constans.RoleUser {
+		return constans.ErrBadParamInput
+	}
+
+	user, err := s.repo.ReadByUsername(ctx, username)
 	if err != nil {
 		logger.Log.Error(err.Error())
 		return err
 	}
 
+	if user.ID == 0 {
+		return constans.ErrNotFound
+	}
+
+	user.Roles = role
+
+	if err = s.repo.Update(ctx, user); err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
 	return nil
 }
 
-func (s *user) ReadByUsername(ctx context.Context, username string) (*model.User, error) {
+func (s *user) ResetPassword(ctx context.Context, username string, password string) error {
 	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
 	defer cancel()
 
+	if password == "" {
+		return constans.ErrBadParamInput
+	}
+
 	user, err := s.repo.ReadByUsername(ctx, username)
 	if err != nil {
-		return nil, err
+		logger.Log.Error(err.Error())
+		return err
 	}
 
-	return &user, nil
+	if user.ID == 0 {
+		return constans.ErrNotFound
+	}
+
+	user.Password, err = utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.Update(ctx, user); err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+)
+
+type VenueService interface {
+	Create(ctx context.Context, request model.VenueRequest) (*model.Venue, error)
+	Read(context.Context) ([]model.Venue, error)
+	ReadByID(ctx context.Context, venueID int64) (*model.Venue, error)
+	CreateSeats(ctx context.Context, request model.SeatRequest) error
+	ReadSeats(ctx context.Context, venueID int64) ([]model.Seat, error)
+	CreateSeatMap(ctx context.Context, request model.SeatMapRequest) error
+	ReadSeatAvailability(ctx context.Context, productID int64) ([]model.ProductSeat, error)
+}
+
+type venue struct {
+	uow            repository.UnitOfWork
+	venueRepo      repository.VenueRepository
+	seatRepo       repository.SeatRepository
+	productRepo    repository.ProductRepository
+	sessionRepo    repository.SessionRepository
+	eventService   EventService
+	contextTimeout time.Duration
+}
+
+func NewVenueService(uow repository.UnitOfWork, venueRepo repository.VenueRepository, seatRepo repository.SeatRepository, productRepo repository.ProductRepository, sessionRepo repository.SessionRepository, events EventService, timeout time.Duration) VenueService {
+	return &venue{
+		uow:            uow,
+		venueRepo:      venueRepo,
+		seatRepo:       seatRepo,
+		productRepo:    productRepo,
+		sessionRepo:    sessionRepo,
+		eventService:   events,
+		contextTimeout: timeout,
+	}
+}
+
+func (s *venue) Create(ctx context.Context, request model.VenueRequest) (*model.Venue, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	venue, err := s.venueRepo.Create(ctx, model.Venue{
+		Name:    request.Name,
+		Address: request.Address,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return venue, nil
+}
+
+func (s *venue) Read(ctx context.Context) ([]model.Venue, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	venues, err := s.venueRepo.Read(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return venues, nil
+}
+
+func (s *venue) ReadByID(ctx context.Context, venueID int64) (*model.Venue, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	venue, err := s.venueRepo.ReadByID(ctx, venueID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if venue == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	return venue, nil
+}
+
+func (s *venue) CreateSeats(ctx context.Context, request model.SeatRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	venue, err := s.venueRepo.ReadByID(ctx, request.VenueID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if venue == nil {
+		return constans.ErrNotFound
+	}
+
+	seats := make([]model.Seat, 0, len(request.Numbers))
+	for _, number := range request.Numbers {
+		seats = append(seats, model.Seat{
+			VenueID: request.VenueID,
+			Section: request.Section,
+			Row:     request.Row,
+			Number:  number,
+		})
+	}
+
+	err = s.venueRepo.CreateSeats(ctx, seats)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *venue) ReadSeats(ctx context.Context, venueID int64) ([]model.Seat, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	seats, err := s.venueRepo.ReadSeats(ctx, venueID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return seats, nil
+}
+
+// CreateSeatMap attaches every seat of a venue to a product, the product stock
+// becomes the number of attached seats. A seat is held for the whole event, so
+// an event with sessions can not have a seat map.
+func (s *venue) CreateSeatMap(ctx context.Context, request model.SeatMapRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	venue, err := s.venueRepo.ReadByID(ctx, request.VenueID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if venue == nil {
+		return constans.ErrNotFound
+	}
+
+	// the seats and the stock matching them are saved together or not at all
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		product, err := s.productRepo.ReadByIDForUpdate(ctx, request.ProductID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if product == nil || product.DeletedAt != nil {
+			return constans.ErrNotFound
+		}
+
+		total, err := s.seatRepo.CountSeatMap(ctx, request.ProductID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if total > 0 {
+			return constans.ErrConflict
+		}
+
+		sessions, err := s.sessionRepo.Count(ctx, request.ProductID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if sessions > 0 {
+			return constans.ErrSeatMapHasSessions
+		}
+
+		attached, err := s.seatRepo.CreateSeatMap(ctx, request.ProductID, request.VenueID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if attached == 0 {
+			return constans.ErrVenueHasNoSeats
+		}
+
+		err = s.productRepo.UpdateStock(ctx, request.ProductID, attached-product.Stock)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		return recordStockUpdated(ctx, s.eventService, request.ProductID)
+	})
+}
+
+func (s *venue) ReadSeatAvailability(ctx context.Context, productID int64) ([]model.ProductSeat, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	seats, err := s.seatRepo.ReadSeatMap(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return seats, nil
+}
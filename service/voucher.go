@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/cecepsprd/ticketing-api/utils/pricing"
+)
+
+type VoucherService interface {
+	Create(ctx context.Context, request model.VoucherRequest) (*model.Voucher, error)
+	Read(context.Context) ([]model.Voucher, error)
+	ReadByID(ctx context.Context, voucherID int64) (*model.Voucher, error)
+	Update(ctx context.Context, request model.VoucherRequest) error
+	Delete(ctx context.Context, voucherID int64) error
+	Report(ctx context.Context, voucherID int64) (*model.VoucherReport, error)
+	// Apply validates a promo code for the given items and returns the voucher with its discount,
+	// the price and discount are in minor units
+	Apply(ctx context.Context, code string, productID int64, price int64, sections []string) (*model.Voucher, int64, error)
+	Redeem(ctx context.Context, redemption model.VoucherRedemption) error
+	Confirm(ctx context.Context, transactionID int64) error
+	Release(ctx context.Context, transactionID int64) error
+}
+
+type voucher struct {
+	repo           repository.VoucherRepository
+	contextTimeout time.Duration
+}
+
+func NewVoucherService(repo repository.VoucherRepository, timeout time.Duration) VoucherService {
+	return &voucher{
+		repo:           repo,
+		contextTimeout: timeout,
+	}
+}
+
+func (s *voucher) Create(ctx context.Context, request model.VoucherRequest) (*model.Voucher, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if err := validateVoucher(request); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ReadByCode(ctx, normalizeCode(request.Code))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if existing != nil {
+		return nil, constans.ErrConflict
+	}
+
+	voucher, err := s.repo.Create(ctx, voucherFromRequest(request))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return voucher, nil
+}
+
+func (s *voucher) Read(ctx context.Context) ([]model.Voucher, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	vouchers, err := s.repo.Read(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return vouchers, nil
+}
+
+func (s *voucher) ReadByID(ctx context.Context, voucherID int64) (*model.Voucher, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	voucher, err := s.repo.ReadByID(ctx, voucherID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if voucher == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	return voucher, nil
+}
+
+func (s *voucher) Update(ctx context.Context, request model.VoucherRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if err := validateVoucher(request); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.ReadByCode(ctx, normalizeCode(request.Code))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if existing != nil && existing.ID != request.ID {
+		return constans.ErrConflict
+	}
+
+	err = s.repo.Update(ctx, voucherFromRequest(request))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *voucher) Delete(ctx context.Context, voucherID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	err := s.repo.Delete(ctx, voucherID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *voucher) Report(ctx context.Context, voucherID int64) (*model.VoucherReport, error) {
+	ctx, cancel := context.WithTimeout(repository.AllowReplica(ctx), s.contextTimeout)
+	defer cancel()
+
+	voucher, err := s.repo.ReadByID(ctx, voucherID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if voucher == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	redemptions, err := s.repo.ReadRedemptions(ctx, voucherID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	report := &model.VoucherReport{
+		Voucher:     *voucher,
+		Redemptions: redemptions,
+	}
+
+	for _, r := range redemptions {
+		switch r.Status {
+		case constans.RESERVED:
+			report.Reserved++
+		case constans.REDEEMED:
+			report.Redeemed++
+			report.TotalDiscount += r.Discount
+		case constans.RELEASED:
+			report.Released++
+		}
+	}
+
+	return report, nil
+}
+
+func (s *voucher) Apply(ctx context.Context, code string, productID int64, price int64, sections []string) (*model.Voucher, int64, error) {
+	voucher, err := s.repo.ReadByCode(ctx, normalizeCode(code))
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	if voucher == nil ||
+		(voucher.ValidFrom != nil && now.Before(*voucher.ValidFrom)) ||
+		(voucher.ValidUntil != nil && now.After(*voucher.ValidUntil)) {
+		return nil, 0, constans.ErrVoucherInvalid
+	}
+
+	if voucher.ProductID != 0 && voucher.ProductID != productID {
+		return nil, 0, constans.ErrVoucherNotApplicable
+	}
+
+	if voucher.MaxRedemptions > 0 && voucher.Redeemed >= voucher.MaxRedemptions {
+		return nil, 0, constans.ErrVoucherExhausted
+	}
+
+	// a general admission checkout is a single ticket without section
+	eligible := 0
+	if len(sections) == 0 {
+		sections = []string{""}
+	}
+	for _, section := range sections {
+		if voucher.Section == "" || strings.EqualFold(voucher.Section, section) {
+			eligible++
+		}
+	}
+
+	if eligible == 0 {
+		return nil, 0, constans.ErrVoucherNotApplicable
+	}
+
+	subtotal := price * int64(eligible)
+
+	discount := voucher.Value
+	if voucher.Type == constans.PERCENTAGE {
+		discount = pricing.Rate(subtotal, voucher.Value)
+	}
+
+	if discount > subtotal {
+		discount = subtotal
+	}
+
+	return voucher, discount, nil
+}
+
+func (s *voucher) Redeem(ctx context.Context, redemption model.VoucherRedemption) error {
+	err := s.repo.Redeem(ctx, redemption)
+	if err != nil {
+		logger.Log.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *voucher) Confirm(ctx context.Context, transactionID int64) error {
+	err := s.repo.UpdateRedemption(ctx, transactionID, constans.REDEEMED)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *voucher) Release(ctx context.Context, transactionID int64) error {
+	err := s.repo.UpdateRedemption(ctx, transactionID, constans.RELEASED)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func validateVoucher(request model.VoucherRequest) error {
+	// percentages are in basis points, 10000 is 100%
+	if request.Type == constans.PERCENTAGE && request.Value > 10000 {
+		return constans.ErrBadParamInput
+	}
+
+	if request.ValidFrom != nil && request.ValidUntil != nil && !request.ValidUntil.After(*request.ValidFrom) {
+		return constans.ErrBadParamInput
+	}
+
+	return nil
+}
+
+func voucherFromRequest(request model.VoucherRequest) model.Voucher {
+	return model.Voucher{
+		ID:             request.ID,
+		Code:           normalizeCode(request.Code),
+		Type:           request.Type,
+		Value:          request.Value,
+		ProductID:      request.ProductID,
+		Section:        request.Section,
+		ValidFrom:      request.ValidFrom,
+		ValidUntil:     request.ValidUntil,
+		MaxRedemptions: request.MaxRedemptions,
+		MaxPerUser:     request.MaxPerUser,
+	}
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	queueToken     = "queue"
+	admissionToken = "admission"
+)
+
+type WaitingRoomService interface {
+	// Open puts a product behind a waiting room, its checkouts need an admission token from then on
+	Open(ctx context.Context, request model.WaitingRoomRequest) (*model.WaitingRoomStats, error)
+	Read(ctx context.Context, productID int64) (*model.WaitingRoomStats, error)
+	// Update pauses, resumes or speeds up the flow of a waiting room from now on
+	Update(ctx context.Context, request model.UpdateWaitingRoomRequest) (*model.WaitingRoomStats, error)
+	// Close removes the waiting room of a product, its checkouts are open to everyone again
+	Close(ctx context.Context, productID int64) error
+	// Join queues a user at the back of a waiting room, a user already queued keeps the place
+	Join(ctx context.Context, productID int64, user model.User) (*model.QueueStatus, error)
+	// Status tells the holder of a queue token where it stands, and hands it the admission token once let in
+	Status(ctx context.Context, productID int64, user model.User, token string) (*model.QueueStatus, error)
+	// CheckAdmission lets a checkout through when its product has no waiting room
+	// or the admission token of the user is still valid
+	CheckAdmission(ctx context.Context, productID int64, userID int64, token string) error
+}
+
+type waitingRoom struct {
+	uow            repository.UnitOfWork
+	repo           repository.WaitingRoomRepository
+	productRepo    repository.ProductRepository
+	secret         []byte
+	contextTimeout time.Duration
+}
+
+func NewWaitingRoomService(uow repository.UnitOfWork, repo repository.WaitingRoomRepository, productRepo repository.ProductRepository, JWTSecret string, timeout time.Duration) WaitingRoomService {
+	return &waitingRoom{
+		uow:            uow,
+		repo:           repo,
+		productRepo:    productRepo,
+		secret:         waitingRoomKey(JWTSecret),
+		contextTimeout: timeout,
+	}
+}
+
+func (s *waitingRoom) Open(ctx context.Context, request model.WaitingRoomRequest) (*model.WaitingRoomStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	product, err := s.productRepo.ReadByID(ctx, request.ProductID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return nil, constans.ErrNotFound
+	}
+
+	existing, err := s.repo.ReadByProduct(ctx, request.ProductID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if existing != nil {
+		return nil, constans.ErrConflict
+	}
+
+	if request.CheckoutWindow == 0 {
+		request.CheckoutWindow = int64(constans.DefaultCheckoutWindow / time.Minute)
+	}
+
+	room := model.WaitingRoom{
+		ProductID:      request.ProductID,
+		Rate:           request.Rate,
+		CheckoutWindow: request.CheckoutWindow,
+		AdmittedAt:     time.Now(),
+	}
+
+	if err = s.repo.Create(ctx, room); err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	created, err := s.repo.ReadByProduct(ctx, request.ProductID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return stats(*created, time.Now()), nil
+}
+
+func (s *waitingRoom) Read(ctx context.Context, productID int64) (*model.WaitingRoomStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	room, err := s.repo.ReadByProduct(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if room == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	return stats(*room, time.Now()), nil
+}
+
+func (s *waitingRoom) Update(ctx context.Context, request model.UpdateWaitingRoomRequest) (*model.WaitingRoomStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	var room *model.WaitingRoom
+
+	err := s.uow.Do(ctx, func(ctx context.Context) (err error) {
+		room, err = s.repo.ReadByProductForUpdate(ctx, request.ProductID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if room == nil {
+			return constans.ErrNotFound
+		}
+
+		// the buyers let in so far stay in, the new settings apply from now on
+		room.Checkpoint(time.Now())
+
+		if request.Rate != nil {
+			room.Rate = *request.Rate
+		}
+		if request.CheckoutWindow != nil {
+			room.CheckoutWindow = *request.CheckoutWindow
+		}
+		if request.Paused != nil {
+			room.Paused = *request.Paused
+		}
+
+		room.Admitted += request.Admit
+		if room.Admitted > room.LastPosition {
+			room.Admitted = room.LastPosition
+		}
+
+		if err = s.repo.Update(ctx, *room); err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats(*room, time.Now()), nil
+}
+
+func (s *waitingRoom) Close(ctx context.Context, productID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	room, err := s.repo.ReadByProduct(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if room == nil {
+		return constans.ErrNotFound
+	}
+
+	if err = s.repo.Delete(ctx, productID); err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *waitingRoom) Join(ctx context.Context, productID int64, user model.User) (*model.QueueStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	var (
+		room  *model.WaitingRoom
+		entry *model.QueueEntry
+	)
+
+	// the room is locked while a position is given out, so every buyer gets its own
+	err := s.uow.Do(ctx, func(ctx context.Context) (err error) {
+		room, err = s.repo.ReadByProductForUpdate(ctx, productID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if room == nil {
+			return constans.ErrNotFound
+		}
+
+		entry, err = s.repo.ReadEntry(ctx, productID, user.ID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		now := time.Now()
+		if entry != nil && !admissionExpired(*room, *entry, now) {
+			return nil
+		}
+
+		// nobody is waiting, the flow starts over from the newcomer
+		if room.AdmittedUpTo(now) >= room.LastPosition {
+			room.Checkpoint(now)
+		}
+
+		room.LastPosition++
+
+		if entry == nil {
+			entry = &model.QueueEntry{ProductID: productID, UserID: user.ID, Position: room.LastPosition}
+			err = s.repo.CreateEntry(ctx, *entry)
+		} else {
+			// a buyer who let the checkout window pass goes to the back of the queue
+			entry.Position = room.LastPosition
+			entry.AdmittedAt = nil
+			err = s.repo.Requeue(ctx, *entry)
+		}
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if err = s.repo.Update(ctx, *room); err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.status(ctx, *room, *entry)
+}
+
+func (s *waitingRoom) Status(ctx context.Context, productID int64, user model.User, token string) (*model.QueueStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	claims, ok := s.parse(token, queueToken, productID, user.ID)
+	if !ok {
+		return nil, constans.ErrQueueTokenInvalid
+	}
+
+	room, err := s.repo.ReadByProduct(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if room == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	entry, err := s.repo.ReadEntry(ctx, productID, user.ID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	// the token of a position given up by joining again is not valid anymore
+	if entry == nil || entry.Position != claimInt(claims, "position") {
+		return nil, constans.ErrQueueTokenInvalid
+	}
+
+	return s.status(ctx, *room, *entry)
+}
+
+func (s *waitingRoom) CheckAdmission(ctx context.Context, productID int64, userID int64, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	room, err := s.repo.ReadByProduct(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if room == nil {
+		return nil
+	}
+
+	// the expiry of the token is the end of the checkout window
+	if _, ok := s.parse(token, admissionToken, productID, userID); !ok {
+		return constans.ErrAdmissionRequired
+	}
+
+	return nil
+}
+
+// status is where entry stands in room right now. The checkout window of a
+// buyer starts when it was let in, which is recorded the first time it is seen.
+func (s *waitingRoom) status(ctx context.Context, room model.WaitingRoom, entry model.QueueEntry) (*model.QueueStatus, error) {
+	var (
+		now    = time.Now()
+		upTo   = room.AdmittedUpTo(now)
+		result = &model.QueueStatus{
+			ProductID: room.ProductID,
+			Position:  entry.Position,
+			Paused:    room.Paused,
+		}
+		err error
+	)
+
+	result.QueueToken, err = s.sign(jwt.MapClaims{
+		"typ":        queueToken,
+		"product_id": room.ProductID,
+		"user_id":    entry.UserID,
+		"position":   entry.Position,
+		"exp":        now.Add(constans.QueueTokenTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Position > upTo {
+		result.Ahead = entry.Position - upTo - 1
+		if !room.Paused {
+			wait := int64(math.Ceil(admissionTime(room, entry.Position).Sub(now).Seconds()))
+			result.EstimatedWait = &wait
+		}
+
+		return result, nil
+	}
+
+	if entry.AdmittedAt == nil {
+		at := admissionTime(room, entry.Position)
+		if at.After(now) {
+			at = now
+		}
+
+		admitted, err := s.repo.Admit(ctx, room.ProductID, entry.UserID, at)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+
+		entry.AdmittedAt = &at
+
+		// a concurrent request recorded it first, its time is the one that counts
+		if !admitted {
+			stored, err := s.repo.ReadEntry(ctx, room.ProductID, entry.UserID)
+			if err != nil {
+				logger.Log.Error(err.Error())
+				return nil, err
+			}
+			if stored != nil && stored.AdmittedAt != nil {
+				entry.AdmittedAt = stored.AdmittedAt
+			}
+		}
+	}
+
+	if admissionExpired(room, entry, now) {
+		return nil, constans.ErrAdmissionExpired
+	}
+
+	expiresAt := entry.AdmittedAt.Add(time.Duration(room.CheckoutWindow) * time.Minute)
+
+	result.AdmissionToken, err = s.sign(jwt.MapClaims{
+		"typ":        admissionToken,
+		"product_id": room.ProductID,
+		"user_id":    entry.UserID,
+		"exp":        expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var wait int64
+	result.EstimatedWait = &wait
+	result.AdmissionExpiresAt = &expiresAt
+
+	return result, nil
+}
+
+func (s *waitingRoom) sign(claims jwt.MapClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+// parse verifies a token of the given type issued to a user for a product
+func (s *waitingRoom) parse(token string, typ string, productID int64, userID int64) (jwt.MapClaims, bool) {
+	if token == "" {
+		return nil, false
+	}
+
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return s.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, false
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != typ || claimInt(claims, "product_id") != productID || claimInt(claims, "user_id") != userID {
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// waitingRoomKey derives the key of the queue and admission tokens from the JWT
+// secret, so none of them passes for a login token nor the other way around
+func waitingRoomKey(JWTSecret string) []byte {
+	mac := hmac.New(sha256.New, []byte(JWTSecret))
+	mac.Write([]byte("waiting room"))
+	return mac.Sum(nil)
+}
+
+func claimInt(claims jwt.MapClaims, name string) int64 {
+	value, _ := claims[name].(float64)
+	return int64(value)
+}
+
+// admissionTime is when a position is let in at the current rate, the positions
+// let in before the last checkpoint count as let in at it
+func admissionTime(room model.WaitingRoom, position int64) time.Time {
+	if position <= room.Admitted || room.Rate <= 0 {
+		return room.AdmittedAt
+	}
+
+	minutes := float64(position-room.Admitted) / float64(room.Rate)
+	return room.AdmittedAt.Add(time.Duration(minutes * float64(time.Minute)))
+}
+
+// admissionExpired reports whether a buyer let in has let its checkout window pass
+func admissionExpired(room model.WaitingRoom, entry model.QueueEntry, now time.Time) bool {
+	if entry.AdmittedAt == nil {
+		return false
+	}
+
+	return !now.Before(entry.AdmittedAt.Add(time.Duration(room.CheckoutWindow) * time.Minute))
+}
+
+func stats(room model.WaitingRoom, now time.Time) *model.WaitingRoomStats {
+	upTo := room.AdmittedUpTo(now)
+	if upTo > room.LastPosition {
+		upTo = room.LastPosition
+	}
+
+	return &model.WaitingRoomStats{
+		WaitingRoom:  room,
+		AdmittedUpTo: upTo,
+		Waiting:      room.LastPosition - upTo,
+	}
+}
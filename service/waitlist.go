@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/cecepsprd/ticketing-api/utils/notification"
+)
+
+// WaitlistService keeps a waitlist per session of a product, products without
+// sessions are waited for with session 0
+type WaitlistService interface {
+	Join(ctx context.Context, productID int64, sessionID int64, user model.User) (*model.Waitlist, error)
+	ReadByUser(ctx context.Context, productID int64, sessionID int64, user model.User) (*model.Waitlist, error)
+	Leave(ctx context.Context, productID int64, sessionID int64, user model.User) error
+	// Reserved returns the number of tickets of a product session held for other waitlisted users
+	Reserved(ctx context.Context, productID int64, sessionID int64, userID int64) (int64, error)
+	// OfferReleasedStock hands the available tickets of a product session to the next waitlisted users
+	OfferReleasedStock(ctx context.Context, productID int64, sessionID int64) error
+	MarkPurchased(ctx context.Context, productID int64, sessionID int64, userID int64) error
+	ExpireOffers(ctx context.Context) error
+}
+
+type waitlist struct {
+	repo           repository.WaitlistRepository
+	productRepo    repository.ProductRepository
+	sessionRepo    repository.SessionRepository
+	userRepo       repository.UserRepository
+	notifier       notification.Notifier
+	offerWindow    time.Duration
+	contextTimeout time.Duration
+}
+
+func NewWaitlistService(repo repository.WaitlistRepository, productRepo repository.ProductRepository, sessionRepo repository.SessionRepository, userRepo repository.UserRepository, notifier notification.Notifier, offerWindow time.Duration, timeout time.Duration) WaitlistService {
+	if offerWindow <= 0 {
+		offerWindow = constans.DefaultWaitlistOfferWindow
+	}
+
+	return &waitlist{
+		repo:           repo,
+		productRepo:    productRepo,
+		sessionRepo:    sessionRepo,
+		userRepo:       userRepo,
+		notifier:       notifier,
+		offerWindow:    offerWindow,
+		contextTimeout: timeout,
+	}
+}
+
+func (s *waitlist) Join(ctx context.Context, productID int64, sessionID int64, user model.User) (*model.Waitlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	product, err := s.productRepo.ReadByID(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return nil, constans.ErrNotFound
+	}
+
+	sessions, err := s.sessionRepo.Count(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	stock := product.Stock
+	if sessions > 0 {
+		if sessionID == 0 {
+			return nil, constans.ErrSessionRequired
+		}
+
+		session, err := s.sessionRepo.ReadByID(ctx, sessionID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+
+		if session == nil || session.ProductID != productID {
+			return nil, constans.ErrNotFound
+		}
+
+		if !session.StartDate.After(time.Now()) {
+			return nil, constans.ErrSessionClosed
+		}
+
+		// every session keeps its own stock, and its own waitlist
+		stock = session.Stock
+	} else if sessionID != 0 {
+		return nil, constans.ErrBadParamInput
+	}
+
+	reserved, err := s.repo.CountActiveOffers(ctx, productID, sessionID, user.ID, time.Now())
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	// only sold out events have a waitlist
+	if stock-reserved > 0 {
+		return nil, constans.ErrNotSoldOut
+	}
+
+	active, err := s.repo.ReadActive(ctx, productID, sessionID, user.ID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if active != nil {
+		return nil, constans.ErrConflict
+	}
+
+	entry, err := s.repo.Create(ctx, model.Waitlist{
+		ProductID: productID,
+		SessionID: sessionID,
+		UserID:    user.ID,
+		Status:    constans.WAITING,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	entry.Position, err = s.repo.Position(ctx, *entry)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *waitlist) ReadByUser(ctx context.Context, productID int64, sessionID int64, user model.User) (*model.Waitlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	entry, err := s.repo.ReadActive(ctx, productID, sessionID, user.ID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	if entry.Status == constans.WAITING {
+		entry.Position, err = s.repo.Position(ctx, *entry)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
+
+func (s *waitlist) Leave(ctx context.Context, productID int64, sessionID int64, user model.User) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	entry, err := s.repo.ReadActive(ctx, productID, sessionID, user.ID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if entry == nil {
+		return constans.ErrNotFound
+	}
+
+	left, err := s.repo.UpdateStatus(ctx, entry.ID, entry.Status, constans.LEFT)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	// giving up an offer releases the ticket to the next in line
+	if left && entry.Status == constans.OFFERED {
+		return s.OfferReleasedStock(ctx, productID, sessionID)
+	}
+
+	return nil
+}
+
+func (s *waitlist) Reserved(ctx context.Context, productID int64, sessionID int64, userID int64) (int64, error) {
+	reserved, err := s.repo.CountActiveOffers(ctx, productID, sessionID, userID, time.Now())
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return 0, err
+	}
+
+	return reserved, nil
+}
+
+func (s *waitlist) OfferReleasedStock(ctx context.Context, productID int64, sessionID int64) error {
+	product, err := s.productRepo.ReadByID(ctx, productID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return nil
+	}
+
+	var session *model.Session
+	stock := product.Stock
+
+	if sessionID != 0 {
+		session, err = s.sessionRepo.ReadByID(ctx, sessionID)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		// a session that started has nothing left to offer
+		if session == nil || !session.StartDate.After(time.Now()) {
+			return nil
+		}
+		stock = session.Stock
+	}
+
+	reserved, err := s.repo.CountActiveOffers(ctx, productID, sessionID, 0, time.Now())
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	available := stock - reserved
+	if available <= 0 {
+		return nil
+	}
+
+	next, err := s.repo.ReadNext(ctx, productID, sessionID, available)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	for _, entry := range next {
+		expiresAt := time.Now().Add(s.offerWindow)
+
+		offered, err := s.repo.Offer(ctx, entry.ID, expiresAt)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		// the entry was taken by a concurrent release or left the waitlist
+		if !offered {
+			continue
+		}
+
+		s.notify(ctx, entry.UserID, product, session, expiresAt)
+	}
+
+	return nil
+}
+
+func (s *waitlist) MarkPurchased(ctx context.Context, productID int64, sessionID int64, userID int64) error {
+	entry, err := s.repo.ReadActive(ctx, productID, sessionID, userID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if entry == nil || entry.Status != constans.OFFERED {
+		return nil
+	}
+
+	_, err = s.repo.UpdateStatus(ctx, entry.ID, constans.OFFERED, constans.PURCHASED)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ExpireOffers closes every purchase window that ran out and passes the
+// tickets on to the next waitlisted users
+func (s *waitlist) ExpireOffers(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	expired, err := s.repo.ReadExpiredOffers(ctx, time.Now())
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	type waitlistKey struct {
+		productID int64
+		sessionID int64
+	}
+
+	waitlists := make(map[waitlistKey]bool)
+	for _, entry := range expired {
+		ok, err := s.repo.UpdateStatus(ctx, entry.ID, constans.OFFERED, constans.EXPIRED)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		if ok {
+			waitlists[waitlistKey{productID: entry.ProductID, sessionID: entry.SessionID}] = true
+		}
+	}
+
+	for key := range waitlists {
+		if err := s.OfferReleasedStock(ctx, key.productID, key.sessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *waitlist) notify(ctx context.Context, userID int64, product *model.Product, session *model.Session, expiresAt time.Time) {
+	user, err := s.userRepo.ReadByID(ctx, userID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return
+	}
+
+	name := product.Name
+	if session != nil {
+		name = fmt.Sprintf("%s on %s", product.Name, session.StartDate.Format(time.RFC1123))
+	}
+
+	message := fmt.Sprintf("A ticket for %s is available for you until %s, checkout before it is offered to the next person in line.",
+		name, expiresAt.Format(time.RFC1123))
+
+	if err := s.notifier.Notify(ctx, user, "Your ticket is waiting", message); err != nil {
+		logger.Log.Error(err.Error())
+	}
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"github.com/cecepsprd/ticketing-api/utils/webhook"
+	"go.uber.org/zap"
+)
+
+// WebhookEvents are the domain events partners can subscribe to
+var WebhookEvents = []string{
+	constans.TransactionCreated,
+	constans.TransactionPaid,
+	constans.TransactionCancelled,
+	constans.TransactionRefunded,
+	constans.TransactionPaidAfterCancel,
+	constans.ProductSoldOut,
+}
+
+type WebhookService interface {
+	Create(ctx context.Context, request model.WebhookRequest) (*model.Webhook, error)
+	Read(context.Context) ([]model.Webhook, error)
+	ReadByID(ctx context.Context, webhookID int64) (*model.Webhook, error)
+	Update(ctx context.Context, request model.WebhookRequest) error
+	Delete(ctx context.Context, webhookID int64) error
+	// ReadDeliveries returns the latest deliveries of a webhook
+	ReadDeliveries(ctx context.Context, webhookID int64) ([]model.WebhookDelivery, error)
+	// Redeliver sends a delivery again right away and returns its outcome
+	Redeliver(ctx context.Context, webhookID int64, deliveryID int64) (*model.WebhookDelivery, error)
+	// Enqueue schedules the delivery of a domain event to the webhooks subscribed to it
+	Enqueue(ctx context.Context, event model.Event) error
+	// Deliver sends the pending deliveries, failed deliveries are retried with an
+	// exponential backoff and webhooks failing too often in a row are disabled
+	Deliver(context.Context) error
+}
+
+type webhookService struct {
+	repo           repository.WebhookRepository
+	client         *http.Client
+	contextTimeout time.Duration
+}
+
+func NewWebhookService(repo repository.WebhookRepository, timeout time.Duration) WebhookService {
+	return &webhookService{
+		repo:           repo,
+		client:         &http.Client{Timeout: constans.WebhookTimeout},
+		contextTimeout: timeout,
+	}
+}
+
+func (s *webhookService) Create(ctx context.Context, request model.WebhookRequest) (*model.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	eventTypes, err := normalizeEventTypes(request.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		if secret, err = randomHex(32); err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+	}
+
+	hook, err := s.repo.Create(ctx, model.Webhook{
+		URL:        request.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+	})
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+func (s *webhookService) Read(ctx context.Context) ([]model.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	hooks, err := s.repo.Read(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	return hooks, nil
+}
+
+func (s *webhookService) ReadByID(ctx context.Context, webhookID int64) (*model.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	hook, err := s.repo.ReadByID(ctx, webhookID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if hook == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	hook.Secret = ""
+
+	return hook, nil
+}
+
+func (s *webhookService) Update(ctx context.Context, request model.WebhookRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	eventTypes, err := normalizeEventTypes(request.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	hook, err := s.repo.ReadByID(ctx, request.ID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if hook == nil {
+		return constans.ErrNotFound
+	}
+
+	hook.URL = request.URL
+	hook.EventTypes = eventTypes
+	if request.Secret != "" {
+		hook.Secret = request.Secret
+	}
+
+	if err = s.repo.Update(ctx, *hook); err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if request.Active != nil {
+		if *request.Active {
+			err = s.repo.Enable(ctx, hook.ID)
+		} else {
+			err = s.repo.Disable(ctx, hook.ID, time.Now())
+		}
+
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, webhookID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	err := s.repo.Delete(ctx, webhookID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *webhookService) ReadDeliveries(ctx context.Context, webhookID int64) ([]model.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	hook, err := s.repo.ReadByID(ctx, webhookID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if hook == nil {
+		return nil, constans.ErrNotFound
+	}
+
+	deliveries, err := s.repo.ReadDeliveries(ctx, webhookID, constans.WebhookLogLimit)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, webhookID int64, deliveryID int64) (*model.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout+constans.WebhookTimeout)
+	defer cancel()
+
+	hook, err := s.repo.ReadByID(ctx, webhookID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	delivery, err := s.repo.ReadDelivery(ctx, deliveryID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+
+	if hook == nil || delivery == nil || delivery.WebhookID != hook.ID {
+		return nil, constans.ErrNotFound
+	}
+
+	if err = s.send(ctx, *hook, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (s *webhookService) Enqueue(ctx context.Context, event model.Event) error {
+	hooks, err := s.repo.ReadSubscribed(ctx, event.Type)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":         event.ID,
+		"type":       event.Type,
+		"created_at": event.CreatedAt,
+		"data":       event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		err = s.repo.CreateDelivery(ctx, model.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			NextAttemptAt: time.Now(),
+		})
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *webhookService) Deliver(ctx context.Context) error {
+	readCtx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	token, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+
+	// every instance claims its own batch, so a delivery is sent by one instance at a time
+	now := time.Now()
+	deliveries, err := s.repo.ClaimDeliveries(readCtx, token, now, now.Add(constans.WebhookLeaseTimeout), constans.WebhookBatchSize)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	hooks := make(map[int64]*model.Webhook)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		if err := s.deliver(ctx, hooks, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliver sends one pending delivery, the webhooks are cached for the batch so a
+// webhook disabled by an earlier delivery is skipped
+func (s *webhookService) deliver(ctx context.Context, hooks map[int64]*model.Webhook, delivery *model.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout+constans.WebhookTimeout)
+	defer cancel()
+
+	hook, ok := hooks[delivery.WebhookID]
+	if !ok {
+		var err error
+		if hook, err = s.repo.ReadByID(ctx, delivery.WebhookID); err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+		hooks[delivery.WebhookID] = hook
+	}
+
+	if hook == nil || !hook.Active {
+		return nil
+	}
+
+	return s.send(ctx, *hook, delivery)
+}
+
+// send makes one delivery attempt and records its outcome. A failure schedules
+// the next attempt with a doubled delay until WebhookMaxAttempts, and disables
+// the webhook after WebhookDisableAfter failures in a row.
+func (s *webhookService) send(ctx context.Context, hook model.Webhook, delivery *model.WebhookDelivery) error {
+	code, err := webhook.Send(ctx, s.client, webhook.Request{
+		URL:        hook.URL,
+		Secret:     hook.Secret,
+		Event:      delivery.EventType,
+		DeliveryID: delivery.ID,
+		Body:       []byte(delivery.Payload),
+	})
+
+	delivery.Attempts++
+	delivery.ResponseCode = code
+
+	if err == nil && code >= http.StatusOK && code < http.StatusMultipleChoices {
+		now := time.Now()
+		delivery.Status = constans.DELIVERED
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+
+		if hook.FailureCount > 0 {
+			if err := s.repo.ResetFailures(ctx, hook.ID); err != nil {
+				logger.Log.Error(err.Error())
+				return err
+			}
+		}
+
+		return s.update(ctx, delivery)
+	}
+
+	if err == nil {
+		err = fmt.Errorf("unexpected response status %d", code)
+	}
+
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > maxEventError {
+		delivery.LastError = delivery.LastError[:maxEventError]
+	}
+
+	backoff := constans.WebhookBaseBackoff << uint(delivery.Attempts-1)
+	if backoff <= 0 || backoff > constans.WebhookMaxBackoff {
+		backoff = constans.WebhookMaxBackoff
+	}
+	delivery.NextAttemptAt = time.Now().Add(backoff)
+
+	delivery.Status = constans.PENDING
+	if delivery.Attempts >= constans.WebhookMaxAttempts {
+		delivery.Status = constans.FAILED
+	}
+
+	logger.Log.Warn("webhook delivery failed",
+		zap.Int64("webhook_id", hook.ID),
+		zap.Int64("delivery_id", delivery.ID),
+		zap.Int("attempts", delivery.Attempts),
+		zap.String("error", delivery.LastError),
+	)
+
+	failures, err := s.repo.RecordFailure(ctx, hook.ID)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	if hook.Active && failures >= constans.WebhookDisableAfter {
+		if err := s.repo.Disable(ctx, hook.ID, time.Now()); err != nil {
+			logger.Log.Error(err.Error())
+			return err
+		}
+
+		logger.Log.Warn("webhook disabled after consecutive failures",
+			zap.Int64("webhook_id", hook.ID),
+			zap.Int64("failures", failures),
+		)
+	}
+
+	return s.update(ctx, delivery)
+}
+
+func (s *webhookService) update(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if err := s.repo.UpdateDelivery(ctx, *delivery); err != nil {
+		logger.Log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// normalizeEventTypes removes duplicated event types and checks they can be subscribed to
+func normalizeEventTypes(eventTypes []string) ([]string, error) {
+	var (
+		result []string
+		seen   = make(map[string]bool)
+	)
+
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if seen[eventType] {
+			continue
+		}
+
+		if !isWebhookEvent(eventType) {
+			return nil, constans.ErrBadParamInput
+		}
+
+		seen[eventType] = true
+		result = append(result, eventType)
+	}
+
+	return result, nil
+}
+
+func isWebhookEvent(eventType string) bool {
+	for _, e := range WebhookEvents {
+		if e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// randomHex returns size random bytes hex encoded
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/repository"
+	"github.com/cecepsprd/ticketing-api/utils/eventbus"
+	"github.com/cecepsprd/ticketing-api/utils/notification"
+)
+
+// sweeps are the recurring jobs and how often they run
+var sweeps = []struct {
+	jobType string
+	every   time.Duration
+}{
+	{constans.JobExpireWaitlistOffers, time.Minute},
+	{constans.JobDeleteIdempotencyKeys, time.Hour},
+	{constans.JobDeleteFinishedJobs, 24 * time.Hour},
+	{constans.JobReconcilePayments, 15 * time.Minute},
+	{constans.JobReconciliationReport, 24 * time.Hour},
+}
+
+// RegisterJobs registers the handlers of the background jobs. Notifications are
+// sent through notifier, the delivery channel behind the queued notifier.
+func RegisterJobs(jobs JobService, userRepo repository.UserRepository, notifier notification.Notifier, tickets TicketService, waitlist WaitlistService, idempotency IdempotencyService, reconciliation ReconciliationService) {
+	jobs.Handle(constans.JobSendNotification, func(ctx context.Context, job model.Job) error {
+		var payload model.NotificationJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return err
+		}
+
+		user, err := userRepo.ReadByID(ctx, payload.UserID)
+		if err != nil {
+			return err
+		}
+
+		return notifier.Notify(ctx, user, payload.Subject, payload.Message)
+	})
+
+	jobs.Handle(constans.JobGenerateTicket, func(ctx context.Context, job model.Job) error {
+		var payload model.TicketJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return err
+		}
+
+		return tickets.Generate(ctx, payload.TransactionID)
+	})
+
+	jobs.Handle(constans.JobExpireWaitlistOffers, func(ctx context.Context, job model.Job) error {
+		return waitlist.ExpireOffers(ctx)
+	})
+
+	jobs.Handle(constans.JobDeleteIdempotencyKeys, func(ctx context.Context, job model.Job) error {
+		return idempotency.DeleteExpired(ctx)
+	})
+
+	jobs.Handle(constans.JobDeleteFinishedJobs, func(ctx context.Context, job model.Job) error {
+		return jobs.DeleteFinished(ctx)
+	})
+
+	jobs.Handle(constans.JobReconcilePayments, func(ctx context.Context, job model.Job) error {
+		_, err := reconciliation.Reconcile(ctx)
+		return err
+	})
+
+	// the report runs at the start of a day and covers the day before
+	jobs.Handle(constans.JobReconciliationReport, func(ctx context.Context, job model.Job) error {
+		return reconciliation.SendReport(ctx, job.RunAt.AddDate(0, 0, -1))
+	})
+}
+
+// ScheduleSweeps queues the recurring jobs of the current period. Every period
+// has a unique key, so the servers and workers scheduling them together queue
+// each sweep only once.
+func ScheduleSweeps(ctx context.Context, jobs JobService) {
+	now := time.Now()
+	for _, sweep := range sweeps {
+		runAt := now.Truncate(sweep.every)
+		key := fmt.Sprintf("%s:%d", sweep.jobType, runAt.Unix())
+
+		// a failed schedule is logged and retried on the next tick
+		jobs.Schedule(ctx, sweep.jobType, nil, runAt, key)
+	}
+}
+
+// SubscribeJobs queues the background jobs triggered by domain events
+func SubscribeJobs(bus eventbus.Bus, jobs JobService) {
+	bus.Subscribe(constans.TransactionPaid, func(ctx context.Context, event model.Event) error {
+		return jobs.Schedule(ctx, constans.JobGenerateTicket, model.TicketJob{TransactionID: event.AggregateID},
+			time.Now(), fmt.Sprintf("%s:%d", constans.JobGenerateTicket, event.AggregateID))
+	})
+}
+
+type queuedNotifier struct {
+	jobs JobService
+}
+
+// NewQueuedNotifier returns a Notifier queueing every notification as a job, so
+// a slow or failing delivery channel is retried in the background
+func NewQueuedNotifier(jobs JobService) notification.Notifier {
+	return &queuedNotifier{
+		jobs: jobs,
+	}
+}
+
+func (n *queuedNotifier) Notify(ctx context.Context, user model.User, subject string, message string) error {
+	return n.jobs.Enqueue(ctx, constans.JobSendNotification, model.NotificationJob{
+		UserID:  user.ID,
+		Subject: subject,
+		Message: message,
+	})
+}
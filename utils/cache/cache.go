@@ -0,0 +1,17 @@
+// Package cache stores values by key for a limited time, in process memory or
+// in Redis when the cache is shared by several instances of the server.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values by key. A value is dropped once its ttl passed, a ttl of 0
+// keeps it until it is deleted or evicted.
+type Cache interface {
+	// Get returns the value of key, ok is false when there is none
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type redisCache struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis stores the values in Redis under keys starting with prefix, so the
+// instances of the server sharing the Redis also share the cache. The client
+// may be anything speaking the Redis protocol, like a local stand-in.
+func NewRedis(client redis.UniversalClient, prefix string) Cache {
+	return &redisCache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+func (c *redisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+
+	return c.client.Del(ctx, prefixed...).Err()
+}
@@ -0,0 +1,57 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+// Handler handles a domain event. Events are delivered at least once, so a
+// handler has to cope with receiving the same event again.
+type Handler func(ctx context.Context, event model.Event) error
+
+// Bus delivers domain events to the in-process subscribers of their type
+type Bus interface {
+	Subscribe(eventType string, handler Handler)
+	// Publish calls every subscriber of the event type, it returns an error when any of them failed
+	Publish(ctx context.Context, event model.Event) error
+}
+
+type bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func New() Bus {
+	return &bus{
+		handlers: make(map[string][]Handler),
+	}
+}
+
+func (b *bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *bus) Publish(ctx context.Context, event model.Event) error {
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	var failed []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d subscribers of %s failed: %v", len(failed), len(handlers), event.Type, failed[0])
+	}
+
+	return nil
+}
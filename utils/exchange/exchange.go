@@ -0,0 +1,95 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+// RatePrecision is the number of decimals exchange rates are kept with
+const RatePrecision = 12
+
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// Provider supplies exchange rates relative to a base currency, a rate is how
+// many major units of the currency one major unit of the base currency buys
+type Provider interface {
+	Rates(ctx context.Context) (base string, rates map[string]string, err error)
+}
+
+type fileProvider struct {
+	path string
+}
+
+// NewFileProvider returns a provider reading the rates from a JSON file, e.g.
+// {"base": "IDR", "rates": {"USD": "0.000064", "SGD": "0.000086"}}
+func NewFileProvider(path string) Provider {
+	return &fileProvider{path: path}
+}
+
+func (p *fileProvider) Rates(ctx context.Context) (string, map[string]string, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var file struct {
+		Base  string            `json:"base"`
+		Rates map[string]string `json:"rates"`
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return "", nil, err
+	}
+
+	return strings.ToUpper(file.Base), file.Rates, nil
+}
+
+// ParseRate parses a positive decimal exchange rate
+func ParseRate(rate string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || r.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+
+	return r, nil
+}
+
+// FormatRate formats an exchange rate with RatePrecision decimals
+func FormatRate(rate *big.Rat) string {
+	return rate.FloatString(RatePrecision)
+}
+
+// Convert converts money to currency at rate, which is the number of major
+// units of currency per major unit of the money currency. The result is
+// rounded half up to a minor unit of currency.
+func Convert(money model.Money, rate *big.Rat, currency string) model.Money {
+	amount := new(big.Rat).SetInt64(money.Amount)
+	amount.Mul(amount, rate)
+	amount.Mul(amount, new(big.Rat).SetInt64(model.MinorUnits(currency)))
+	amount.Quo(amount, new(big.Rat).SetInt64(model.MinorUnits(money.Currency)))
+
+	return model.NewMoney(round(amount), currency)
+}
+
+// round rounds a rational half away from zero to an integer
+func round(r *big.Rat) int64 {
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+
+	// (2*num + den) / (2*den) is num/den rounded half up
+	num.Mul(num, big.NewInt(2))
+	num.Add(num, den)
+	num.Quo(num, new(big.Int).Mul(den, big.NewInt(2)))
+
+	if r.Sign() < 0 {
+		num.Neg(num)
+	}
+
+	return num.Int64()
+}
@@ -0,0 +1,150 @@
+// Package hub fans messages out to the subscribers of a topic. Messages carry
+// the latest state of their topic, so a subscriber too slow to keep up only
+// misses the states that were already replaced and never holds up the others.
+package hub
+
+import (
+	"sync"
+)
+
+// Message is a state of a topic, encoded once for every subscriber. IDs never
+// go down, a message older than the last one of its topic is dropped while a
+// message with the same ID is a fresher read of the same state.
+type Message struct {
+	ID   int64
+	Data []byte
+}
+
+type Hub struct {
+	mu     sync.RWMutex
+	topics map[int64]*topic
+}
+
+type topic struct {
+	subscribers map[*Subscription]struct{}
+	// latest is the ID of the last message of the topic, its data is not kept
+	latest int64
+}
+
+// Subscription receives the messages of a topic on C until it is closed
+type Subscription struct {
+	C     <-chan Message
+	ch    chan Message
+	hub   *Hub
+	topic int64
+}
+
+func New() *Hub {
+	return &Hub{
+		topics: make(map[int64]*topic),
+	}
+}
+
+// Subscribe starts receiving the messages of a topic, the subscription has to
+// be closed once it is not read anymore
+func (h *Hub) Subscribe(topicID int64) *Subscription {
+	ch := make(chan Message, 1)
+	sub := &Subscription{C: ch, ch: ch, hub: h, topic: topicID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(topicID)
+	t.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+// Publish hands msg to every subscriber of the topic without waiting for any
+// of them, a subscriber still holding an older message gets msg instead
+func (h *Hub) Publish(topicID int64, msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(topicID)
+	if msg.ID < t.latest {
+		return
+	}
+	t.latest = msg.ID
+
+	// the sends never block, holding the lock keeps the messages of a topic in order
+	for sub := range t.subscribers {
+		sub.offer(msg)
+	}
+}
+
+// Seen records id as the latest message of a topic nobody listens to, so a
+// subscriber joining later knows which state it missed
+func (h *Hub) Seen(topicID int64, id int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(topicID)
+	if id > t.latest {
+		t.latest = id
+	}
+}
+
+// Latest returns the ID of the last message of a topic, ok is false when none
+// was seen since the hub started
+func (h *Hub) Latest(topicID int64) (id int64, ok bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	t, ok := h.topics[topicID]
+	if !ok || t.latest == 0 {
+		return 0, false
+	}
+
+	return t.latest, true
+}
+
+// Subscribers counts the subscriptions of a topic
+func (h *Hub) Subscribers(topicID int64) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if t, ok := h.topics[topicID]; ok {
+		return len(t.subscribers)
+	}
+
+	return 0
+}
+
+// topic returns a topic, creating it when needed. The write lock must be held.
+func (h *Hub) topic(topicID int64) *topic {
+	t, ok := h.topics[topicID]
+	if !ok {
+		t = &topic{subscribers: make(map[*Subscription]struct{})}
+		h.topics[topicID] = t
+	}
+
+	return t
+}
+
+// Close stops the subscription, C is not closed so a reader never mistakes it
+// for a message
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if t, ok := s.hub.topics[s.topic]; ok {
+		delete(t.subscribers, s)
+	}
+}
+
+// offer replaces the message waiting in the subscription with msg
+func (s *Subscription) offer(msg Message) {
+	for {
+		select {
+		case s.ch <- msg:
+			return
+		default:
+		}
+
+		select {
+		case <-s.ch:
+		default:
+		}
+	}
+}
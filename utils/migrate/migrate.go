@@ -0,0 +1,485 @@
+// Package migrate applies the versioned SQL migrations to a MySQL, PostgreSQL
+// or SQLite database and keeps track of them in the schema_migrations table.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cecepsprd/ticketing-api/constans"
+)
+
+const (
+	// LockName is the advisory lock held while migrating, so instances started
+	// at the same time do not apply the same migration twice
+	LockName = "schema_migrations"
+	// LockTimeout is how many seconds an instance waits for another one to finish migrating
+	LockTimeout = 300
+)
+
+var (
+	ErrLocked           = errors.New("another instance is migrating the database")
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	ErrMissingFile      = errors.New("applied migration has no file")
+	ErrInvalidName      = errors.New("invalid migration name")
+
+	fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	slug     = regexp.MustCompile(`[^a-z0-9]+`)
+
+	createTable = `CREATE TABLE IF NOT EXISTS schema_migrations(
+		version BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (version)
+	)`
+	selectApplied = `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`
+	insertApplied = `INSERT INTO schema_migrations (version, name, checksum) VALUES (?,?,?)`
+	deleteApplied = `DELETE FROM schema_migrations WHERE version=?`
+	getLock       = `SELECT GET_LOCK(?, ?)`
+	releaseLock   = `SELECT RELEASE_LOCK(?)`
+
+	// PostgreSQL numbers its placeholders and takes its advisory locks by number
+	pgInsertApplied = `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1,$2,$3)`
+	pgDeleteApplied = `DELETE FROM schema_migrations WHERE version=$1`
+	pgTryLock       = `SELECT pg_try_advisory_lock(hashtext($1))`
+	pgReleaseLock   = `SELECT pg_advisory_unlock(hashtext($1))`
+)
+
+// Migration is a versioned schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status is the state of a migration in a database
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	Modified  bool       `json:"modified"`
+	Missing   bool       `json:"missing"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type applied struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies the migrations of a file system to a database
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// New loads the migrations of fsys for a database of driver, it fails when a
+// version has no up file or is used twice
+func New(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// Load reads the migrations of fsys ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidName, entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %d is used by %s and %s", ErrInvalidName, version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("%w: version %d has no up file", ErrInvalidName, migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies the pending migrations in order and returns the applied ones
+func (m *Migrator) Up(ctx context.Context) (done []Migration, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn, history map[int64]applied) error {
+		if err := m.verify(history); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := history[migration.Version]; ok {
+				continue
+			}
+
+			if err := execute(ctx, conn, migration.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err := conn.ExecContext(ctx, m.query(insertApplied), migration.Version, migration.Name, migration.Checksum); err != nil {
+				return err
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns the reverted ones
+func (m *Migrator) Down(ctx context.Context, steps int) (done []Migration, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn, history map[int64]applied) error {
+		if err := m.verify(history); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := history[migration.Version]; !ok {
+				continue
+			}
+
+			if err := execute(ctx, conn, migration.Down); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err := conn.ExecContext(ctx, m.query(deleteApplied), migration.Version); err != nil {
+				return err
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Baseline records the migrations up to version as applied without running
+// them, for databases created before the migrations were tracked
+func (m *Migrator) Baseline(ctx context.Context, version int64) (done []Migration, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn, history map[int64]applied) error {
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := history[migration.Version]; ok {
+				continue
+			}
+
+			if _, err := conn.ExecContext(ctx, m.query(insertApplied), migration.Version, migration.Name, migration.Checksum); err != nil {
+				return err
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Status lists every known migration with whether it was applied, and the
+// applied ones that were modified or whose file is gone
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if _, err := m.db.ExecContext(ctx, createTable); err != nil {
+		return nil, err
+	}
+
+	history, err := readApplied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := history[migration.Version]; ok {
+			appliedAt := row.appliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Modified = row.checksum != migration.Checksum
+			delete(history, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, row := range history {
+		appliedAt := row.appliedAt
+		statuses = append(statuses, Status{Version: version, Name: row.name, Applied: true, Missing: true, AppliedAt: &appliedAt})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Create writes an empty up and down file for the next version in dir and
+// returns their paths
+func Create(dir, name string) (up string, down string, err error) {
+	name = strings.Trim(slug.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("%w: name is empty", ErrInvalidName)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%06d_%s", version, name))
+	up, down = base+".up.sql", base+".down.sql"
+
+	if err = os.WriteFile(up, []byte{}, 0644); err != nil {
+		return "", "", err
+	}
+	if err = os.WriteFile(down, []byte{}, 0644); err != nil {
+		return "", "", err
+	}
+
+	return up, down, nil
+}
+
+// locked runs fn on a single connection holding the migration lock
+func (m *Migrator) locked(ctx context.Context, fn func(*sql.Conn, map[int64]applied) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	release, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, err = conn.ExecContext(ctx, createTable); err != nil {
+		return err
+	}
+
+	// read after locking, another instance may have migrated while we waited
+	history, err := readApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, history)
+}
+
+// lock takes the advisory lock of the database on conn and returns its release
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	switch m.driver {
+	case constans.DriverPostgres:
+		deadline := time.Now().Add(LockTimeout * time.Second)
+		for {
+			var acquired bool
+			if err := conn.QueryRowContext(ctx, pgTryLock, LockName).Scan(&acquired); err != nil {
+				return nil, err
+			}
+			if acquired {
+				return func() { conn.ExecContext(context.Background(), pgReleaseLock, LockName) }, nil
+			}
+			if time.Now().After(deadline) {
+				return nil, ErrLocked
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+	case constans.DriverSQLite:
+		// a database file is only migrated by the process that opened it
+		return func() {}, nil
+	}
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, getLock, LockName, LockTimeout).Scan(&acquired); err != nil {
+		return nil, err
+	}
+	if acquired.Int64 != 1 {
+		return nil, ErrLocked
+	}
+
+	return func() { conn.ExecContext(context.Background(), releaseLock, LockName) }, nil
+}
+
+// query returns the statement of the driver, PostgreSQL numbers its placeholders
+func (m *Migrator) query(query string) string {
+	if m.driver != constans.DriverPostgres {
+		return query
+	}
+
+	switch query {
+	case insertApplied:
+		return pgInsertApplied
+	case deleteApplied:
+		return pgDeleteApplied
+	}
+
+	return query
+}
+
+// verify refuses to migrate when an applied migration was changed or removed
+func (m *Migrator) verify(history map[int64]applied) error {
+	known := map[int64]bool{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		if row, ok := history[migration.Version]; ok && row.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+
+	for version, row := range history {
+		if !known[version] {
+			return fmt.Errorf("%w: %d_%s", ErrMissingFile, version, row.name)
+		}
+	}
+
+	return nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func readApplied(ctx context.Context, db queryer) (map[int64]applied, error) {
+	rows, err := db.QueryContext(ctx, selectApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := map[int64]applied{}
+	for rows.Next() {
+		var (
+			version int64
+			row     applied
+		)
+		if err = rows.Scan(&version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		history[version] = row
+	}
+
+	return history, rows.Err()
+}
+
+// execute runs the statements of a migration one by one, MySQL commits DDL
+// implicitly so a failed migration is not rolled back and must be fixed by hand
+func execute(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, statement := range Split(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w\n%s", err, statement)
+		}
+	}
+
+	return nil
+}
+
+// Split splits a script into its statements. Statements end with a semicolon
+// at the end of a line and lines starting with -- are comments.
+func Split(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(line, ";") {
+			statement := strings.TrimSuffix(strings.TrimSpace(current.String()), ";")
+			if statement != "" {
+				statements = append(statements, statement)
+			}
+			current.Reset()
+		}
+	}
+
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+
+	return statements
+}
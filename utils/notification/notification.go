@@ -0,0 +1,33 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/utils/logger"
+	"go.uber.org/zap"
+)
+
+// Notifier delivers a message to a user, e.g. by email or push notification
+type Notifier interface {
+	Notify(ctx context.Context, user model.User, subject string, message string) error
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a Notifier writing every notification to the application log,
+// it is used until a real delivery channel is configured
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, user model.User, subject string, message string) error {
+	logger.Log.Info("notification",
+		zap.Int64("user_id", user.ID),
+		zap.String("email", user.Email),
+		zap.String("subject", subject),
+		zap.String("message", message),
+	)
+
+	return nil
+}
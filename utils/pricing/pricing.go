@@ -0,0 +1,97 @@
+package pricing
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cecepsprd/ticketing-api/model"
+)
+
+// Rules describes the fees and taxes added on top of a ticket price. Rates are
+// in basis points (1/100 of a percent), amounts are in minor units.
+//
+// Every computed amount (fee, surcharge, tax and percentage discount) is rounded
+// half up to a minor unit first and then half up to the rounding step of the
+// currency, so a price is always rounded the same way wherever it is computed.
+type Rules struct {
+	// PlatformFeeBPS is the platform fee rate on the discounted subtotal
+	PlatformFeeBPS int64
+	// PlatformFeeFixed is a flat platform fee per transaction, only charged on prices in FeeCurrency
+	PlatformFeeFixed int64
+	// FeeCurrency is the currency of PlatformFeeFixed
+	FeeCurrency string
+	// TaxBPS is the VAT rate applied on the subtotal and all fees
+	TaxBPS int64
+	// Surcharges are the payment method surcharge rates, keyed by payment method
+	Surcharges map[string]int64
+	// RoundTo is the smallest payable amount per currency, e.g. 100 to charge whole rupiah
+	RoundTo map[string]int64
+}
+
+// Calculate returns the price breakdown of quantity tickets of unitPrice with
+// the given discount in minor units, paid with paymentMethod
+func (r Rules) Calculate(unitPrice model.Money, quantity int64, discount int64, paymentMethod string) model.PriceBreakdown {
+	currency := unitPrice.Currency
+
+	b := model.PriceBreakdown{
+		Currency:  currency,
+		UnitPrice: unitPrice.Amount,
+		Quantity:  quantity,
+		Subtotal:  unitPrice.Amount * quantity,
+		Discount:  r.Round(currency, discount),
+	}
+
+	if b.Discount > b.Subtotal {
+		b.Discount = b.Subtotal
+	}
+
+	net := b.Subtotal - b.Discount
+	if net > 0 {
+		b.Fee = r.Round(currency, Rate(net, r.PlatformFeeBPS))
+		if currency == r.FeeCurrency {
+			b.Fee += r.PlatformFeeFixed
+		}
+		b.Surcharge = r.Round(currency, Rate(net+b.Fee, r.Surcharges[paymentMethod]))
+	}
+	b.Tax = r.Round(currency, Rate(net+b.Fee+b.Surcharge, r.TaxBPS))
+	b.Total = net + b.Fee + b.Surcharge + b.Tax
+
+	return b
+}
+
+// Round rounds an amount half up to a multiple of the rounding step of currency
+func (r Rules) Round(currency string, amount int64) int64 {
+	step := r.RoundTo[currency]
+	if step <= 1 {
+		return amount
+	}
+
+	return (amount + step/2) / step * step
+}
+
+// Rate applies a basis points rate on an amount, rounding half up to a minor unit
+func Rate(amount int64, bps int64) int64 {
+	return (amount*bps + 5000) / 10000
+}
+
+// ParseValues reads values formatted as key=value pairs, e.g. the surcharges
+// "credit_card=290,gopay=200" or the rounding steps "IDR=100"
+func ParseValues(value string) map[string]int64 {
+	values := make(map[string]int64)
+
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		v, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		values[strings.TrimSpace(kv[0])] = v
+	}
+
+	return values
+}
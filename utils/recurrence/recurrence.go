@@ -0,0 +1,188 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+
+	// MaxOccurrences limits the number of dates a single rule can expand to
+	MaxOccurrences = 366
+)
+
+var (
+	ErrInvalidRule = errors.New("invalid recurrence rule")
+
+	weekdays = map[string]time.Weekday{
+		"SU": time.Sunday,
+		"MO": time.Monday,
+		"TU": time.Tuesday,
+		"WE": time.Wednesday,
+		"TH": time.Thursday,
+		"FR": time.Friday,
+		"SA": time.Saturday,
+	}
+)
+
+// Rule is the subset of an iCalendar RRULE supported for event sessions
+type Rule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    []time.Weekday
+}
+
+// Parse reads a rule such as FREQ=WEEKLY;INTERVAL=1;COUNT=10;BYDAY=MO,WE.
+// Either COUNT or UNTIL is required so the rule always terminates.
+func Parse(rule string) (Rule, error) {
+	r := Rule{Interval: 1}
+
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return Rule{}, fmt.Errorf("%w: %s", ErrInvalidRule, part)
+		}
+
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		switch key {
+		case "FREQ":
+			if value != Daily && value != Weekly && value != Monthly {
+				return Rule{}, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRule, value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return Rule{}, fmt.Errorf("%w: INTERVAL must be a positive number", ErrInvalidRule)
+			}
+			r.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return Rule{}, fmt.Errorf("%w: COUNT must be a positive number", ErrInvalidRule)
+			}
+			r.Count = count
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return Rule{}, fmt.Errorf("%w: UNTIL %s", ErrInvalidRule, value)
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return Rule{}, fmt.Errorf("%w: BYDAY %s", ErrInvalidRule, day)
+				}
+				r.ByDay = append(r.ByDay, weekday)
+			}
+		default:
+			return Rule{}, fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, key)
+		}
+	}
+
+	if r.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+
+	if r.Count == 0 && r.Until.IsZero() {
+		return Rule{}, fmt.Errorf("%w: COUNT or UNTIL is required", ErrInvalidRule)
+	}
+
+	if len(r.ByDay) > 0 && r.Freq != Weekly {
+		return Rule{}, fmt.Errorf("%w: BYDAY is only supported with FREQ=WEEKLY", ErrInvalidRule)
+	}
+
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// a date only UNTIL includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+
+	return time.Time{}, ErrInvalidRule
+}
+
+// Expand returns the start times of every occurrence of the rule, the first
+// occurrence being start itself when it matches the rule
+func (r Rule) Expand(start time.Time) []time.Time {
+	var result []time.Time
+
+	add := func(t time.Time) bool {
+		if t.Before(start) {
+			return true
+		}
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		result = append(result, t)
+		return (r.Count == 0 || len(result) < r.Count) && len(result) < MaxOccurrences
+	}
+
+	for period := 0; ; period++ {
+		switch r.Freq {
+		case Daily:
+			if !add(start.AddDate(0, 0, period*r.Interval)) {
+				return result
+			}
+		case Monthly:
+			if !add(start.AddDate(0, period*r.Interval, 0)) {
+				return result
+			}
+		case Weekly:
+			week := start.AddDate(0, 0, period*7*r.Interval)
+			if len(r.ByDay) == 0 {
+				if !add(week) {
+					return result
+				}
+				continue
+			}
+
+			// walk the week starting on the weekday of the first session
+			for offset := 0; offset < 7; offset++ {
+				day := week.AddDate(0, 0, offset)
+				if !r.matchesDay(day.Weekday()) {
+					continue
+				}
+				if !add(day) {
+					return result
+				}
+			}
+		}
+
+		// guards against rules that never produce an occurrence
+		if period > MaxOccurrences*7 {
+			return result
+		}
+	}
+}
+
+func (r Rule) matchesDay(weekday time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d == weekday {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package ticket
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Field is a labelled line of a ticket
+type Field struct {
+	Label string
+	Value string
+}
+
+// Render returns a single page A4 PDF with the title and fields of a ticket,
+// written with the standard Helvetica fonts so no font has to be embedded
+func Render(title string, fields []Field) []byte {
+	var content bytes.Buffer
+
+	fmt.Fprintf(&content, "BT /F2 22 Tf 50 780 Td (%s) Tj ET\n", escape(title))
+	fmt.Fprintf(&content, "50 765 m 545 765 l S\n")
+
+	y := 735
+	for _, field := range fields {
+		fmt.Fprintf(&content, "BT /F2 11 Tf 50 %d Td (%s) Tj ET\n", y, escape(field.Label))
+		fmt.Fprintf(&content, "BT /F1 11 Tf 180 %d Td (%s) Tj ET\n", y, escape(field.Value))
+		y -= 22
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Contents 4 0 R /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var (
+		pdf     bytes.Buffer
+		offsets []int
+	)
+
+	pdf.WriteString("%PDF-1.4\n")
+	for i, object := range objects {
+		offsets = append(offsets, pdf.Len())
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return pdf.Bytes()
+}
+
+// escape quotes a PDF string, characters outside of printable ASCII are replaced
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
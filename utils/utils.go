@@ -2,11 +2,14 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/cecepsprd/ticketing-api/constans"
 	"github.com/cecepsprd/ticketing-api/model"
+	"github.com/cecepsprd/ticketing-api/utils/exchange"
+	"github.com/cecepsprd/ticketing-api/utils/recurrence"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 	"golang.org/x/crypto/bcrypt"
@@ -19,6 +22,7 @@ func GetUserByContext(c echo.Context) model.User {
 		Username: claims["username"].(string),
 		Email:    claims["email"].(string),
 		Phone:    claims["phone"].(string),
+		Roles:    claims["roles"].(string),
 	}
 }
 
@@ -50,13 +54,28 @@ func SetHTTPStatusCode(err error) int {
 	if err == nil {
 		return http.StatusOK
 	}
+	if errors.Is(err, recurrence.ErrInvalidRule) || errors.Is(err, exchange.ErrInvalidRate) {
+		return http.StatusBadRequest
+	}
 	switch err {
 	case constans.ErrInternalServerError:
 		return http.StatusInternalServerError
 	case constans.ErrNotFound:
 		return http.StatusNotFound
-	case constans.ErrConflict:
+	case constans.ErrConflict, constans.ErrSeatNotAvailable, constans.ErrNotSoldOut, constans.ErrTicketRunOut, constans.ErrProductHasTickets,
+		constans.ErrVoucherExhausted, constans.ErrIdempotencyKeyReused, constans.ErrIdempotencyInProgress, constans.ErrTicketNotIssued,
+		constans.ErrAdmissionExpired, constans.ErrSeatMapHasSessions, constans.ErrSessionsHaveSeats:
 		return http.StatusConflict
+	case constans.ErrBadParamInput, constans.ErrSeatRequired, constans.ErrVenueHasNoSeats,
+		constans.ErrCategoryNotFound, constans.ErrTagInvalid, constans.ErrSessionRequired, constans.ErrSessionClosed, constans.ErrPaymentMethodRequired,
+		constans.ErrVoucherInvalid, constans.ErrVoucherNotApplicable, constans.ErrCurrencyNotSupported, constans.ErrQueueTokenInvalid:
+		return http.StatusBadRequest
+	case constans.ErrRateProviderUnavailable:
+		return http.StatusServiceUnavailable
+	case constans.ErrInvalidSignature:
+		return http.StatusUnauthorized
+	case constans.ErrAdmissionRequired:
+		return http.StatusForbidden
 	case constans.ErrWrongEmailOrPassword:
 		return http.StatusBadRequest
 	default: